@@ -0,0 +1,45 @@
+package mcplogchef
+
+import (
+	"context"
+	"sync"
+)
+
+// AuditQuery is a query a tool ran against Logchef, recorded for the audit log.
+type AuditQuery struct {
+	SQL  string `json:"sql"`
+	Rows int    `json:"rows"`
+}
+
+// AuditRecorder collects the queries run during a single tool call.
+type AuditRecorder struct {
+	mu      sync.Mutex
+	queries []AuditQuery
+}
+
+// Queries returns the queries recorded so far.
+func (r *AuditRecorder) Queries() []AuditQuery {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]AuditQuery(nil), r.queries...)
+}
+
+type auditRecorderKey struct{}
+
+// WithAuditRecorder adds a new AuditRecorder to the context and returns both.
+func WithAuditRecorder(ctx context.Context) (context.Context, *AuditRecorder) {
+	r := &AuditRecorder{}
+	return context.WithValue(ctx, auditRecorderKey{}, r), r
+}
+
+// RecordQuery notes a query run by a tool, with the number of rows it
+// returned. It does nothing when the call is not being audited.
+func RecordQuery(ctx context.Context, sql string, rows int) {
+	r, ok := ctx.Value(auditRecorderKey{}).(*AuditRecorder)
+	if !ok {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.queries = append(r.queries, AuditQuery{SQL: sql, Rows: rows})
+}
@@ -0,0 +1,88 @@
+package mcplogchef
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// AuthConfig controls how MCP clients authenticate to the SSE and
+// streamable-http transports.
+type AuthConfig struct {
+	// Tokens are the shared secrets accepted as "Authorization: Bearer
+	// <token>". Bearer authentication is disabled when empty.
+	Tokens []string
+	// RequireAPIKeyHeader rejects requests without an X-Logchef-API-Key
+	// header, so sessions always use the caller's own Logchef credentials
+	// and never fall back to the server's LOGCHEF_API_KEY.
+	RequireAPIKeyHeader bool
+}
+
+// Enabled reports whether any authentication is configured.
+func (a AuthConfig) Enabled() bool {
+	return len(a.Tokens) > 0 || a.RequireAPIKeyHeader
+}
+
+// validToken reports whether token matches one of the configured tokens,
+// comparing in constant time.
+func (a AuthConfig) validToken(token string) bool {
+	ok := false
+	for _, t := range a.Tokens {
+		if subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+			ok = true
+		}
+	}
+	return ok
+}
+
+type logchefHeaderAPIKeyOnlyKey struct{}
+
+// headerAPIKeyOnly reports whether the request was admitted by RequireAuth
+// with RequireAPIKeyHeader set, in which case the environment and config
+// file API key must not be used.
+func headerAPIKeyOnly(ctx context.Context) bool {
+	only, _ := ctx.Value(logchefHeaderAPIKeyOnlyKey{}).(bool)
+	return only
+}
+
+// RequireAuth wraps an MCP HTTP handler, rejecting requests that do not
+// satisfy cfg with 401 Unauthorized.
+func RequireAuth(next http.Handler, cfg AuthConfig) http.Handler {
+	if !cfg.Enabled() {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(cfg.Tokens) > 0 {
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || token == "" {
+				unauthorized(w, r, `Bearer realm="logchef-mcp"`, "missing bearer token")
+				return
+			}
+			if !cfg.validToken(strings.TrimSpace(token)) {
+				unauthorized(w, r, `Bearer realm="logchef-mcp", error="invalid_token"`, "invalid bearer token")
+				return
+			}
+		}
+		if cfg.RequireAPIKeyHeader {
+			if r.Header.Get(logchefAPIKeyHeader) == "" {
+				unauthorized(w, r, "", "missing "+logchefAPIKeyHeader+" header")
+				return
+			}
+			r = r.WithContext(context.WithValue(r.Context(), logchefHeaderAPIKeyOnlyKey{}, true))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func unauthorized(w http.ResponseWriter, r *http.Request, challenge, msg string) {
+	slog.Warn("Rejected unauthenticated request", "remote_addr", r.RemoteAddr, "path", r.URL.Path, "reason", msg)
+	if challenge != "" {
+		w.Header().Set("WWW-Authenticate", challenge)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnauthorized)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
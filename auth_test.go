@@ -0,0 +1,76 @@
+package mcplogchef_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	mcplogchef "github.com/mr-karan/logchef-mcp"
+)
+
+func TestRequireAuth(t *testing.T) {
+	t.Setenv("LOGCHEF_API_KEY", "operator-key")
+
+	var gotKey string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := mcplogchef.ExtractLogchefInfoFromHeaders(context.Background(), r)
+		gotKey = mcplogchef.LogchefAPIKeyFromContext(ctx)
+	})
+
+	tests := []struct {
+		name       string
+		cfg        mcplogchef.AuthConfig
+		headers    map[string]string
+		wantStatus int
+		wantKey    string
+		challenge  string
+	}{
+		{name: "disabled", wantStatus: http.StatusOK, wantKey: "operator-key"},
+		{
+			name: "missing token", cfg: mcplogchef.AuthConfig{Tokens: []string{"s3cret"}},
+			wantStatus: http.StatusUnauthorized, challenge: `Bearer realm="logchef-mcp"`,
+		},
+		{
+			name: "wrong token", cfg: mcplogchef.AuthConfig{Tokens: []string{"s3cret"}},
+			headers:    map[string]string{"Authorization": "Bearer nope"},
+			wantStatus: http.StatusUnauthorized, challenge: `error="invalid_token"`,
+		},
+		{
+			name: "valid token", cfg: mcplogchef.AuthConfig{Tokens: []string{"other", "s3cret"}},
+			headers:    map[string]string{"Authorization": "Bearer s3cret"},
+			wantStatus: http.StatusOK, wantKey: "operator-key",
+		},
+		{
+			name: "missing api key header", cfg: mcplogchef.AuthConfig{RequireAPIKeyHeader: true},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "api key header", cfg: mcplogchef.AuthConfig{RequireAPIKeyHeader: true},
+			headers:    map[string]string{"X-Logchef-API-Key": "caller-key"},
+			wantStatus: http.StatusOK, wantKey: "caller-key",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotKey = ""
+			req := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			mcplogchef.RequireAuth(next, tt.cfg).ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if gotKey != tt.wantKey {
+				t.Errorf("session API key = %q, want %q", gotKey, tt.wantKey)
+			}
+			if got := rec.Header().Get("WWW-Authenticate"); !strings.Contains(got, tt.challenge) {
+				t.Errorf("WWW-Authenticate = %q, want %q", got, tt.challenge)
+			}
+		})
+	}
+}
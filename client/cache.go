@@ -0,0 +1,162 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Default TTLs used when the corresponding CacheConfig field is zero.
+const (
+	defaultProfileTTL = time.Minute
+	defaultTeamsTTL   = time.Minute
+	defaultSourcesTTL = 2 * time.Minute
+	defaultSchemaTTL  = 5 * time.Minute
+	defaultMetaTTL    = 10 * time.Minute
+)
+
+// maxCacheEntries triggers a sweep of expired entries once exceeded.
+const maxCacheEntries = 1024
+
+// CacheConfig controls the in-memory cache of slow-changing Logchef metadata
+// (profile, teams, team sources, source schemas and server meta). The cache is
+// shared by all clients in the process; entries are scoped by base URL and
+// API key so sessions never see each other's data.
+type CacheConfig struct {
+	// Disabled turns the cache off entirely.
+	Disabled bool
+
+	ProfileTTL time.Duration
+	TeamsTTL   time.Duration
+	SourcesTTL time.Duration
+	SchemaTTL  time.Duration
+	MetaTTL    time.Duration
+}
+
+func (c *CacheConfig) setDefaults() {
+	if c.ProfileTTL <= 0 {
+		c.ProfileTTL = defaultProfileTTL
+	}
+	if c.TeamsTTL <= 0 {
+		c.TeamsTTL = defaultTeamsTTL
+	}
+	if c.SourcesTTL <= 0 {
+		c.SourcesTTL = defaultSourcesTTL
+	}
+	if c.SchemaTTL <= 0 {
+		c.SchemaTTL = defaultSchemaTTL
+	}
+	if c.MetaTTL <= 0 {
+		c.MetaTTL = defaultMetaTTL
+	}
+}
+
+// sharedCache holds cached metadata for every client in the process.
+var sharedCache = &metadataCache{entries: map[string]cacheEntry{}}
+
+type cacheEntry struct {
+	value   any
+	expires time.Time
+}
+
+// metadataCache is a TTL cache with singleflight de-duplication, so concurrent
+// identical requests result in a single Logchef call.
+type metadataCache struct {
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+	group   singleflight.Group
+}
+
+func (m *metadataCache) get(key string) (any, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.entries[key]
+	if !ok || time.Now().After(e.expires) {
+		return nil, false
+	}
+	return e.value, true
+}
+
+func (m *metadataCache) set(key string, value any, ttl time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	if len(m.entries) >= maxCacheEntries {
+		for k, e := range m.entries {
+			if now.After(e.expires) {
+				delete(m.entries, k)
+			}
+		}
+	}
+	m.entries[key] = cacheEntry{value: value, expires: now.Add(ttl)}
+}
+
+// deletePrefix removes every entry whose key starts with prefix.
+func (m *metadataCache) deletePrefix(prefix string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for k := range m.entries {
+		if strings.HasPrefix(k, prefix) {
+			delete(m.entries, k)
+		}
+	}
+}
+
+// cacheInstancePrefix scopes cache keys to a Logchef instance.
+func (c *Client) cacheInstancePrefix() string {
+	return strings.TrimRight(c.config.BaseURL, "/") + "|"
+}
+
+// cacheKey scopes key to this client's Logchef instance and API key. The key
+// is hashed so it never sits in memory next to the base URL in plain text.
+func (c *Client) cacheKey(key string) string {
+	sum := sha256.Sum256([]byte(c.config.APIKey))
+	return c.cacheInstancePrefix() + hex.EncodeToString(sum[:8]) + "|" + key
+}
+
+// cached returns the cached value for key or calls fetch, caching the result
+// for ttl. Values returned from the cache are shared and must not be modified.
+func cached[T any](ctx context.Context, c *Client, key string, ttl time.Duration, fetch func(context.Context) (*T, error)) (*T, error) {
+	if c.config.Cache.Disabled {
+		return fetch(ctx)
+	}
+
+	fullKey := c.cacheKey(key)
+	if v, ok := sharedCache.get(fullKey); ok {
+		slog.Debug("Logchef cache hit", "key", key)
+		return v.(*T), nil
+	}
+
+	// The shared fetch must not be cancelled by whichever caller happened to
+	// start it, so it runs detached and each caller waits on its own context.
+	ch := sharedCache.group.DoChan(fullKey, func() (any, error) {
+		v, err := fetch(context.WithoutCancel(ctx))
+		if err != nil {
+			return nil, err
+		}
+		sharedCache.set(fullKey, v, ttl)
+		return v, nil
+	})
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.(*T), nil
+	}
+}
+
+// InvalidateCache drops all cached metadata for this client's Logchef
+// instance, across every API key. Tools call it after mutating teams, users,
+// sources or memberships, since those changes are visible to other users too.
+func (c *Client) InvalidateCache() {
+	sharedCache.deletePrefix(c.cacheInstancePrefix())
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	neturl "net/url"
 	"time"
@@ -16,12 +17,30 @@ type Config struct {
 	BaseURL string
 	APIKey  string
 	Timeout time.Duration
+	// Retry controls retries of idempotent requests. Zero fields use defaults.
+	Retry RetryPolicy
+	// Limits bounds request rate and concurrency against BaseURL. The limiter
+	// is shared by every client with the same BaseURL and Limits.
+	Limits Limits
+	// Cache controls caching of profile, teams, sources, schema and meta.
+	Cache CacheConfig
+	// Debug logs every request and response (with credentials scrubbed) and
+	// each retry attempt via slog.
+	Debug bool
+	// Transport sends each HTTP request, including retries. Nil uses
+	// http.DefaultTransport. The debug transport wraps it when Debug is set.
+	// Use NewTransport for a custom CA, client certificates or a proxy.
+	Transport http.RoundTripper
+	// Headers are added to every request. They cannot override the
+	// Authorization and Content-Type headers set by the client.
+	Headers map[string]string
 }
 
 // Client represents a Logchef API client
 type Client struct {
 	config     Config
 	httpClient *http.Client
+	limiter    *limiter
 }
 
 // New creates a new Logchef client
@@ -29,13 +48,131 @@ func New(config Config) *Client {
 	if config.Timeout == 0 {
 		config.Timeout = 30 * time.Second
 	}
+	config.Retry.setDefaults()
+	config.Cache.setDefaults()
+
+	httpClient := &http.Client{
+		Timeout:   config.Timeout,
+		Transport: config.Transport,
+	}
+	if config.Debug {
+		httpClient.Transport = newDebugTransport(config.Transport)
+	}
 
 	return &Client{
-		config: config,
-		httpClient: &http.Client{
-			Timeout: config.Timeout,
-		},
+		config:     config,
+		httpClient: httpClient,
+		limiter:    sharedLimiter(config.BaseURL, config.Limits),
+	}
+}
+
+// apiRequest describes a single call to the Logchef API.
+type apiRequest struct {
+	method string
+	path   string
+	query  neturl.Values
+	body   any
+	// readOnly marks a POST that only reads data (e.g. a log query), making
+	// it safe to retry like a GET.
+	readOnly bool
+}
+
+// do sends r to Logchef and decodes a successful JSON response into out,
+// which may be nil for endpoints whose response body is ignored. Any non-2xx
+// response is returned as an *APIError. Transient failures of idempotent
+// requests are retried according to the client's RetryPolicy.
+func (c *Client) do(ctx context.Context, r apiRequest, out any) error {
+	u := c.config.BaseURL + r.path
+	if len(r.query) > 0 {
+		u += "?" + r.query.Encode()
+	}
+
+	var payload []byte
+	if r.body != nil {
+		b, err := json.Marshal(r.body)
+		if err != nil {
+			return fmt.Errorf("marshal request: %w", err)
+		}
+		payload = b
+	}
+
+	maxAttempts := 1
+	if r.method == http.MethodGet || r.readOnly {
+		maxAttempts = c.config.Retry.MaxAttempts
+	}
+
+	for attempt := 1; ; attempt++ {
+		respBody, err := c.send(ctx, r, u, payload)
+		if err == nil {
+			if out == nil || len(respBody) == 0 {
+				return nil
+			}
+			if err := json.Unmarshal(respBody, out); err != nil {
+				return fmt.Errorf("unmarshal response: %w", err)
+			}
+			return nil
+		}
+
+		if attempt >= maxAttempts || !isRetryable(ctx, err) {
+			return err
+		}
+		wait, ok := c.config.Retry.delay(attempt, err)
+		if !ok {
+			return err
+		}
+		if c.config.Debug {
+			slog.Info("Retrying Logchef request",
+				"method", r.method, "path", r.path,
+				"attempt", attempt, "max_attempts", maxAttempts,
+				"wait", wait, "error", err)
+		}
+		if err := sleep(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+// send performs a single HTTP round trip and returns the response body of a
+// successful request.
+func (c *Client) send(ctx context.Context, r apiRequest, u string, payload []byte) ([]byte, error) {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, r.method, u, body)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	for k, v := range c.config.Headers {
+		req.Header.Set(k, v)
+	}
+	if c.config.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	release, err := c.limiter.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response body: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, newAPIError(resp, r.method, r.path, respBody)
 	}
+	return respBody, nil
 }
 
 // ProfileResponse represents the response from the /api/v1/me endpoint
@@ -444,160 +581,56 @@ type SourceValidationResponse struct {
 
 // GetProfile retrieves the current user profile
 func (c *Client) GetProfile(ctx context.Context) (*ProfileResponse, error) {
-	url := fmt.Sprintf("%s/api/v1/me", c.config.BaseURL)
-
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.config.APIKey))
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("make request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("read response body: %w", err)
-	}
-
-	var profile ProfileResponse
-	if err := json.Unmarshal(body, &profile); err != nil {
-		return nil, fmt.Errorf("unmarshal response: %w", err)
-	}
-
-	return &profile, nil
+	return cached(ctx, c, "profile", c.config.Cache.ProfileTTL, func(ctx context.Context) (*ProfileResponse, error) {
+		var profile ProfileResponse
+		if err := c.do(ctx, apiRequest{method: http.MethodGet, path: "/api/v1/me"}, &profile); err != nil {
+			return nil, err
+		}
+		return &profile, nil
+	})
 }
 
 // GetTeams retrieves the teams that the current user belongs to
 func (c *Client) GetTeams(ctx context.Context) (*TeamsResponse, error) {
-	url := fmt.Sprintf("%s/api/v1/me/teams", c.config.BaseURL)
-
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.config.APIKey))
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("make request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("read response body: %w", err)
-	}
-
-	var teams TeamsResponse
-	if err := json.Unmarshal(body, &teams); err != nil {
-		return nil, fmt.Errorf("unmarshal response: %w", err)
-	}
-
-	return &teams, nil
+	return cached(ctx, c, "teams", c.config.Cache.TeamsTTL, func(ctx context.Context) (*TeamsResponse, error) {
+		var teams TeamsResponse
+		if err := c.do(ctx, apiRequest{method: http.MethodGet, path: "/api/v1/me/teams"}, &teams); err != nil {
+			return nil, err
+		}
+		return &teams, nil
+	})
 }
 
 // GetTeamSources retrieves the sources that belong to a specific team
 func (c *Client) GetTeamSources(ctx context.Context, teamID int) (*TeamSourcesResponse, error) {
-	url := fmt.Sprintf("%s/api/v1/teams/%d/sources", c.config.BaseURL, teamID)
-
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.config.APIKey))
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("make request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("read response body: %w", err)
-	}
-
-	var sources TeamSourcesResponse
-	if err := json.Unmarshal(body, &sources); err != nil {
-		return nil, fmt.Errorf("unmarshal response: %w", err)
-	}
-
-	return &sources, nil
+	return cached(ctx, c, fmt.Sprintf("sources:%d", teamID), c.config.Cache.SourcesTTL, func(ctx context.Context) (*TeamSourcesResponse, error) {
+		var sources TeamSourcesResponse
+		path := fmt.Sprintf("/api/v1/teams/%d/sources", teamID)
+		if err := c.do(ctx, apiRequest{method: http.MethodGet, path: path}, &sources); err != nil {
+			return nil, err
+		}
+		return &sources, nil
+	})
 }
 
 // GetMeta retrieves server metadata including version information
 func (c *Client) GetMeta(ctx context.Context) (*MetaResponse, error) {
-	url := fmt.Sprintf("%s/api/v1/meta", c.config.BaseURL)
-
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
-	}
-
-	// Meta endpoint doesn't require authentication
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("make request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("read response body: %w", err)
-	}
-
-	var meta MetaResponse
-	if err := json.Unmarshal(body, &meta); err != nil {
-		return nil, fmt.Errorf("unmarshal response: %w", err)
-	}
-
-	return &meta, nil
+	return cached(ctx, c, "meta", c.config.Cache.MetaTTL, func(ctx context.Context) (*MetaResponse, error) {
+		var meta MetaResponse
+		if err := c.do(ctx, apiRequest{method: http.MethodGet, path: "/api/v1/meta"}, &meta); err != nil {
+			return nil, err
+		}
+		return &meta, nil
+	})
 }
 
 // QueryLogs executes a log query against a specific source within a team
 func (c *Client) QueryLogs(ctx context.Context, teamID, sourceID int, request LogQueryRequest) (*LogQueryResponse, error) {
-	url := fmt.Sprintf("%s/api/v1/teams/%d/sources/%d/logs/query", c.config.BaseURL, teamID, sourceID)
-
-	// Set default limit if not specified
+	// Set default limit if not specified. Callers (the MCP tools) enforce
+	// the maximum.
 	if request.Limit <= 0 {
 		request.Limit = 100
 	}
-	// Enforce max limit
-	if request.Limit > 100 {
-		request.Limit = 100
-	}
 
 	// Set default timeout if not specified
 	if request.QueryTimeout == nil {
@@ -605,1144 +638,295 @@ func (c *Client) QueryLogs(ctx context.Context, teamID, sourceID int, request Lo
 		request.QueryTimeout = &defaultTimeout
 	}
 
-	requestBody, err := json.Marshal(request)
-	if err != nil {
-		return nil, fmt.Errorf("marshal request: %w", err)
-	}
-
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(requestBody))
-	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.config.APIKey))
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("make request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("read response body: %w", err)
-	}
-
 	var logResponse LogQueryResponse
-	if err := json.Unmarshal(body, &logResponse); err != nil {
-		return nil, fmt.Errorf("unmarshal response: %w", err)
+	path := fmt.Sprintf("/api/v1/teams/%d/sources/%d/logs/query", teamID, sourceID)
+	if err := c.do(ctx, apiRequest{method: http.MethodPost, path: path, body: request, readOnly: true}, &logResponse); err != nil {
+		return nil, err
 	}
-
 	return &logResponse, nil
 }
 
 // GetSourceSchema retrieves the schema (column names and types) for a specific source within a team
 func (c *Client) GetSourceSchema(ctx context.Context, teamID, sourceID int) (*SchemaResponse, error) {
-	url := fmt.Sprintf("%s/api/v1/teams/%d/sources/%d/schema", c.config.BaseURL, teamID, sourceID)
-
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.config.APIKey))
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("make request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("read response body: %w", err)
-	}
-
-	var schema SchemaResponse
-	if err := json.Unmarshal(body, &schema); err != nil {
-		return nil, fmt.Errorf("unmarshal response: %w", err)
-	}
-
-	return &schema, nil
+	return cached(ctx, c, fmt.Sprintf("schema:%d:%d", teamID, sourceID), c.config.Cache.SchemaTTL, func(ctx context.Context) (*SchemaResponse, error) {
+		var schema SchemaResponse
+		path := fmt.Sprintf("/api/v1/teams/%d/sources/%d/schema", teamID, sourceID)
+		if err := c.do(ctx, apiRequest{method: http.MethodGet, path: path}, &schema); err != nil {
+			return nil, err
+		}
+		return &schema, nil
+	})
 }
 
 // GetSourceStats retrieves statistics for a specific source within a team
 func (c *Client) GetSourceStats(ctx context.Context, teamID, sourceID int) (*SourceStatsResponse, error) {
-	url := fmt.Sprintf("%s/api/v1/teams/%d/sources/%d/stats", c.config.BaseURL, teamID, sourceID)
-
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.config.APIKey))
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("make request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("read response body: %w", err)
-	}
-
 	var stats SourceStatsResponse
-	if err := json.Unmarshal(body, &stats); err != nil {
-		return nil, fmt.Errorf("unmarshal response: %w", err)
+	path := fmt.Sprintf("/api/v1/teams/%d/sources/%d/stats", teamID, sourceID)
+	if err := c.do(ctx, apiRequest{method: http.MethodGet, path: path}, &stats); err != nil {
+		return nil, err
 	}
-
 	return &stats, nil
 }
 
 // GetLogHistogram generates histogram data for logs within a team source
 func (c *Client) GetLogHistogram(ctx context.Context, teamID, sourceID int, request HistogramRequest) (*HistogramResponse, error) {
-	url := fmt.Sprintf("%s/api/v1/teams/%d/sources/%d/logs/histogram", c.config.BaseURL, teamID, sourceID)
-
 	// Set default timeout if not specified
 	if request.QueryTimeout == nil {
 		defaultTimeout := 30
 		request.QueryTimeout = &defaultTimeout
 	}
 
-	requestBody, err := json.Marshal(request)
-	if err != nil {
-		return nil, fmt.Errorf("marshal request: %w", err)
-	}
-
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(requestBody))
-	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.config.APIKey))
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("make request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("read response body: %w", err)
-	}
-
 	var histogram HistogramResponse
-	if err := json.Unmarshal(body, &histogram); err != nil {
-		return nil, fmt.Errorf("unmarshal response: %w", err)
+	path := fmt.Sprintf("/api/v1/teams/%d/sources/%d/logs/histogram", teamID, sourceID)
+	if err := c.do(ctx, apiRequest{method: http.MethodPost, path: path, body: request, readOnly: true}, &histogram); err != nil {
+		return nil, err
 	}
-
 	return &histogram, nil
 }
 
 // GetCollections retrieves all collections for a specific team and source
 func (c *Client) GetCollections(ctx context.Context, teamID, sourceID int) (*CollectionsResponse, error) {
-	url := fmt.Sprintf("%s/api/v1/teams/%d/sources/%d/collections", c.config.BaseURL, teamID, sourceID)
-
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.config.APIKey))
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("make request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("read response body: %w", err)
-	}
-
 	var collections CollectionsResponse
-	if err := json.Unmarshal(body, &collections); err != nil {
-		return nil, fmt.Errorf("unmarshal response: %w", err)
+	path := fmt.Sprintf("/api/v1/teams/%d/sources/%d/collections", teamID, sourceID)
+	if err := c.do(ctx, apiRequest{method: http.MethodGet, path: path}, &collections); err != nil {
+		return nil, err
 	}
-
 	return &collections, nil
 }
 
 // CreateCollection creates a new collection for a specific team and source
 func (c *Client) CreateCollection(ctx context.Context, teamID, sourceID int, request CollectionRequest) (*CollectionResponse, error) {
-	url := fmt.Sprintf("%s/api/v1/teams/%d/sources/%d/collections", c.config.BaseURL, teamID, sourceID)
-
-	requestBody, err := json.Marshal(request)
-	if err != nil {
-		return nil, fmt.Errorf("marshal request: %w", err)
-	}
-
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(requestBody))
-	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.config.APIKey))
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("make request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusCreated {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("read response body: %w", err)
-	}
-
 	var collection CollectionResponse
-	if err := json.Unmarshal(body, &collection); err != nil {
-		return nil, fmt.Errorf("unmarshal response: %w", err)
+	path := fmt.Sprintf("/api/v1/teams/%d/sources/%d/collections", teamID, sourceID)
+	if err := c.do(ctx, apiRequest{method: http.MethodPost, path: path, body: request}, &collection); err != nil {
+		return nil, err
 	}
-
 	return &collection, nil
 }
 
 // GetCollection retrieves a specific collection by ID
 func (c *Client) GetCollection(ctx context.Context, teamID, sourceID, collectionID int) (*CollectionResponse, error) {
-	url := fmt.Sprintf("%s/api/v1/teams/%d/sources/%d/collections/%d", c.config.BaseURL, teamID, sourceID, collectionID)
-
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
+	var collection CollectionResponse
+	path := fmt.Sprintf("/api/v1/teams/%d/sources/%d/collections/%d", teamID, sourceID, collectionID)
+	if err := c.do(ctx, apiRequest{method: http.MethodGet, path: path}, &collection); err != nil {
+		return nil, err
 	}
-
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.config.APIKey))
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("make request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("read response body: %w", err)
-	}
-
-	var collection CollectionResponse
-	if err := json.Unmarshal(body, &collection); err != nil {
-		return nil, fmt.Errorf("unmarshal response: %w", err)
-	}
-
-	return &collection, nil
-}
+	return &collection, nil
+}
 
 // UpdateCollection updates an existing collection
 func (c *Client) UpdateCollection(ctx context.Context, teamID, sourceID, collectionID int, request CollectionRequest) (*CollectionResponse, error) {
-	url := fmt.Sprintf("%s/api/v1/teams/%d/sources/%d/collections/%d", c.config.BaseURL, teamID, sourceID, collectionID)
-
-	requestBody, err := json.Marshal(request)
-	if err != nil {
-		return nil, fmt.Errorf("marshal request: %w", err)
-	}
-
-	req, err := http.NewRequestWithContext(ctx, "PUT", url, bytes.NewReader(requestBody))
-	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.config.APIKey))
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("make request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("read response body: %w", err)
-	}
-
 	var collection CollectionResponse
-	if err := json.Unmarshal(body, &collection); err != nil {
-		return nil, fmt.Errorf("unmarshal response: %w", err)
+	path := fmt.Sprintf("/api/v1/teams/%d/sources/%d/collections/%d", teamID, sourceID, collectionID)
+	if err := c.do(ctx, apiRequest{method: http.MethodPut, path: path, body: request}, &collection); err != nil {
+		return nil, err
 	}
-
 	return &collection, nil
 }
 
 // DeleteCollection deletes a collection by ID
 func (c *Client) DeleteCollection(ctx context.Context, teamID, sourceID, collectionID int) error {
-	url := fmt.Sprintf("%s/api/v1/teams/%d/sources/%d/collections/%d", c.config.BaseURL, teamID, sourceID, collectionID)
-
-	req, err := http.NewRequestWithContext(ctx, "DELETE", url, nil)
-	if err != nil {
-		return fmt.Errorf("create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.config.APIKey))
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return fmt.Errorf("make request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusNoContent {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
-
-	return nil
+	path := fmt.Sprintf("/api/v1/teams/%d/sources/%d/collections/%d", teamID, sourceID, collectionID)
+	return c.do(ctx, apiRequest{method: http.MethodDelete, path: path}, nil)
 }
 
 // Admin Team Management Methods
 
 // ListAllTeams retrieves all teams (admin only)
 func (c *Client) ListAllTeams(ctx context.Context) (*TeamsListResponse, error) {
-	url := fmt.Sprintf("%s/api/v1/admin/teams", c.config.BaseURL)
-
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.config.APIKey))
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("make request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("read response body: %w", err)
-	}
-
 	var teams TeamsListResponse
-	if err := json.Unmarshal(body, &teams); err != nil {
-		return nil, fmt.Errorf("unmarshal response: %w", err)
+	if err := c.do(ctx, apiRequest{method: http.MethodGet, path: "/api/v1/admin/teams"}, &teams); err != nil {
+		return nil, err
 	}
-
 	return &teams, nil
 }
 
 // GetTeamByID retrieves a specific team by ID
 func (c *Client) GetTeamByID(ctx context.Context, teamID int) (*TeamResponse, error) {
-	url := fmt.Sprintf("%s/api/v1/teams/%d", c.config.BaseURL, teamID)
-
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.config.APIKey))
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("make request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("read response body: %w", err)
-	}
-
 	var team TeamResponse
-	if err := json.Unmarshal(body, &team); err != nil {
-		return nil, fmt.Errorf("unmarshal response: %w", err)
+	path := fmt.Sprintf("/api/v1/teams/%d", teamID)
+	if err := c.do(ctx, apiRequest{method: http.MethodGet, path: path}, &team); err != nil {
+		return nil, err
 	}
-
 	return &team, nil
 }
 
 // CreateTeam creates a new team (admin only)
 func (c *Client) CreateTeam(ctx context.Context, request TeamRequest) (*TeamResponse, error) {
-	url := fmt.Sprintf("%s/api/v1/admin/teams", c.config.BaseURL)
-
-	requestBody, err := json.Marshal(request)
-	if err != nil {
-		return nil, fmt.Errorf("marshal request: %w", err)
-	}
-
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(requestBody))
-	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.config.APIKey))
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("make request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusCreated {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("read response body: %w", err)
-	}
-
 	var team TeamResponse
-	if err := json.Unmarshal(body, &team); err != nil {
-		return nil, fmt.Errorf("unmarshal response: %w", err)
+	if err := c.do(ctx, apiRequest{method: http.MethodPost, path: "/api/v1/admin/teams", body: request}, &team); err != nil {
+		return nil, err
 	}
-
 	return &team, nil
 }
 
 // UpdateTeam updates an existing team
 func (c *Client) UpdateTeam(ctx context.Context, teamID int, request TeamUpdateRequest) (*TeamResponse, error) {
-	url := fmt.Sprintf("%s/api/v1/teams/%d", c.config.BaseURL, teamID)
-
-	requestBody, err := json.Marshal(request)
-	if err != nil {
-		return nil, fmt.Errorf("marshal request: %w", err)
-	}
-
-	req, err := http.NewRequestWithContext(ctx, "PUT", url, bytes.NewReader(requestBody))
-	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.config.APIKey))
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("make request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("read response body: %w", err)
-	}
-
 	var team TeamResponse
-	if err := json.Unmarshal(body, &team); err != nil {
-		return nil, fmt.Errorf("unmarshal response: %w", err)
+	path := fmt.Sprintf("/api/v1/teams/%d", teamID)
+	if err := c.do(ctx, apiRequest{method: http.MethodPut, path: path, body: request}, &team); err != nil {
+		return nil, err
 	}
-
 	return &team, nil
 }
 
 // DeleteTeam deletes a team (admin only)
 func (c *Client) DeleteTeam(ctx context.Context, teamID int) error {
-	url := fmt.Sprintf("%s/api/v1/admin/teams/%d", c.config.BaseURL, teamID)
-
-	req, err := http.NewRequestWithContext(ctx, "DELETE", url, nil)
-	if err != nil {
-		return fmt.Errorf("create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.config.APIKey))
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return fmt.Errorf("make request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
-
-	return nil
+	path := fmt.Sprintf("/api/v1/admin/teams/%d", teamID)
+	return c.do(ctx, apiRequest{method: http.MethodDelete, path: path}, nil)
 }
 
 // ListTeamMembers retrieves all members of a team
 func (c *Client) ListTeamMembers(ctx context.Context, teamID int) (*TeamMembersResponse, error) {
-	url := fmt.Sprintf("%s/api/v1/teams/%d/members", c.config.BaseURL, teamID)
-
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.config.APIKey))
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("make request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("read response body: %w", err)
-	}
-
 	var members TeamMembersResponse
-	if err := json.Unmarshal(body, &members); err != nil {
-		return nil, fmt.Errorf("unmarshal response: %w", err)
+	path := fmt.Sprintf("/api/v1/teams/%d/members", teamID)
+	if err := c.do(ctx, apiRequest{method: http.MethodGet, path: path}, &members); err != nil {
+		return nil, err
 	}
-
 	return &members, nil
 }
 
 // AddTeamMember adds a user to a team
 func (c *Client) AddTeamMember(ctx context.Context, teamID int, request TeamMemberRequest) error {
-	url := fmt.Sprintf("%s/api/v1/teams/%d/members", c.config.BaseURL, teamID)
-
-	requestBody, err := json.Marshal(request)
-	if err != nil {
-		return fmt.Errorf("marshal request: %w", err)
-	}
-
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(requestBody))
-	if err != nil {
-		return fmt.Errorf("create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.config.APIKey))
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return fmt.Errorf("make request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
-
-	return nil
+	path := fmt.Sprintf("/api/v1/teams/%d/members", teamID)
+	return c.do(ctx, apiRequest{method: http.MethodPost, path: path, body: request}, nil)
 }
 
 // RemoveTeamMember removes a user from a team
 func (c *Client) RemoveTeamMember(ctx context.Context, teamID, userID int) error {
-	url := fmt.Sprintf("%s/api/v1/teams/%d/members/%d", c.config.BaseURL, teamID, userID)
-
-	req, err := http.NewRequestWithContext(ctx, "DELETE", url, nil)
-	if err != nil {
-		return fmt.Errorf("create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.config.APIKey))
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return fmt.Errorf("make request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
-
-	return nil
+	path := fmt.Sprintf("/api/v1/teams/%d/members/%d", teamID, userID)
+	return c.do(ctx, apiRequest{method: http.MethodDelete, path: path}, nil)
 }
 
 // LinkSourceToTeam links a source to a team
 func (c *Client) LinkSourceToTeam(ctx context.Context, teamID int, request TeamSourceRequest) error {
-	url := fmt.Sprintf("%s/api/v1/teams/%d/sources", c.config.BaseURL, teamID)
-
-	requestBody, err := json.Marshal(request)
-	if err != nil {
-		return fmt.Errorf("marshal request: %w", err)
-	}
-
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(requestBody))
-	if err != nil {
-		return fmt.Errorf("create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.config.APIKey))
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return fmt.Errorf("make request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
-
-	return nil
+	path := fmt.Sprintf("/api/v1/teams/%d/sources", teamID)
+	return c.do(ctx, apiRequest{method: http.MethodPost, path: path, body: request}, nil)
 }
 
 // UnlinkSourceFromTeam removes a source from a team
 func (c *Client) UnlinkSourceFromTeam(ctx context.Context, teamID, sourceID int) error {
-	url := fmt.Sprintf("%s/api/v1/teams/%d/sources/%d", c.config.BaseURL, teamID, sourceID)
-
-	req, err := http.NewRequestWithContext(ctx, "DELETE", url, nil)
-	if err != nil {
-		return fmt.Errorf("create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.config.APIKey))
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return fmt.Errorf("make request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
-
-	return nil
+	path := fmt.Sprintf("/api/v1/teams/%d/sources/%d", teamID, sourceID)
+	return c.do(ctx, apiRequest{method: http.MethodDelete, path: path}, nil)
 }
 
 // Admin User Management Methods
 
 // ListAllUsers retrieves all users (admin only)
 func (c *Client) ListAllUsers(ctx context.Context) (*UsersListResponse, error) {
-	url := fmt.Sprintf("%s/api/v1/admin/users", c.config.BaseURL)
-
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.config.APIKey))
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("make request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("read response body: %w", err)
-	}
-
 	var users UsersListResponse
-	if err := json.Unmarshal(body, &users); err != nil {
-		return nil, fmt.Errorf("unmarshal response: %w", err)
+	if err := c.do(ctx, apiRequest{method: http.MethodGet, path: "/api/v1/admin/users"}, &users); err != nil {
+		return nil, err
 	}
-
 	return &users, nil
 }
 
 // GetUserByID retrieves a specific user by ID (admin only)
 func (c *Client) GetUserByID(ctx context.Context, userID int) (*UserResponse, error) {
-	url := fmt.Sprintf("%s/api/v1/admin/users/%d", c.config.BaseURL, userID)
-
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.config.APIKey))
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("make request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("read response body: %w", err)
-	}
-
 	var user UserResponse
-	if err := json.Unmarshal(body, &user); err != nil {
-		return nil, fmt.Errorf("unmarshal response: %w", err)
+	path := fmt.Sprintf("/api/v1/admin/users/%d", userID)
+	if err := c.do(ctx, apiRequest{method: http.MethodGet, path: path}, &user); err != nil {
+		return nil, err
 	}
-
 	return &user, nil
 }
 
 // CreateUser creates a new user (admin only)
-func (c *Client) CreateUser(ctx context.Context, request UserRequest) (*UserResponse, error) {
-	url := fmt.Sprintf("%s/api/v1/admin/users", c.config.BaseURL)
-
-	requestBody, err := json.Marshal(request)
-	if err != nil {
-		return nil, fmt.Errorf("marshal request: %w", err)
-	}
-
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(requestBody))
-	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.config.APIKey))
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("make request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusCreated {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("read response body: %w", err)
-	}
-
-	var user UserResponse
-	if err := json.Unmarshal(body, &user); err != nil {
-		return nil, fmt.Errorf("unmarshal response: %w", err)
-	}
-
-	return &user, nil
-}
-
-// UpdateUser updates an existing user (admin only)
-func (c *Client) UpdateUser(ctx context.Context, userID int, request UserUpdateRequest) (*UserResponse, error) {
-	url := fmt.Sprintf("%s/api/v1/admin/users/%d", c.config.BaseURL, userID)
-
-	requestBody, err := json.Marshal(request)
-	if err != nil {
-		return nil, fmt.Errorf("marshal request: %w", err)
-	}
-
-	req, err := http.NewRequestWithContext(ctx, "PUT", url, bytes.NewReader(requestBody))
-	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.config.APIKey))
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("make request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("read response body: %w", err)
+func (c *Client) CreateUser(ctx context.Context, request UserRequest) (*UserResponse, error) {
+	var user UserResponse
+	if err := c.do(ctx, apiRequest{method: http.MethodPost, path: "/api/v1/admin/users", body: request}, &user); err != nil {
+		return nil, err
 	}
+	return &user, nil
+}
 
+// UpdateUser updates an existing user (admin only)
+func (c *Client) UpdateUser(ctx context.Context, userID int, request UserUpdateRequest) (*UserResponse, error) {
 	var user UserResponse
-	if err := json.Unmarshal(body, &user); err != nil {
-		return nil, fmt.Errorf("unmarshal response: %w", err)
+	path := fmt.Sprintf("/api/v1/admin/users/%d", userID)
+	if err := c.do(ctx, apiRequest{method: http.MethodPut, path: path, body: request}, &user); err != nil {
+		return nil, err
 	}
-
 	return &user, nil
 }
 
 // DeleteUser deletes a user (admin only)
 func (c *Client) DeleteUser(ctx context.Context, userID int) error {
-	url := fmt.Sprintf("%s/api/v1/admin/users/%d", c.config.BaseURL, userID)
-
-	req, err := http.NewRequestWithContext(ctx, "DELETE", url, nil)
-	if err != nil {
-		return fmt.Errorf("create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.config.APIKey))
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return fmt.Errorf("make request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
-
-	return nil
+	path := fmt.Sprintf("/api/v1/admin/users/%d", userID)
+	return c.do(ctx, apiRequest{method: http.MethodDelete, path: path}, nil)
 }
 
 // API Token Management Methods
 
 // ListAPITokens retrieves all API tokens for the current user
 func (c *Client) ListAPITokens(ctx context.Context) (*APITokensResponse, error) {
-	url := fmt.Sprintf("%s/api/v1/me/tokens", c.config.BaseURL)
-
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.config.APIKey))
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("make request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("read response body: %w", err)
-	}
-
 	var tokens APITokensResponse
-	if err := json.Unmarshal(body, &tokens); err != nil {
-		return nil, fmt.Errorf("unmarshal response: %w", err)
+	if err := c.do(ctx, apiRequest{method: http.MethodGet, path: "/api/v1/me/tokens"}, &tokens); err != nil {
+		return nil, err
 	}
-
 	return &tokens, nil
 }
 
 // CreateAPIToken creates a new API token for the current user
 func (c *Client) CreateAPIToken(ctx context.Context, request APITokenRequest) (*APITokenResponse, error) {
-	url := fmt.Sprintf("%s/api/v1/me/tokens", c.config.BaseURL)
-
-	requestBody, err := json.Marshal(request)
-	if err != nil {
-		return nil, fmt.Errorf("marshal request: %w", err)
-	}
-
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(requestBody))
-	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.config.APIKey))
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("make request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusCreated {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("read response body: %w", err)
-	}
-
 	var token APITokenResponse
-	if err := json.Unmarshal(body, &token); err != nil {
-		return nil, fmt.Errorf("unmarshal response: %w", err)
+	if err := c.do(ctx, apiRequest{method: http.MethodPost, path: "/api/v1/me/tokens", body: request}, &token); err != nil {
+		return nil, err
 	}
-
 	return &token, nil
 }
 
 // DeleteAPIToken deletes an API token
 func (c *Client) DeleteAPIToken(ctx context.Context, tokenID int) error {
-	url := fmt.Sprintf("%s/api/v1/me/tokens/%d", c.config.BaseURL, tokenID)
-
-	req, err := http.NewRequestWithContext(ctx, "DELETE", url, nil)
-	if err != nil {
-		return fmt.Errorf("create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.config.APIKey))
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return fmt.Errorf("make request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
-
-	return nil
+	path := fmt.Sprintf("/api/v1/me/tokens/%d", tokenID)
+	return c.do(ctx, apiRequest{method: http.MethodDelete, path: path}, nil)
 }
 
 // Admin Source Management Methods
 
 // ListAllSources retrieves all sources (admin only)
 func (c *Client) ListAllSources(ctx context.Context) (*SourcesListResponse, error) {
-	url := fmt.Sprintf("%s/api/v1/admin/sources", c.config.BaseURL)
-
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.config.APIKey))
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("make request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("read response body: %w", err)
-	}
-
 	var sources SourcesListResponse
-	if err := json.Unmarshal(body, &sources); err != nil {
-		return nil, fmt.Errorf("unmarshal response: %w", err)
+	if err := c.do(ctx, apiRequest{method: http.MethodGet, path: "/api/v1/admin/sources"}, &sources); err != nil {
+		return nil, err
 	}
-
 	return &sources, nil
 }
 
 // CreateSource creates a new source (admin only)
 func (c *Client) CreateSource(ctx context.Context, request SourceRequest) (*AdminSourceResponse, error) {
-	url := fmt.Sprintf("%s/api/v1/admin/sources", c.config.BaseURL)
-
-	requestBody, err := json.Marshal(request)
-	if err != nil {
-		return nil, fmt.Errorf("marshal request: %w", err)
-	}
-
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(requestBody))
-	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.config.APIKey))
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("make request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusCreated {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("read response body: %w", err)
-	}
-
 	var source AdminSourceResponse
-	if err := json.Unmarshal(body, &source); err != nil {
-		return nil, fmt.Errorf("unmarshal response: %w", err)
+	if err := c.do(ctx, apiRequest{method: http.MethodPost, path: "/api/v1/admin/sources", body: request}, &source); err != nil {
+		return nil, err
 	}
-
 	return &source, nil
 }
 
 // ValidateSourceConnection validates a source connection (admin only)
 func (c *Client) ValidateSourceConnection(ctx context.Context, request SourceValidationRequest) (*SourceValidationResponse, error) {
-	url := fmt.Sprintf("%s/api/v1/admin/sources/validate", c.config.BaseURL)
-
-	requestBody, err := json.Marshal(request)
-	if err != nil {
-		return nil, fmt.Errorf("marshal request: %w", err)
-	}
-
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(requestBody))
-	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.config.APIKey))
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("make request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("read response body: %w", err)
-	}
-
 	var validation SourceValidationResponse
-	if err := json.Unmarshal(body, &validation); err != nil {
-		return nil, fmt.Errorf("unmarshal response: %w", err)
+	if err := c.do(ctx, apiRequest{method: http.MethodPost, path: "/api/v1/admin/sources/validate", body: request, readOnly: true}, &validation); err != nil {
+		return nil, err
 	}
-
 	return &validation, nil
 }
 
 // DeleteSource deletes a source (admin only)
 func (c *Client) DeleteSource(ctx context.Context, sourceID int) error {
-	url := fmt.Sprintf("%s/api/v1/admin/sources/%d", c.config.BaseURL, sourceID)
-
-	req, err := http.NewRequestWithContext(ctx, "DELETE", url, nil)
-	if err != nil {
-		return fmt.Errorf("create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.config.APIKey))
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return fmt.Errorf("make request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
-
-	return nil
+	path := fmt.Sprintf("/api/v1/admin/sources/%d", sourceID)
+	return c.do(ctx, apiRequest{method: http.MethodDelete, path: path}, nil)
 }
 
 // GetAdminSourceStats retrieves source statistics (admin only)
 func (c *Client) GetAdminSourceStats(ctx context.Context, sourceID int) (*SourceStatsResponse, error) {
-	url := fmt.Sprintf("%s/api/v1/admin/sources/%d/stats", c.config.BaseURL, sourceID)
-
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.config.APIKey))
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("make request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("read response body: %w", err)
-	}
-
 	var stats SourceStatsResponse
-	if err := json.Unmarshal(body, &stats); err != nil {
-		return nil, fmt.Errorf("unmarshal response: %w", err)
+	path := fmt.Sprintf("/api/v1/admin/sources/%d/stats", sourceID)
+	if err := c.do(ctx, apiRequest{method: http.MethodGet, path: path}, &stats); err != nil {
+		return nil, err
 	}
-
 	return &stats, nil
 }
 
-
 // --- LogchefQL ---
 
 type LogchefQLQueryRequest struct {
@@ -1782,50 +966,18 @@ type LogchefQLTranslateResponse struct {
 }
 
 func (c *Client) QueryLogchefQL(ctx context.Context, teamID, sourceID int, req LogchefQLQueryRequest) (*LogchefQLQueryResponse, error) {
-	url := fmt.Sprintf("%s/api/v1/teams/%d/sources/%d/logchefql/query", c.config.BaseURL, teamID, sourceID)
-	body, _ := json.Marshal(req)
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
-	if err != nil {
-		return nil, err
-	}
-	httpReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.config.APIKey))
-	httpReq.Header.Set("Content-Type", "application/json")
-	resp, err := c.httpClient.Do(httpReq)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	respBody, _ := io.ReadAll(resp.Body)
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(respBody))
-	}
 	var result LogchefQLQueryResponse
-	if err := json.Unmarshal(respBody, &result); err != nil {
+	path := fmt.Sprintf("/api/v1/teams/%d/sources/%d/logchefql/query", teamID, sourceID)
+	if err := c.do(ctx, apiRequest{method: http.MethodPost, path: path, body: req, readOnly: true}, &result); err != nil {
 		return nil, err
 	}
 	return &result, nil
 }
 
 func (c *Client) TranslateLogchefQL(ctx context.Context, teamID, sourceID int, req LogchefQLTranslateRequest) (*LogchefQLTranslateResponse, error) {
-	url := fmt.Sprintf("%s/api/v1/teams/%d/sources/%d/logchefql/translate", c.config.BaseURL, teamID, sourceID)
-	body, _ := json.Marshal(req)
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
-	if err != nil {
-		return nil, err
-	}
-	httpReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.config.APIKey))
-	httpReq.Header.Set("Content-Type", "application/json")
-	resp, err := c.httpClient.Do(httpReq)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	respBody, _ := io.ReadAll(resp.Body)
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(respBody))
-	}
 	var result LogchefQLTranslateResponse
-	if err := json.Unmarshal(respBody, &result); err != nil {
+	path := fmt.Sprintf("/api/v1/teams/%d/sources/%d/logchefql/translate", teamID, sourceID)
+	if err := c.do(ctx, apiRequest{method: http.MethodPost, path: path, body: req, readOnly: true}, &result); err != nil {
 		return nil, err
 	}
 	return &result, nil
@@ -1847,30 +999,15 @@ type FieldValue struct {
 }
 
 func (c *Client) GetFieldValues(ctx context.Context, teamID, sourceID int, fieldName, fieldType, startTime, endTime string, limit int) (*FieldValuesResponse, error) {
-	baseURL := fmt.Sprintf("%s/api/v1/teams/%d/sources/%d/fields/%s/values",
-		c.config.BaseURL, teamID, sourceID, neturl.PathEscape(fieldName))
+	path := fmt.Sprintf("/api/v1/teams/%d/sources/%d/fields/%s/values",
+		teamID, sourceID, neturl.PathEscape(fieldName))
 	params := neturl.Values{}
 	params.Set("type", fieldType)
 	params.Set("start_time", startTime)
 	params.Set("end_time", endTime)
 	params.Set("limit", fmt.Sprintf("%d", limit))
-	httpReq, err := http.NewRequestWithContext(ctx, "GET", baseURL+"?"+params.Encode(), nil)
-	if err != nil {
-		return nil, err
-	}
-	httpReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.config.APIKey))
-	httpReq.Header.Set("Content-Type", "application/json")
-	resp, err := c.httpClient.Do(httpReq)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	respBody, _ := io.ReadAll(resp.Body)
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(respBody))
-	}
 	var result FieldValuesResponse
-	if err := json.Unmarshal(respBody, &result); err != nil {
+	if err := c.do(ctx, apiRequest{method: http.MethodGet, path: path, query: params}, &result); err != nil {
 		return nil, err
 	}
 	return &result, nil
@@ -1896,25 +1033,9 @@ type LogContextResponse struct {
 }
 
 func (c *Client) GetLogContext(ctx context.Context, teamID, sourceID int, req LogContextRequest) (*LogContextResponse, error) {
-	url := fmt.Sprintf("%s/api/v1/teams/%d/sources/%d/logs/context", c.config.BaseURL, teamID, sourceID)
-	body, _ := json.Marshal(req)
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
-	if err != nil {
-		return nil, err
-	}
-	httpReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.config.APIKey))
-	httpReq.Header.Set("Content-Type", "application/json")
-	resp, err := c.httpClient.Do(httpReq)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	respBody, _ := io.ReadAll(resp.Body)
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(respBody))
-	}
 	var result LogContextResponse
-	if err := json.Unmarshal(respBody, &result); err != nil {
+	path := fmt.Sprintf("/api/v1/teams/%d/sources/%d/logs/context", teamID, sourceID)
+	if err := c.do(ctx, apiRequest{method: http.MethodPost, path: path, body: req, readOnly: true}, &result); err != nil {
 		return nil, err
 	}
 	return &result, nil
@@ -1953,48 +1074,18 @@ type AlertHistoryResponse struct {
 }
 
 func (c *Client) ListAlerts(ctx context.Context, teamID, sourceID int) (*AlertsListResponse, error) {
-	url := fmt.Sprintf("%s/api/v1/teams/%d/sources/%d/alerts", c.config.BaseURL, teamID, sourceID)
-	httpReq, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, err
-	}
-	httpReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.config.APIKey))
-	httpReq.Header.Set("Content-Type", "application/json")
-	resp, err := c.httpClient.Do(httpReq)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	respBody, _ := io.ReadAll(resp.Body)
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(respBody))
-	}
 	var result AlertsListResponse
-	if err := json.Unmarshal(respBody, &result); err != nil {
+	path := fmt.Sprintf("/api/v1/teams/%d/sources/%d/alerts", teamID, sourceID)
+	if err := c.do(ctx, apiRequest{method: http.MethodGet, path: path}, &result); err != nil {
 		return nil, err
 	}
 	return &result, nil
 }
 
 func (c *Client) GetAlertHistory(ctx context.Context, teamID, sourceID, alertID int) (*AlertHistoryResponse, error) {
-	url := fmt.Sprintf("%s/api/v1/teams/%d/sources/%d/alerts/%d/history", c.config.BaseURL, teamID, sourceID, alertID)
-	httpReq, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, err
-	}
-	httpReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.config.APIKey))
-	httpReq.Header.Set("Content-Type", "application/json")
-	resp, err := c.httpClient.Do(httpReq)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	respBody, _ := io.ReadAll(resp.Body)
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(respBody))
-	}
 	var result AlertHistoryResponse
-	if err := json.Unmarshal(respBody, &result); err != nil {
+	path := fmt.Sprintf("/api/v1/teams/%d/sources/%d/alerts/%d/history", teamID, sourceID, alertID)
+	if err := c.do(ctx, apiRequest{method: http.MethodGet, path: path}, &result); err != nil {
 		return nil, err
 	}
 	return &result, nil
@@ -2015,25 +1106,9 @@ type LogchefQLValidateResponse struct {
 }
 
 func (c *Client) ValidateLogchefQL(ctx context.Context, teamID, sourceID int, req LogchefQLValidateRequest) (*LogchefQLValidateResponse, error) {
-	url := fmt.Sprintf("%s/api/v1/teams/%d/sources/%d/logchefql/validate", c.config.BaseURL, teamID, sourceID)
-	body, _ := json.Marshal(req)
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
-	if err != nil {
-		return nil, err
-	}
-	httpReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.config.APIKey))
-	httpReq.Header.Set("Content-Type", "application/json")
-	resp, err := c.httpClient.Do(httpReq)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	respBody, _ := io.ReadAll(resp.Body)
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(respBody))
-	}
 	var validateResult LogchefQLValidateResponse
-	if err := json.Unmarshal(respBody, &validateResult); err != nil {
+	path := fmt.Sprintf("/api/v1/teams/%d/sources/%d/logchefql/validate", teamID, sourceID)
+	if err := c.do(ctx, apiRequest{method: http.MethodPost, path: path, body: req, readOnly: true}, &validateResult); err != nil {
 		return nil, err
 	}
 	return &validateResult, nil
@@ -2053,25 +1128,9 @@ type GenerateSQLResponse struct {
 }
 
 func (c *Client) GenerateAISQL(ctx context.Context, teamID, sourceID int, req GenerateSQLRequest) (*GenerateSQLResponse, error) {
-	url := fmt.Sprintf("%s/api/v1/teams/%d/sources/%d/generate-sql", c.config.BaseURL, teamID, sourceID)
-	body, _ := json.Marshal(req)
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
-	if err != nil {
-		return nil, err
-	}
-	httpReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.config.APIKey))
-	httpReq.Header.Set("Content-Type", "application/json")
-	resp, err := c.httpClient.Do(httpReq)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	respBody, _ := io.ReadAll(resp.Body)
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(respBody))
-	}
 	var genResult GenerateSQLResponse
-	if err := json.Unmarshal(respBody, &genResult); err != nil {
+	path := fmt.Sprintf("/api/v1/teams/%d/sources/%d/generate-sql", teamID, sourceID)
+	if err := c.do(ctx, apiRequest{method: http.MethodPost, path: path, body: req}, &genResult); err != nil {
 		return nil, err
 	}
 	return &genResult, nil
@@ -2085,8 +1144,7 @@ type AllFieldValuesResponse struct {
 }
 
 func (c *Client) GetAllFieldValues(ctx context.Context, teamID, sourceID int, startTime, endTime, timezone string, limit int) (*AllFieldValuesResponse, error) {
-	baseURL := fmt.Sprintf("%s/api/v1/teams/%d/sources/%d/fields/values",
-		c.config.BaseURL, teamID, sourceID)
+	path := fmt.Sprintf("/api/v1/teams/%d/sources/%d/fields/values", teamID, sourceID)
 	params := neturl.Values{}
 	params.Set("start_time", startTime)
 	params.Set("end_time", endTime)
@@ -2096,23 +1154,8 @@ func (c *Client) GetAllFieldValues(ctx context.Context, teamID, sourceID int, st
 	if limit > 0 {
 		params.Set("limit", fmt.Sprintf("%d", limit))
 	}
-	httpReq, err := http.NewRequestWithContext(ctx, "GET", baseURL+"?"+params.Encode(), nil)
-	if err != nil {
-		return nil, err
-	}
-	httpReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.config.APIKey))
-	httpReq.Header.Set("Content-Type", "application/json")
-	resp, err := c.httpClient.Do(httpReq)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	respBody, _ := io.ReadAll(resp.Body)
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(respBody))
-	}
 	var allFieldsResult AllFieldValuesResponse
-	if err := json.Unmarshal(respBody, &allFieldsResult); err != nil {
+	if err := c.do(ctx, apiRequest{method: http.MethodGet, path: path, query: params}, &allFieldsResult); err != nil {
 		return nil, err
 	}
 	return &allFieldsResult, nil
@@ -0,0 +1,126 @@
+package client_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/mr-karan/logchef-mcp/client"
+	"github.com/mr-karan/logchef-mcp/logcheftest"
+)
+
+func TestGetTeams(t *testing.T) {
+	srv := logcheftest.NewServer(t)
+
+	teams, err := srv.Client(logcheftest.MemberKey).GetTeams(context.Background())
+	if err != nil {
+		t.Fatalf("GetTeams: %v", err)
+	}
+	if len(teams.Data) != 1 || teams.Data[0].Name != "platform" || teams.Data[0].Role != "member" {
+		t.Fatalf("unexpected teams: %+v", teams.Data)
+	}
+}
+
+func TestAPIErrors(t *testing.T) {
+	srv := logcheftest.NewServer(t)
+	ctx := context.Background()
+
+	_, err := srv.Client("bogus").GetProfile(ctx)
+	if !client.IsUnauthorized(err) {
+		t.Errorf("bad key: want unauthorized, got %v", err)
+	}
+
+	_, err = srv.Client(logcheftest.MemberKey).ListAllUsers(ctx)
+	if !client.IsForbidden(err) {
+		t.Errorf("member listing users: want forbidden, got %v", err)
+	}
+
+	_, err = srv.Client(logcheftest.AdminKey).GetSourceSchema(ctx, 1, 99)
+	if !client.IsNotFound(err) {
+		t.Errorf("unknown source: want not found, got %v", err)
+	}
+	apiErr, ok := client.AsAPIError(err)
+	if !ok || apiErr.Message != "source not found" || apiErr.RequestID == "" {
+		t.Errorf("unexpected API error: %+v", apiErr)
+	}
+}
+
+func TestRetriesReadOnlyQueries(t *testing.T) {
+	srv := logcheftest.NewServer(t)
+	path := "/api/v1/teams/1/sources/1/logs/query"
+	srv.Inject(logcheftest.Fault{Method: http.MethodPost, Path: path, Status: http.StatusServiceUnavailable})
+
+	resp, err := srv.Client(logcheftest.MemberKey).QueryLogs(context.Background(), 1, 1, client.LogQueryRequest{
+		RawSQL: "SELECT * FROM logs.app",
+		Limit:  3,
+	})
+	if err != nil {
+		t.Fatalf("QueryLogs: %v", err)
+	}
+	if len(resp.Data.Data) != 3 {
+		t.Errorf("want 3 rows, got %d", len(resp.Data.Data))
+	}
+	if n := srv.CountRequests(http.MethodPost, path); n != 2 {
+		t.Errorf("want 2 attempts, got %d", n)
+	}
+}
+
+func TestDoesNotRetryMutations(t *testing.T) {
+	srv := logcheftest.NewServer(t)
+	path := "/api/v1/admin/teams"
+	srv.Inject(logcheftest.Fault{Method: http.MethodPost, Path: path, Status: http.StatusServiceUnavailable})
+
+	_, err := srv.Client(logcheftest.AdminKey).CreateTeam(context.Background(), client.TeamRequest{Name: "sre"})
+	if !client.IsServerError(err) {
+		t.Fatalf("want server error, got %v", err)
+	}
+	if n := srv.CountRequests(http.MethodPost, path); n != 1 {
+		t.Errorf("want 1 attempt, got %d", n)
+	}
+}
+
+func TestMetadataCache(t *testing.T) {
+	srv := logcheftest.NewServer(t)
+	c := srv.Client(logcheftest.AdminKey)
+	ctx := context.Background()
+	path := "/api/v1/teams/1/sources/1/schema"
+
+	for range 3 {
+		if _, err := c.GetSourceSchema(ctx, 1, 1); err != nil {
+			t.Fatalf("GetSourceSchema: %v", err)
+		}
+	}
+	if n := srv.CountRequests(http.MethodGet, path); n != 1 {
+		t.Errorf("want 1 request while cached, got %d", n)
+	}
+
+	c.InvalidateCache()
+	if _, err := c.GetSourceSchema(ctx, 1, 1); err != nil {
+		t.Fatalf("GetSourceSchema: %v", err)
+	}
+	if n := srv.CountRequests(http.MethodGet, path); n != 2 {
+		t.Errorf("want 2 requests after invalidation, got %d", n)
+	}
+}
+
+func TestQueryLogchefQL(t *testing.T) {
+	srv := logcheftest.NewServer(t)
+
+	resp, err := srv.Client(logcheftest.MemberKey).QueryLogchefQL(context.Background(), 1, 1, client.LogchefQLQueryRequest{
+		Query:     `level="error" and service="api"`,
+		StartTime: "2026-03-01 10:00:00",
+		EndTime:   "2026-03-01 11:00:00",
+	})
+	if err != nil {
+		t.Fatalf("QueryLogchefQL: %v", err)
+	}
+	if len(resp.Data.Logs) != 2 {
+		t.Fatalf("want 2 matching rows, got %d: %v", len(resp.Data.Logs), resp.Data.Logs)
+	}
+	if got := resp.Data.Logs[0]["message"]; got != "upstream timeout from inventory" {
+		t.Errorf("want newest row first, got %v", got)
+	}
+	if resp.Data.GeneratedSQL == "" {
+		t.Error("want generated SQL")
+	}
+}
@@ -0,0 +1,146 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxDebugBodyLen caps how much of each request/response body is logged.
+const maxDebugBodyLen = 2048
+
+const redacted = "[REDACTED]"
+
+// sensitiveHeaders are scrubbed before request headers are logged.
+var sensitiveHeaders = []string{"Authorization", "X-Logchef-API-Key", "Cookie", "Set-Cookie"}
+
+// sensitiveKeys are JSON object keys whose values are scrubbed from logged
+// bodies, matched case-insensitively by substring.
+var sensitiveKeys = []string{"token", "api_key", "apikey", "password", "secret", "authorization"}
+
+// debugTransport is an http.RoundTripper that logs every Logchef request and
+// response with credentials scrubbed. It is installed when Config.Debug is set.
+type debugTransport struct {
+	next http.RoundTripper
+}
+
+func newDebugTransport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &debugTransport{next: next}
+}
+
+func (t *debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.GetBody != nil {
+		if rc, err := req.GetBody(); err == nil {
+			reqBody, _ = io.ReadAll(rc)
+			rc.Close()
+		}
+	}
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	latency := time.Since(start)
+
+	attrs := []any{
+		"method", req.Method,
+		"path", req.URL.Path,
+		"query", req.URL.RawQuery,
+		"headers", redactHeaders(req.Header),
+		"latency", latency,
+	}
+	if len(reqBody) > 0 {
+		attrs = append(attrs, "request_body", redactBody(reqBody))
+	}
+
+	if err != nil {
+		attrs = append(attrs, "error", err)
+		slog.Info("Logchef request failed", attrs...)
+		return nil, err
+	}
+
+	respBody, readErr := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	if readErr != nil {
+		attrs = append(attrs, "status", resp.StatusCode, "error", readErr)
+		slog.Info("Logchef response body read failed", attrs...)
+		return resp, readErr
+	}
+
+	attrs = append(attrs, "status", resp.StatusCode)
+	if id := resp.Header.Get("X-Request-ID"); id != "" {
+		attrs = append(attrs, "request_id", id)
+	}
+	if len(respBody) > 0 {
+		attrs = append(attrs, "response_body", redactBody(respBody))
+	}
+	slog.Info("Logchef request", attrs...)
+	return resp, nil
+}
+
+// redactHeaders returns a copy of h with credential-bearing headers scrubbed,
+// including custom headers whose names look like they carry a secret.
+func redactHeaders(h http.Header) http.Header {
+	out := h.Clone()
+	for _, k := range sensitiveHeaders {
+		if out.Get(k) != "" {
+			out.Set(k, redacted)
+		}
+	}
+	for k := range out {
+		if isSensitiveKey(strings.ReplaceAll(k, "-", "_")) {
+			out.Set(k, redacted)
+		}
+	}
+	return out
+}
+
+// redactBody scrubs sensitive values from a JSON body and truncates it for
+// logging. Non-JSON bodies are only truncated.
+func redactBody(body []byte) string {
+	var v any
+	if err := json.Unmarshal(body, &v); err == nil {
+		if b, err := json.Marshal(redactValue(v)); err == nil {
+			body = b
+		}
+	}
+	if len(body) > maxDebugBodyLen {
+		return string(body[:maxDebugBodyLen]) + "...(truncated)"
+	}
+	return string(body)
+}
+
+func redactValue(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, val := range t {
+			if isSensitiveKey(k) {
+				t[k] = redacted
+				continue
+			}
+			t[k] = redactValue(val)
+		}
+	case []any:
+		for i, val := range t {
+			t[i] = redactValue(val)
+		}
+	}
+	return v
+}
+
+func isSensitiveKey(k string) bool {
+	k = strings.ToLower(k)
+	for _, s := range sensitiveKeys {
+		if strings.Contains(k, s) {
+			return true
+		}
+	}
+	return false
+}
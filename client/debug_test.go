@@ -0,0 +1,52 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDebugLogRedactsSecrets(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"success","data":{"name":"ci","token":"resp-token-secret"}}`))
+	}))
+	defer srv.Close()
+
+	var logs bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+	defer slog.SetDefault(prev)
+
+	c := New(Config{
+		BaseURL: srv.URL,
+		APIKey:  "bearer-key-secret",
+		Debug:   true,
+		Headers: map[string]string{
+			"X-Logchef-API-Key": "header-key-secret",
+			"Cookie":            "session=cookie-secret",
+			"X-Upstream-Token":  "custom-header-secret",
+		},
+	})
+	body := map[string]any{"name": "ci", "api_key": "body-key-secret", "nested": []any{map[string]any{"password": "nested-secret"}}}
+	if err := c.do(context.Background(), apiRequest{method: http.MethodPost, path: "/api/v1/tokens", body: body}, nil); err != nil {
+		t.Fatalf("request: %v", err)
+	}
+
+	out := logs.String()
+	if !strings.Contains(out, "Logchef request") || !strings.Contains(out, "/api/v1/tokens") {
+		t.Fatalf("request was not logged:\n%s", out)
+	}
+	for _, secret := range []string{"bearer-key-secret", "header-key-secret", "cookie-secret", "custom-header-secret", "body-key-secret", "nested-secret", "resp-token-secret"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("debug log contains %q:\n%s", secret, out)
+		}
+	}
+	if !strings.Contains(out, redacted) || !strings.Contains(out, "ci") {
+		t.Errorf("debug log lacks redacted values or the non-secret fields:\n%s", out)
+	}
+}
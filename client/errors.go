@@ -0,0 +1,115 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxErrorBodyLen caps how much of an unstructured error body is kept in
+// APIError.Message so a proxy's HTML error page doesn't flood the model.
+const maxErrorBodyLen = 512
+
+// APIError is returned by Client methods when Logchef responds with a non-2xx
+// status code.
+type APIError struct {
+	// StatusCode is the HTTP status code returned by Logchef.
+	StatusCode int
+	// Message is the error message reported by Logchef, or the raw response
+	// body when it could not be parsed.
+	Message string
+	// ErrorType is Logchef's error classification (e.g. ValidationError), if any.
+	ErrorType string
+	// Method and Path identify the request that failed.
+	Method string
+	Path   string
+	// RequestID is the value of the X-Request-ID response header, if present.
+	RequestID string
+	// RetryAfter is the delay requested by a Retry-After header, if present.
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = http.StatusText(e.StatusCode)
+	}
+	s := fmt.Sprintf("logchef API error (status %d) on %s %s: %s", e.StatusCode, e.Method, e.Path, msg)
+	if e.RequestID != "" {
+		s += fmt.Sprintf(" [request_id=%s]", e.RequestID)
+	}
+	return s
+}
+
+// errorEnvelope is the JSON body Logchef sends alongside error responses.
+type errorEnvelope struct {
+	Status    string `json:"status"`
+	Message   string `json:"message"`
+	Error     string `json:"error"`
+	ErrorType string `json:"error_type"`
+}
+
+func newAPIError(resp *http.Response, method, path string, body []byte) *APIError {
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		Method:     method,
+		Path:       path,
+		RequestID:  resp.Header.Get("X-Request-ID"),
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+
+	var env errorEnvelope
+	if err := json.Unmarshal(body, &env); err == nil {
+		apiErr.Message = env.Message
+		if apiErr.Message == "" {
+			apiErr.Message = env.Error
+		}
+		apiErr.ErrorType = env.ErrorType
+	}
+	if apiErr.Message == "" {
+		msg := strings.TrimSpace(string(body))
+		if len(msg) > maxErrorBodyLen {
+			msg = msg[:maxErrorBodyLen] + "..."
+		}
+		apiErr.Message = msg
+	}
+	return apiErr
+}
+
+// AsAPIError reports whether err is, or wraps, an *APIError and returns it.
+func AsAPIError(err error) (*APIError, bool) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr, true
+	}
+	return nil, false
+}
+
+func hasStatus(err error, code int) bool {
+	apiErr, ok := AsAPIError(err)
+	return ok && apiErr.StatusCode == code
+}
+
+// IsBadRequest reports whether err is a Logchef 400 response.
+func IsBadRequest(err error) bool { return hasStatus(err, http.StatusBadRequest) }
+
+// IsUnauthorized reports whether err is a Logchef 401 response.
+func IsUnauthorized(err error) bool { return hasStatus(err, http.StatusUnauthorized) }
+
+// IsForbidden reports whether err is a Logchef 403 response.
+func IsForbidden(err error) bool { return hasStatus(err, http.StatusForbidden) }
+
+// IsNotFound reports whether err is a Logchef 404 response.
+func IsNotFound(err error) bool { return hasStatus(err, http.StatusNotFound) }
+
+// IsRateLimited reports whether err is a Logchef 429 response.
+func IsRateLimited(err error) bool { return hasStatus(err, http.StatusTooManyRequests) }
+
+// IsServerError reports whether err is a Logchef 5xx response.
+func IsServerError(err error) bool {
+	apiErr, ok := AsAPIError(err)
+	return ok && apiErr.StatusCode >= 500
+}
@@ -0,0 +1,132 @@
+package client
+
+import (
+	"context"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limits bounds the load that clients put on a single Logchef instance. All
+// clients created with the same BaseURL and Limits share one limiter, so the
+// bounds hold across every MCP session talking to that instance.
+type Limits struct {
+	// RequestsPerSecond is the sustained request rate. Zero means unlimited.
+	RequestsPerSecond float64
+	// Burst is the number of requests allowed above the sustained rate.
+	// Defaults to 1 when RequestsPerSecond is set.
+	Burst int
+	// MaxInFlight caps concurrent requests. Zero means unlimited.
+	MaxInFlight int
+}
+
+type limiterKey struct {
+	baseURL string
+	limits  Limits
+}
+
+var (
+	limitersMu sync.Mutex
+	limiters   = map[limiterKey]*limiter{}
+)
+
+// sharedLimiter returns the limiter for baseURL, creating it on first use. It
+// returns nil when l imposes no limits.
+func sharedLimiter(baseURL string, l Limits) *limiter {
+	if l.RequestsPerSecond <= 0 && l.MaxInFlight <= 0 {
+		return nil
+	}
+	if l.RequestsPerSecond > 0 && l.Burst <= 0 {
+		l.Burst = 1
+	}
+
+	key := limiterKey{baseURL: strings.TrimRight(baseURL, "/"), limits: l}
+	limitersMu.Lock()
+	defer limitersMu.Unlock()
+	if lim, ok := limiters[key]; ok {
+		return lim
+	}
+	lim := newLimiter(l)
+	limiters[key] = lim
+	return lim
+}
+
+// limiter combines a token bucket with a semaphore on in-flight requests.
+type limiter struct {
+	rate  float64
+	burst float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+
+	inFlight chan struct{}
+}
+
+func newLimiter(l Limits) *limiter {
+	lim := &limiter{
+		rate:   l.RequestsPerSecond,
+		burst:  float64(l.Burst),
+		tokens: float64(l.Burst),
+		last:   time.Now(),
+	}
+	if l.MaxInFlight > 0 {
+		lim.inFlight = make(chan struct{}, l.MaxInFlight)
+	}
+	return lim
+}
+
+// acquire blocks until a request may be sent and returns a func that must be
+// called once the request has completed.
+func (l *limiter) acquire(ctx context.Context) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+
+	if err := l.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	if l.inFlight == nil {
+		return func() {}, nil
+	}
+	select {
+	case l.inFlight <- struct{}{}:
+		return func() { <-l.inFlight }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// wait takes one token from the bucket, sleeping until it is available.
+func (l *limiter) wait(ctx context.Context) error {
+	if l.rate <= 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	// Reserve the token up front; a negative balance queues later callers
+	// behind this one.
+	l.tokens--
+	var delay time.Duration
+	if l.tokens < 0 {
+		delay = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if delay == 0 {
+		return nil
+	}
+	if err := sleep(ctx, delay); err != nil {
+		// Give the reservation back so a cancelled call doesn't slow others.
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return err
+	}
+	return nil
+}
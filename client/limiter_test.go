@@ -0,0 +1,101 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSharedLimiter(t *testing.T) {
+	limits := Limits{RequestsPerSecond: 5, MaxInFlight: 2}
+	a := sharedLimiter("http://shared.test", limits)
+	if a == nil {
+		t.Fatal("no limiter for non-zero limits")
+	}
+	if b := sharedLimiter("http://shared.test/", limits); b != a {
+		t.Error("same instance and limits did not share a limiter")
+	}
+	if b := sharedLimiter("http://other.test", limits); b == a {
+		t.Error("different instances shared a limiter")
+	}
+	if b := sharedLimiter("http://shared.test", Limits{RequestsPerSecond: 5, MaxInFlight: 3}); b == a {
+		t.Error("different limits shared a limiter")
+	}
+	if b := sharedLimiter("http://shared.test", Limits{}); b != nil {
+		t.Error("zero limits returned a limiter")
+	}
+	if a.burst != 1 {
+		t.Errorf("burst = %v, want the default of 1", a.burst)
+	}
+}
+
+func TestLimiterMaxInFlight(t *testing.T) {
+	lim := newLimiter(Limits{MaxInFlight: 2})
+	var current, peak atomic.Int32
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release, err := lim.acquire(context.Background())
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			n := current.Add(1)
+			for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+			}
+			time.Sleep(5 * time.Millisecond)
+			current.Add(-1)
+			release()
+		}()
+	}
+	wg.Wait()
+	if p := peak.Load(); p != 2 {
+		t.Errorf("peak in-flight = %d, want 2", p)
+	}
+}
+
+func TestLimiterCancel(t *testing.T) {
+	t.Run("in flight", func(t *testing.T) {
+		lim := newLimiter(Limits{MaxInFlight: 1})
+		release, err := lim.acquire(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		if _, err := lim.acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("acquire while full = %v, want deadline exceeded", err)
+		}
+		release()
+		if release, err := lim.acquire(context.Background()); err != nil {
+			t.Errorf("acquire after release: %v", err)
+		} else {
+			release()
+		}
+	})
+
+	t.Run("rate", func(t *testing.T) {
+		lim := newLimiter(Limits{RequestsPerSecond: 1, Burst: 1})
+		if _, err := lim.acquire(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		if _, err := lim.acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("acquire with no tokens = %v, want deadline exceeded", err)
+		}
+		// The cancelled call gave its reservation back, so the next caller
+		// waits for one token rather than two.
+		lim.mu.Lock()
+		tokens := lim.tokens
+		lim.mu.Unlock()
+		if tokens < -0.1 {
+			t.Errorf("tokens after cancel = %v, want the reservation returned", tokens)
+		}
+	})
+}
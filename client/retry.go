@@ -0,0 +1,132 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	neturl "net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Default retry settings used when the corresponding RetryPolicy field is zero.
+const (
+	defaultRetryMaxAttempts    = 3
+	defaultRetryInitialBackoff = 250 * time.Millisecond
+	defaultRetryMaxBackoff     = 5 * time.Second
+)
+
+// RetryPolicy controls how failed requests are retried. Only idempotent GETs
+// and read-only query POSTs are ever retried; mutations are sent once.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	// Set to 1 to disable retries.
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry. Subsequent delays
+	// double, with jitter, up to MaxBackoff.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between attempts. A Retry-After header asking
+	// for a longer wait ends the retry loop instead.
+	MaxBackoff time.Duration
+}
+
+func (p *RetryPolicy) setDefaults() {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = defaultRetryMaxAttempts
+	}
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = defaultRetryInitialBackoff
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = defaultRetryMaxBackoff
+	}
+}
+
+// delay returns how long to wait before the attempt following attempt, and
+// false if the server asked for a longer wait than the policy allows.
+func (p RetryPolicy) delay(attempt int, err error) (time.Duration, bool) {
+	if apiErr, ok := AsAPIError(err); ok && apiErr.RetryAfter > 0 {
+		if apiErr.RetryAfter > p.MaxBackoff {
+			return 0, false
+		}
+		return apiErr.RetryAfter, true
+	}
+
+	backoff := p.InitialBackoff << (attempt - 1)
+	if backoff <= 0 || backoff > p.MaxBackoff {
+		backoff = p.MaxBackoff
+	}
+	// Equal jitter: wait somewhere between half and the full backoff so that
+	// concurrent tool calls don't retry in lockstep.
+	half := backoff / 2
+	return half + rand.N(half+1), true
+}
+
+// isRetryable reports whether err is a transient failure worth retrying.
+func isRetryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	if apiErr, ok := AsAPIError(err); ok {
+		switch apiErr.StatusCode {
+		case http.StatusTooManyRequests, http.StatusBadGateway,
+			http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		case http.StatusInternalServerError:
+			return isTooManyQueries(apiErr.Message)
+		}
+		return false
+	}
+
+	// Connection-level failures (refused, reset, DNS) are retried, but client
+	// timeouts are not: a query that already ran for the full timeout will
+	// most likely time out again.
+	var urlErr *neturl.Error
+	if errors.As(err, &urlErr) {
+		return !urlErr.Timeout()
+	}
+	return false
+}
+
+// isTooManyQueries detects ClickHouse's TOO_MANY_SIMULTANEOUS_QUERIES error,
+// which Logchef surfaces as a 500.
+func isTooManyQueries(msg string) bool {
+	msg = strings.ToLower(msg)
+	return strings.Contains(msg, "too many simultaneous queries") ||
+		strings.Contains(msg, "too_many_simultaneous_queries")
+}
+
+// parseRetryAfter parses a Retry-After header given either as delay-seconds
+// or as an HTTP date. It returns zero if the header is absent or invalid.
+func parseRetryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+		if secs < 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// sleep waits for d or until ctx is done, whichever comes first.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
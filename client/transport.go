@@ -0,0 +1,85 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"os"
+)
+
+// TransportConfig configures how clients connect to Logchef, for instances
+// behind an internal CA, requiring client certificates or only reachable
+// through an egress proxy.
+type TransportConfig struct {
+	// CAFile is a PEM bundle of CAs trusted for Logchef's certificate in
+	// addition to the system roots.
+	CAFile string
+	// CertFile and KeyFile are a PEM client certificate and key presented
+	// to Logchef.
+	CertFile string
+	KeyFile  string
+	// InsecureSkipVerify disables verification of Logchef's certificate.
+	// Only use it for testing.
+	InsecureSkipVerify bool
+	// ProxyURL is an http, https or socks5 proxy for requests to Logchef.
+	// Empty uses HTTPS_PROXY, HTTP_PROXY and NO_PROXY from the environment.
+	ProxyURL string
+}
+
+// IsZero reports whether tc leaves every setting at its default.
+func (tc TransportConfig) IsZero() bool {
+	return tc == TransportConfig{}
+}
+
+// NewTransport returns a copy of http.DefaultTransport configured by tc, for
+// use as Config.Transport. Create it once and share it between clients so
+// they share connections.
+func NewTransport(tc TransportConfig) (*http.Transport, error) {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	if tc.ProxyURL != "" {
+		u, err := neturl.Parse(tc.ProxyURL)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL %q", tc.ProxyURL)
+		}
+		switch u.Scheme {
+		case "http", "https", "socks5":
+		default:
+			return nil, fmt.Errorf("invalid proxy URL %q: scheme must be http, https or socks5", tc.ProxyURL)
+		}
+		t.Proxy = http.ProxyURL(u)
+	}
+
+	cfg := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: tc.InsecureSkipVerify,
+	}
+	if tc.CAFile != "" {
+		pem, err := os.ReadFile(tc.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read CA file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", tc.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+	if (tc.CertFile == "") != (tc.KeyFile == "") {
+		return nil, errors.New("client certificate and key must be set together")
+	}
+	if tc.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(tc.CertFile, tc.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	t.TLSClientConfig = cfg
+	return t, nil
+}
@@ -0,0 +1,87 @@
+package client_test
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mr-karan/logchef-mcp/client"
+)
+
+func TestTransport(t *testing.T) {
+	var got http.Header
+	meta := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status":"success","data":{"version":"v1.0.0"}}`))
+	})
+	getMeta := func(tc client.TransportConfig, baseURL string, headers map[string]string) error {
+		t.Helper()
+		transport, err := client.NewTransport(tc)
+		if err != nil {
+			t.Fatalf("NewTransport: %v", err)
+		}
+		c := client.New(client.Config{
+			BaseURL: baseURL, APIKey: "key", Transport: transport, Headers: headers,
+			Retry: client.RetryPolicy{MaxAttempts: 1}, Cache: client.CacheConfig{Disabled: true},
+		})
+		_, err = c.GetMeta(context.Background())
+		return err
+	}
+
+	t.Run("custom CA", func(t *testing.T) {
+		srv := httptest.NewTLSServer(meta)
+		defer srv.Close()
+		if err := getMeta(client.TransportConfig{}, srv.URL, nil); err == nil {
+			t.Error("request to a server with an untrusted certificate succeeded")
+		}
+		caFile := filepath.Join(t.TempDir(), "ca.pem")
+		caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+		if err := os.WriteFile(caFile, caPEM, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := getMeta(client.TransportConfig{CAFile: caFile}, srv.URL, nil); err != nil {
+			t.Errorf("with CA file: %v", err)
+		}
+		if err := getMeta(client.TransportConfig{InsecureSkipVerify: true}, srv.URL, nil); err != nil {
+			t.Errorf("with verification disabled: %v", err)
+		}
+	})
+
+	t.Run("proxy and headers", func(t *testing.T) {
+		proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Host != "logchef.internal" {
+				http.Error(w, "not proxied", http.StatusBadGateway)
+				return
+			}
+			meta(w, r)
+		}))
+		defer proxy.Close()
+		headers := map[string]string{"X-Tenant": "acme", "Authorization": "Bearer override"}
+		if err := getMeta(client.TransportConfig{ProxyURL: proxy.URL}, "http://logchef.internal", headers); err != nil {
+			t.Fatalf("through proxy: %v", err)
+		}
+		if got.Get("X-Tenant") != "acme" {
+			t.Errorf("custom header not sent: %v", got)
+		}
+		if got.Get("Authorization") != "Bearer key" {
+			t.Errorf("Authorization = %q, custom headers must not override it", got.Get("Authorization"))
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for name, tc := range map[string]client.TransportConfig{
+			"missing CA file":  {CAFile: filepath.Join(t.TempDir(), "missing.pem")},
+			"cert without key": {CertFile: "client.pem"},
+			"proxy scheme":     {ProxyURL: "ftp://proxy.internal:21"},
+		} {
+			if _, err := client.NewTransport(tc); err == nil {
+				t.Errorf("%s: NewTransport succeeded", name)
+			}
+		}
+	})
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	mcplogchef "github.com/mr-karan/logchef-mcp"
+)
+
+// auditProfileTimeout bounds the GetProfile lookup that identifies the
+// Logchef user behind an audited call. Profiles are cached, so this is
+// usually free.
+const auditProfileTimeout = 5 * time.Second
+
+// maxAuditErrorLen caps the error message stored in an audit event.
+const maxAuditErrorLen = 1024
+
+// auditSensitiveKeys are argument names whose values are redacted, matched
+// case-insensitively by substring.
+var auditSensitiveKeys = []string{"password", "secret", "token", "api_key", "apikey"}
+
+// auditEvent is one JSON line in the audit log.
+type auditEvent struct {
+	Time        time.Time               `json:"time"`
+	Tool        string                  `json:"tool"`
+	Category    string                  `json:"category"`
+	Destructive bool                    `json:"destructive,omitempty"`
+	Session     string                  `json:"session,omitempty"`
+	Instance    string                  `json:"instance,omitempty"`
+	User        string                  `json:"user,omitempty"`
+	UserID      int                     `json:"user_id,omitempty"`
+	TeamID      int                     `json:"team_id,omitempty"`
+	SourceID    int                     `json:"source_id,omitempty"`
+	Arguments   map[string]any          `json:"arguments,omitempty"`
+	Queries     []mcplogchef.AuditQuery `json:"queries,omitempty"`
+	DurationMS  int64                   `json:"duration_ms"`
+	// Outcome is "started" for the event written before a destructive tool
+	// runs, then "success" or "error".
+	Outcome string `json:"outcome"`
+	Error   string `json:"error,omitempty"`
+}
+
+// auditLog writes an audit event for every call to a tool in one of its
+// categories, and for every destructive tool regardless of category.
+type auditLog struct {
+	// categories to audit; empty audits all of them.
+	categories []string
+
+	mu  sync.Mutex
+	w   io.Writer
+	enc *json.Encoder
+
+	// tools maps tool names to their category and is filled in by
+	// newServer once the tools are registered.
+	tools       map[string]string
+	destructive map[string]bool
+}
+
+// openAuditLog opens the audit log at path, appending to it, or stdout for
+// "-". It returns nil if path is empty.
+func openAuditLog(path string, categories []string) (*auditLog, error) {
+	if path == "" {
+		return nil, nil
+	}
+	var w io.Writer = os.Stdout
+	if path != "-" {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+		if err != nil {
+			return nil, fmt.Errorf("open audit log: %w", err)
+		}
+		w = f
+	}
+	return newAuditLog(w, categories), nil
+}
+
+func newAuditLog(w io.Writer, categories []string) *auditLog {
+	return &auditLog{w: w, enc: json.NewEncoder(w), categories: categories}
+}
+
+// setTools records the category of each registered tool and which tools
+// are destructive. As in the MCP spec, the destructive hint only counts for
+// tools not marked read-only; mcp-go sets it on every tool by default.
+func (a *auditLog) setTools(s *server.MCPServer, categories map[string]string) {
+	a.tools = categories
+	a.destructive = make(map[string]bool)
+	for name, t := range s.ListTools() {
+		ann := t.Tool.Annotations
+		if readOnly := ann.ReadOnlyHint; readOnly != nil && *readOnly {
+			continue
+		}
+		if hint := ann.DestructiveHint; hint == nil || *hint {
+			a.destructive[name] = true
+		}
+	}
+}
+
+func (a *auditLog) audited(tool string) bool {
+	return a.destructive[tool] || len(a.categories) == 0 || slices.Contains(a.categories, a.tools[tool])
+}
+
+// middleware records each audited tool call. Destructive tools also get an
+// event before they run, so the attempt is on record even if the call never
+// returns.
+func (a *auditLog) middleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		tool := req.Params.Name
+		if !a.audited(tool) {
+			return next(ctx, req)
+		}
+
+		ev := auditEvent{
+			Tool:        tool,
+			Category:    a.tools[tool],
+			Destructive: a.destructive[tool],
+			Instance:    req.GetString(instanceArgument, ""),
+			TeamID:      req.GetInt("team_id", 0),
+			SourceID:    req.GetInt("source_id", 0),
+			Arguments:   redactArguments(req.GetArguments()),
+		}
+		if session := server.ClientSessionFromContext(ctx); session != nil {
+			ev.Session = session.SessionID()
+		}
+		ev.User, ev.UserID = auditUser(ctx)
+		if ev.Destructive {
+			ev.Time = time.Now()
+			ev.Outcome = "started"
+			a.write(ev)
+		}
+
+		ctx, rec := mcplogchef.WithAuditRecorder(ctx)
+		start := time.Now()
+		res, err := next(ctx, req)
+		ev.Time = time.Now()
+		ev.DurationMS = time.Since(start).Milliseconds()
+		ev.Queries = rec.Queries()
+		ev.Outcome = "success"
+		switch {
+		case err != nil:
+			ev.Outcome, ev.Error = "error", err.Error()
+		case res != nil && res.IsError:
+			ev.Outcome, ev.Error = "error", resultText(res)
+		}
+		if len(ev.Error) > maxAuditErrorLen {
+			ev.Error = ev.Error[:maxAuditErrorLen] + "...(truncated)"
+		}
+		a.write(ev)
+		return res, err
+	}
+}
+
+func (a *auditLog) write(ev auditEvent) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err := a.enc.Encode(ev); err != nil {
+		slog.Error("Writing audit event failed", "tool", ev.Tool, "error", err)
+		return
+	}
+	// Make sure destructive actions reach the disk before acknowledging them.
+	if f, ok := a.w.(*os.File); ok && ev.Destructive {
+		_ = f.Sync()
+	}
+}
+
+// auditUser returns the email and ID of the Logchef user behind the
+// session's API key, or zero values if they cannot be looked up.
+func auditUser(ctx context.Context) (string, int) {
+	c := mcplogchef.LogchefClientFromContext(ctx)
+	if c == nil {
+		return "", 0
+	}
+	ctx, cancel := context.WithTimeout(ctx, auditProfileTimeout)
+	defer cancel()
+	profile, err := c.GetProfile(ctx)
+	if err != nil {
+		slog.Debug("Audit user lookup failed", "error", err)
+		return "", 0
+	}
+	return profile.Data.User.Email, profile.Data.User.ID
+}
+
+// redactArguments returns a copy of args with the values of secret-looking
+// keys replaced.
+func redactArguments(args map[string]any) map[string]any {
+	if len(args) == 0 {
+		return nil
+	}
+	out := make(map[string]any, len(args))
+	for k, v := range args {
+		out[k] = redactArgument(k, v)
+	}
+	return out
+}
+
+func redactArgument(key string, v any) any {
+	lower := strings.ToLower(key)
+	for _, s := range auditSensitiveKeys {
+		if strings.Contains(lower, s) {
+			return "[REDACTED]"
+		}
+	}
+	switch t := v.(type) {
+	case map[string]any:
+		return redactArguments(t)
+	case []any:
+		out := make([]any, len(t))
+		for i, e := range t {
+			out[i] = redactArgument("", e)
+		}
+		return out
+	}
+	return v
+}
+
+// resultText returns the text content of a tool result.
+func resultText(res *mcp.CallToolResult) string {
+	var parts []string
+	for _, c := range res.Content {
+		if t, ok := c.(mcp.TextContent); ok {
+			parts = append(parts, t.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mr-karan/logchef-mcp/logcheftest"
+)
+
+func TestAuditLog(t *testing.T) {
+	var buf bytes.Buffer
+	audit := newAuditLog(&buf, []string{"logs"})
+	h := newServerHarness(t, newServer(options{dt: allTools()}, nil, audit), logcheftest.MemberKey)
+
+	h.callTool("get_teams", nil) // profile category, not audited
+	h.callTool("query_logs", map[string]any{
+		"team_id": 1, "source_id": 1, "raw_sql": "SELECT * FROM logs.app LIMIT 2", "limit": 2,
+	})
+	h.callTool("create_api_token", map[string]any{"name": "ci", "token": "hunter2"})
+	h.callTool("delete_team", map[string]any{"team_id": 1}) // admin, but destructive
+
+	var events []auditEvent
+	sc := bufio.NewScanner(&buf)
+	for sc.Scan() {
+		var ev auditEvent
+		if err := json.Unmarshal(sc.Bytes(), &ev); err != nil {
+			t.Fatalf("decode %s: %v", sc.Text(), err)
+		}
+		events = append(events, ev)
+	}
+	var tools []string
+	for _, ev := range events {
+		tools = append(tools, ev.Tool+":"+ev.Outcome)
+	}
+	if got, want := strings.Join(tools, ","), "query_logs:success,delete_team:started,delete_team:error"; got != want {
+		t.Fatalf("audited calls = %s, want %s", got, want)
+	}
+
+	q := events[0]
+	if q.Category != "logs" || q.TeamID != 1 || q.SourceID != 1 || q.User == "" || q.UserID == 0 {
+		t.Errorf("query_logs event = %+v", q)
+	}
+	if len(q.Queries) != 1 || q.Queries[0].SQL != "SELECT * FROM logs.app WHERE `timestamp` >= now() - INTERVAL 1 HOUR LIMIT 2" || q.Queries[0].Rows != 2 {
+		t.Errorf("query_logs queries = %+v", q.Queries)
+	}
+	if d := events[2]; !d.Destructive || d.Category != "admin" || d.Error == "" {
+		t.Errorf("delete_team event = %+v", d)
+	}
+}
+
+func TestRedactArguments(t *testing.T) {
+	got := redactArguments(map[string]any{
+		"name":     "ci",
+		"password": "hunter2",
+		"nested":   map[string]any{"api_key": "k", "keep": 1},
+	})
+	b, _ := json.Marshal(got)
+	if want := `{"name":"ci","nested":{"api_key":"[REDACTED]","keep":1},"password":"[REDACTED]"}`; string(b) != want {
+		t.Errorf("redacted = %s, want %s", b, want)
+	}
+}
@@ -0,0 +1,564 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"gopkg.in/yaml.v3"
+
+	mcplogchef "github.com/mr-karan/logchef-mcp"
+	"github.com/mr-karan/logchef-mcp/client"
+)
+
+// fileConfig is the config file read with --config. Every field is optional;
+// pointers distinguish "unset" from zero values so that unset fields keep
+// their flag or environment value.
+type fileConfig struct {
+	Transport    *string `yaml:"transport,omitempty" toml:"transport"`
+	Address      *string `yaml:"address,omitempty" toml:"address"`
+	BasePath     *string `yaml:"base_path,omitempty" toml:"base_path"`
+	EndpointPath *string `yaml:"endpoint_path,omitempty" toml:"endpoint_path"`
+	LogLevel     *string `yaml:"log_level,omitempty" toml:"log_level"`
+	// MetricsAddress serves /healthz, /readyz and /metrics on a separate listener.
+	MetricsAddress *string `yaml:"metrics_address,omitempty" toml:"metrics_address"`
+	// ShutdownTimeout is how long shutdown waits for running tool calls.
+	ShutdownTimeout *time.Duration `yaml:"shutdown_timeout,omitempty" toml:"shutdown_timeout"`
+
+	Logchef   logchefFileConfig             `yaml:"logchef" toml:"logchef"`
+	Instances map[string]instanceFileConfig `yaml:"instances,omitempty" toml:"instances"`
+	Tools     toolsFileConfig               `yaml:"tools" toml:"tools"`
+	Security  securityFileConfig            `yaml:"security" toml:"security"`
+	Audit     auditFileConfig               `yaml:"audit" toml:"audit"`
+}
+
+type auditFileConfig struct {
+	// Path is the audit log file, or "-" for stdout.
+	Path       *string  `yaml:"path,omitempty" toml:"path"`
+	Categories []string `yaml:"categories,omitempty" toml:"categories"`
+}
+
+type logchefFileConfig struct {
+	URL          *string `yaml:"url,omitempty" toml:"url"`
+	APIKey       *string `yaml:"api_key,omitempty" toml:"api_key"`
+	Debug        *bool   `yaml:"debug,omitempty" toml:"debug"`
+	DisableCache *bool   `yaml:"disable_cache,omitempty" toml:"disable_cache"`
+
+	// Outbound TLS, proxy and extra headers for requests to Logchef.
+	CAFile             *string           `yaml:"ca_file,omitempty" toml:"ca_file"`
+	ClientCert         *string           `yaml:"client_cert,omitempty" toml:"client_cert"`
+	ClientKey          *string           `yaml:"client_key,omitempty" toml:"client_key"`
+	InsecureSkipVerify *bool             `yaml:"insecure_skip_verify,omitempty" toml:"insecure_skip_verify"`
+	Proxy              *string           `yaml:"proxy,omitempty" toml:"proxy"`
+	Headers            map[string]string `yaml:"headers,omitempty" toml:"headers"`
+
+	Retry struct {
+		MaxAttempts    *int           `yaml:"max_attempts,omitempty" toml:"max_attempts"`
+		InitialBackoff *time.Duration `yaml:"initial_backoff,omitempty" toml:"initial_backoff"`
+		MaxBackoff     *time.Duration `yaml:"max_backoff,omitempty" toml:"max_backoff"`
+	} `yaml:"retry" toml:"retry"`
+
+	RateLimit struct {
+		RequestsPerSecond *float64 `yaml:"requests_per_second,omitempty" toml:"requests_per_second"`
+		Burst             *int     `yaml:"burst,omitempty" toml:"burst"`
+		MaxInFlight       *int     `yaml:"max_in_flight,omitempty" toml:"max_in_flight"`
+	} `yaml:"rate_limit" toml:"rate_limit"`
+}
+
+type toolsFileConfig struct {
+	// Enabled and Disabled list tool categories, as --enabled-tools and
+	// the --disable-* flags.
+	Enabled  []string `yaml:"enabled,omitempty" toml:"enabled"`
+	Disabled []string `yaml:"disabled,omitempty" toml:"disabled"`
+
+	DefaultTimezone *string                  `yaml:"default_timezone,omitempty" toml:"default_timezone"`
+	Limits          map[string]toolLimitFile `yaml:"limits,omitempty" toml:"limits"`
+
+	// ReadBudget bounds the rows a query may read, as --warn-read-rows and
+	// --max-read-rows.
+	ReadBudget struct {
+		WarnRows *int `yaml:"warn_rows,omitempty" toml:"warn_rows"`
+		MaxRows  *int `yaml:"max_rows,omitempty" toml:"max_rows"`
+	} `yaml:"read_budget" toml:"read_budget"`
+}
+
+type toolLimitFile struct {
+	MaxRows      int           `yaml:"max_rows,omitempty" toml:"max_rows"`
+	QueryTimeout time.Duration `yaml:"query_timeout,omitempty" toml:"query_timeout"`
+}
+
+type securityFileConfig struct {
+	ReadOnly   *bool    `yaml:"read_only,omitempty" toml:"read_only"`
+	AllowTools []string `yaml:"allow_tools,omitempty" toml:"allow_tools"`
+	DenyTools  []string `yaml:"deny_tools,omitempty" toml:"deny_tools"`
+
+	// AuthTokens are bearer tokens accepted from MCP clients over HTTP.
+	AuthTokens          []string `yaml:"auth_tokens,omitempty" toml:"auth_tokens"`
+	RequireAPIKeyHeader *bool    `yaml:"require_api_key_header,omitempty" toml:"require_api_key_header"`
+
+	// AllowedHosts and AllowedSchemes restrict the X-Logchef-URL header.
+	AllowedHosts   []string `yaml:"allowed_hosts,omitempty" toml:"allowed_hosts"`
+	AllowedSchemes []string `yaml:"allowed_schemes,omitempty" toml:"allowed_schemes"`
+
+	// TLSCert, TLSKey and ClientCA are PEM files for serving HTTPS and
+	// requiring client certificates.
+	TLSCert  *string `yaml:"tls_cert,omitempty" toml:"tls_cert"`
+	TLSKey   *string `yaml:"tls_key,omitempty" toml:"tls_key"`
+	ClientCA *string `yaml:"client_ca,omitempty" toml:"client_ca"`
+}
+
+// toolCategories lists the categories accepted by --enabled-tools.
+var toolCategories = strings.Split(defaultEnabledTools, ",")
+
+// loadConfig reads a config file, as TOML if its extension is .toml and as
+// YAML otherwise. Unknown keys are rejected so typos do not go unnoticed.
+func loadConfig(path string) (*fileConfig, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+	var fc fileConfig
+	if strings.EqualFold(filepath.Ext(path), ".toml") {
+		md, err := toml.Decode(string(b), &fc)
+		if err != nil {
+			return nil, fmt.Errorf("parse config %s: %w", path, err)
+		}
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			return nil, fmt.Errorf("parse config %s: unknown key %q", path, undecoded[0].String())
+		}
+		return &fc, nil
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	if err := dec.Decode(&fc); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("parse config %s: %w", path, err)
+	}
+	return &fc, nil
+}
+
+// configSetting is a config file value destined for a flag. env names the
+// environment variable that also sets the flag, if any.
+type configSetting struct {
+	flag  string
+	env   string
+	value string
+}
+
+// settings returns the config file values that map onto flags.
+func (fc *fileConfig) settings() []configSetting {
+	var s []configSetting
+	add := func(flag, env string, value *string) {
+		if value != nil {
+			s = append(s, configSetting{flag: flag, env: env, value: *value})
+		}
+	}
+	str := func(v string) *string { return &v }
+	add("transport", "", fc.Transport)
+	add("address", "", fc.Address)
+	add("base-path", "", fc.BasePath)
+	add("endpoint-path", "", fc.EndpointPath)
+	add("log-level", "", fc.LogLevel)
+	add("metrics-address", "LOGCHEF_METRICS_ADDRESS", fc.MetricsAddress)
+	if v := fc.ShutdownTimeout; v != nil {
+		add("shutdown-timeout", "LOGCHEF_SHUTDOWN_TIMEOUT", str(v.String()))
+	}
+
+	lc := fc.Logchef
+	if lc.Debug != nil {
+		add("debug", "", str(strconv.FormatBool(*lc.Debug)))
+	}
+	if lc.DisableCache != nil {
+		add("disable-cache", "", str(strconv.FormatBool(*lc.DisableCache)))
+	}
+	add("logchef-ca", "LOGCHEF_CA_FILE", lc.CAFile)
+	add("logchef-client-cert", "LOGCHEF_CLIENT_CERT", lc.ClientCert)
+	add("logchef-client-key", "LOGCHEF_CLIENT_KEY", lc.ClientKey)
+	if v := lc.InsecureSkipVerify; v != nil {
+		add("logchef-insecure-skip-verify", "LOGCHEF_INSECURE_SKIP_VERIFY", str(strconv.FormatBool(*v)))
+	}
+	add("logchef-proxy", "LOGCHEF_PROXY", lc.Proxy)
+	if lc.Headers != nil {
+		var headers []string
+		for _, name := range slices.Sorted(maps.Keys(lc.Headers)) {
+			headers = append(headers, name+": "+lc.Headers[name])
+		}
+		add("logchef-headers", "LOGCHEF_HEADERS", str(strings.Join(headers, ",")))
+	}
+	if v := lc.Retry.MaxAttempts; v != nil {
+		add("retry-max-attempts", "", str(strconv.Itoa(*v)))
+	}
+	if v := lc.Retry.InitialBackoff; v != nil {
+		add("retry-initial-backoff", "", str(v.String()))
+	}
+	if v := lc.Retry.MaxBackoff; v != nil {
+		add("retry-max-backoff", "", str(v.String()))
+	}
+	if v := lc.RateLimit.RequestsPerSecond; v != nil {
+		add("rate-limit", "LOGCHEF_RATE_LIMIT", str(strconv.FormatFloat(*v, 'f', -1, 64)))
+	}
+	if v := lc.RateLimit.Burst; v != nil {
+		add("rate-limit-burst", "LOGCHEF_RATE_LIMIT_BURST", str(strconv.Itoa(*v)))
+	}
+	if v := lc.RateLimit.MaxInFlight; v != nil {
+		add("max-in-flight", "LOGCHEF_MAX_IN_FLIGHT", str(strconv.Itoa(*v)))
+	}
+
+	if fc.Tools.Enabled != nil {
+		add("enabled-tools", "", str(strings.Join(fc.Tools.Enabled, ",")))
+	}
+	for _, category := range fc.Tools.Disabled {
+		add("disable-"+category, "", str("true"))
+	}
+	add("default-timezone", "LOGCHEF_DEFAULT_TIMEZONE", fc.Tools.DefaultTimezone)
+	if v := fc.Tools.ReadBudget.WarnRows; v != nil {
+		add("warn-read-rows", "LOGCHEF_WARN_READ_ROWS", str(strconv.Itoa(*v)))
+	}
+	if v := fc.Tools.ReadBudget.MaxRows; v != nil {
+		add("max-read-rows", "LOGCHEF_MAX_READ_ROWS", str(strconv.Itoa(*v)))
+	}
+
+	if v := fc.Security.ReadOnly; v != nil {
+		add("read-only", "LOGCHEF_READ_ONLY", str(strconv.FormatBool(*v)))
+	}
+	if fc.Security.AllowTools != nil {
+		add("allow-tools", "", str(strings.Join(fc.Security.AllowTools, ",")))
+	}
+	if fc.Security.DenyTools != nil {
+		add("deny-tools", "", str(strings.Join(fc.Security.DenyTools, ",")))
+	}
+	if v := fc.Security.RequireAPIKeyHeader; v != nil {
+		add("require-api-key-header", "LOGCHEF_REQUIRE_API_KEY_HEADER", str(strconv.FormatBool(*v)))
+	}
+	if fc.Security.AllowedHosts != nil {
+		add("allowed-hosts", "LOGCHEF_ALLOWED_HOSTS", str(strings.Join(fc.Security.AllowedHosts, ",")))
+	}
+	if fc.Security.AllowedSchemes != nil {
+		add("allowed-schemes", "LOGCHEF_ALLOWED_SCHEMES", str(strings.Join(fc.Security.AllowedSchemes, ",")))
+	}
+	add("audit-log", "LOGCHEF_AUDIT_LOG", fc.Audit.Path)
+	if fc.Audit.Categories != nil {
+		add("audit-categories", "LOGCHEF_AUDIT_CATEGORIES", str(strings.Join(fc.Audit.Categories, ",")))
+	}
+	add("tls-cert", "LOGCHEF_TLS_CERT", fc.Security.TLSCert)
+	add("tls-key", "LOGCHEF_TLS_KEY", fc.Security.TLSKey)
+	add("client-ca", "LOGCHEF_CLIENT_CA", fc.Security.ClientCA)
+	return s
+}
+
+// load merges the config file named by --config into o and validates the
+// result. Config file values apply only where neither the flag nor its
+// environment variable was set, giving config < env < flags.
+func (o *options) load(fs *flag.FlagSet) error {
+	if o.configPath != "" {
+		fc, err := loadConfig(o.configPath)
+		if err != nil {
+			return err
+		}
+		if err := o.applyConfig(fs, fc); err != nil {
+			return err
+		}
+	}
+	// LOGCHEF_URL and LOGCHEF_API_KEY win over the config file, as they do
+	// for each request, so the effective configuration shows them.
+	if u := strings.TrimRight(os.Getenv("LOGCHEF_URL"), "/"); u != "" {
+		o.lc.url = u
+	}
+	if k := os.Getenv("LOGCHEF_API_KEY"); k != "" {
+		o.lc.apiKey = k
+	}
+	return o.validate()
+}
+
+func (o *options) applyConfig(fs *flag.FlagSet, fc *fileConfig) error {
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if set["t"] {
+		set["transport"] = true
+	}
+	for _, category := range fc.Tools.Disabled {
+		if !slices.Contains(toolCategories, category) {
+			return fmt.Errorf("config tools.disabled: unknown tool category %q", category)
+		}
+	}
+	for _, s := range fc.settings() {
+		if set[s.flag] {
+			continue
+		}
+		if _, ok := os.LookupEnv(s.env); s.env != "" && ok {
+			continue
+		}
+		if err := fs.Set(s.flag, s.value); err != nil {
+			return fmt.Errorf("config value for --%s: %w", s.flag, err)
+		}
+	}
+
+	if _, ok := os.LookupEnv("LOGCHEF_MCP_AUTH_TOKENS"); !ok && fc.Security.AuthTokens != nil {
+		o.auth.Tokens = nil
+		for _, t := range fc.Security.AuthTokens {
+			if t == "" {
+				return errors.New("config security.auth_tokens: empty token")
+			}
+			o.auth.Tokens = append(o.auth.Tokens, t)
+		}
+	}
+	if fc.Logchef.URL != nil {
+		o.lc.url = *fc.Logchef.URL
+	}
+	if fc.Logchef.APIKey != nil {
+		o.lc.apiKey = *fc.Logchef.APIKey
+	}
+	instances, err := parseInstances(fc.Instances)
+	if err != nil {
+		return err
+	}
+	o.instances = instances
+	if len(fc.Tools.Limits) > 0 {
+		o.limits.tools = make(map[string]mcplogchef.ToolLimit, len(fc.Tools.Limits))
+		for name, l := range fc.Tools.Limits {
+			if l.MaxRows < 0 || l.QueryTimeout < 0 {
+				return fmt.Errorf("config tools.limits.%s: limits must not be negative", name)
+			}
+			o.limits.tools[name] = mcplogchef.ToolLimit{MaxRows: l.MaxRows, QueryTimeout: l.QueryTimeout}
+		}
+	}
+	return nil
+}
+
+// validate checks the merged configuration and parses the URL policy.
+func (o *options) validate() error {
+	if !slices.Contains([]string{"stdio", "sse", "streamable-http"}, o.transport) {
+		return fmt.Errorf("invalid transport %q: must be 'stdio', 'sse', or 'streamable-http'", o.transport)
+	}
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(o.logLevel)); err != nil {
+		return fmt.Errorf("invalid log level %q", o.logLevel)
+	}
+	for _, category := range strings.Split(o.dt.enabledTools, ",") {
+		if category = strings.TrimSpace(category); category != "" && !slices.Contains(toolCategories, category) {
+			return fmt.Errorf("unknown tool category %q in enabled tools", category)
+		}
+	}
+	for _, category := range splitList(o.auditCategories) {
+		if !slices.Contains(toolCategories, category) {
+			return fmt.Errorf("unknown tool category %q in audit categories", category)
+		}
+	}
+	if o.auditPath == "-" && o.transport == "stdio" {
+		return errors.New("audit log cannot be written to stdout with the stdio transport; use a file")
+	}
+	if err := o.tls.validate(); err != nil {
+		return err
+	}
+	if o.shutdownTimeout < 0 {
+		return errors.New("shutdown timeout must not be negative")
+	}
+	if b := o.limits.readBudget; b.WarnRows < 0 || b.MaxRows < 0 {
+		return errors.New("read budget must not be negative")
+	}
+	policy, err := mcplogchef.ParseURLPolicy(splitList(o.allowedHosts), splitList(o.allowedSchemes))
+	if err != nil {
+		return fmt.Errorf("allowed hosts: %w", err)
+	}
+	o.urlPolicy = policy
+	lc := o.lc
+	if lc.retryMaxAttempts < 0 || lc.retryInitialBackoff < 0 || lc.retryMaxBackoff < 0 ||
+		lc.rateLimit < 0 || lc.rateLimitBurst < 0 || lc.maxInFlight < 0 {
+		return errors.New("retry and rate limit settings must not be negative")
+	}
+	if o.lc.headerMap, err = parseHeaders(o.lc.headers); err != nil {
+		return fmt.Errorf("logchef headers: %w", err)
+	}
+	if !lc.tc.IsZero() {
+		t, err := client.NewTransport(lc.tc)
+		if err != nil {
+			return fmt.Errorf("logchef transport: %w", err)
+		}
+		o.lc.transport = t
+	}
+	return nil
+}
+
+// writeEffectiveConfig prints the merged configuration as YAML, in the config
+// file format, with secrets redacted.
+func writeEffectiveConfig(w io.Writer, o options) error {
+	var fc fileConfig
+	fc.Transport = &o.transport
+	fc.Address = &o.address
+	fc.BasePath = &o.basePath
+	fc.EndpointPath = &o.endpointPath
+	fc.LogLevel = &o.logLevel
+	if o.metricsAddress != "" {
+		fc.MetricsAddress = &o.metricsAddress
+	}
+	fc.ShutdownTimeout = &o.shutdownTimeout
+
+	lc := o.lc
+	const redacted = "[REDACTED]"
+	// URLs may carry user:password@ credentials.
+	redactURL := func(s string) string {
+		u, err := url.Parse(s)
+		if err != nil {
+			return redacted
+		}
+		return u.Redacted()
+	}
+	if lc.url != "" {
+		fc.Logchef.URL = ptr(redactURL(lc.url))
+	}
+	if lc.apiKey != "" {
+		fc.Logchef.APIKey = ptr(redacted)
+	}
+	fc.Logchef.Debug = &lc.debug
+	fc.Logchef.DisableCache = &lc.disableCache
+	if lc.tc.CAFile != "" {
+		fc.Logchef.CAFile = &lc.tc.CAFile
+	}
+	if lc.tc.CertFile != "" {
+		fc.Logchef.ClientCert = &lc.tc.CertFile
+		fc.Logchef.ClientKey = &lc.tc.KeyFile
+	}
+	fc.Logchef.InsecureSkipVerify = &lc.tc.InsecureSkipVerify
+	if lc.tc.ProxyURL != "" {
+		// validate has already rejected unparsable proxy URLs.
+		u, _ := url.Parse(lc.tc.ProxyURL)
+		fc.Logchef.Proxy = ptr(u.Redacted())
+	}
+	// Custom headers often carry credentials, so only their names are shown.
+	for name := range lc.headerMap {
+		if fc.Logchef.Headers == nil {
+			fc.Logchef.Headers = make(map[string]string)
+		}
+		fc.Logchef.Headers[name] = redacted
+	}
+	fc.Logchef.Retry.MaxAttempts = &lc.retryMaxAttempts
+	fc.Logchef.Retry.InitialBackoff = &lc.retryInitialBackoff
+	fc.Logchef.Retry.MaxBackoff = &lc.retryMaxBackoff
+	fc.Logchef.RateLimit.RequestsPerSecond = &lc.rateLimit
+	fc.Logchef.RateLimit.Burst = &lc.rateLimitBurst
+	fc.Logchef.RateLimit.MaxInFlight = &lc.maxInFlight
+
+	if len(o.instances) > 0 {
+		fc.Instances = make(map[string]instanceFileConfig, len(o.instances))
+		for _, inst := range o.instances {
+			ic := instanceFileConfig{URL: redactURL(inst.URL), Description: inst.Description}
+			if inst.APIKey != "" {
+				ic.APIKey = redacted
+			}
+			fc.Instances[inst.Name] = ic
+		}
+	}
+
+	dt := o.dt
+	fc.Tools.Enabled = strings.Split(dt.enabledTools, ",")
+	for _, c := range []struct {
+		name     string
+		disabled bool
+	}{
+		{"profile", dt.profile}, {"sources", dt.sources}, {"logs", dt.logs},
+		{"logchefql", dt.logchefql}, {"investigate", dt.investigate}, {"admin", dt.admin},
+		{"analysis", dt.analysis}, {"telemetry", dt.telemetry}, {"discover", dt.discover},
+	} {
+		if c.disabled {
+			fc.Tools.Disabled = append(fc.Tools.Disabled, c.name)
+		}
+	}
+	if o.limits.defaultTimezone != "" {
+		fc.Tools.DefaultTimezone = &o.limits.defaultTimezone
+	}
+	if b := o.limits.readBudget; b.Enabled() {
+		fc.Tools.ReadBudget.WarnRows = &b.WarnRows
+		fc.Tools.ReadBudget.MaxRows = &b.MaxRows
+	}
+	if len(o.limits.tools) > 0 {
+		fc.Tools.Limits = make(map[string]toolLimitFile, len(o.limits.tools))
+		for name, l := range o.limits.tools {
+			fc.Tools.Limits[name] = toolLimitFile{MaxRows: l.MaxRows, QueryTimeout: l.QueryTimeout}
+		}
+	}
+
+	fc.Security.ReadOnly = &dt.readOnly
+	fc.Security.RequireAPIKeyHeader = &o.auth.RequireAPIKeyHeader
+	fc.Security.AllowedHosts = splitList(o.allowedHosts)
+	fc.Security.AllowedSchemes = splitList(o.allowedSchemes)
+	if o.auditPath != "" {
+		fc.Audit.Path = &o.auditPath
+		fc.Audit.Categories = splitList(o.auditCategories)
+	}
+	if o.tls.enabled() {
+		fc.Security.TLSCert = &o.tls.cert
+		fc.Security.TLSKey = &o.tls.key
+	}
+	if o.tls.clientCA != "" {
+		fc.Security.ClientCA = &o.tls.clientCA
+	}
+	for range o.auth.Tokens {
+		fc.Security.AuthTokens = append(fc.Security.AuthTokens, redacted)
+	}
+	for _, p := range dt.filter.allow {
+		fc.Security.AllowTools = append(fc.Security.AllowTools, p.raw)
+	}
+	for _, p := range dt.filter.deny {
+		fc.Security.DenyTools = append(fc.Security.DenyTools, p.raw)
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(fc); err != nil {
+		return fmt.Errorf("encode config: %w", err)
+	}
+	return enc.Close()
+}
+
+func ptr[T any](v T) *T { return &v }
+
+// toolLimits holds the operator's per-tool limits, default timezone and read
+// budget, injected into each tool call's context.
+type toolLimits struct {
+	defaultTimezone string
+	tools           map[string]mcplogchef.ToolLimit
+	readBudget      mcplogchef.ReadBudget
+}
+
+func (l toolLimits) enabled() bool {
+	return l.defaultTimezone != "" || len(l.tools) > 0 || l.readBudget.Enabled()
+}
+
+func (l toolLimits) middleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if l.defaultTimezone != "" {
+			ctx = mcplogchef.WithDefaultTimezone(ctx, l.defaultTimezone)
+		}
+		if limit, ok := l.tools[req.Params.Name]; ok {
+			ctx = mcplogchef.WithToolLimit(ctx, limit)
+		}
+		if l.readBudget.Enabled() {
+			ctx = mcplogchef.WithReadBudget(ctx, l.readBudget)
+		}
+		return next(ctx, req)
+	}
+}
+
+// warnUnknown logs limits configured for tools that are not registered.
+func (l toolLimits) warnUnknown(s *server.MCPServer) {
+	registered := s.ListTools()
+	for name := range l.tools {
+		if _, ok := registered[name]; !ok {
+			slog.Warn("Limits configured for unregistered tool", "tool", name)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/mr-karan/logchef-mcp/logcheftest"
+	"github.com/mr-karan/logchef-mcp/tools"
+)
+
+// elicitor answers elicitation requests with a fixed response and records
+// the messages it was shown.
+type elicitor struct {
+	res      mcp.ElicitationResponse
+	messages []string
+}
+
+func (e *elicitor) Elicit(ctx context.Context, req mcp.ElicitationRequest) (*mcp.ElicitationResult, error) {
+	e.messages = append(e.messages, req.Params.Message)
+	return &mcp.ElicitationResult{ElicitationResponse: e.res}, nil
+}
+
+func TestDeleteConfirmToken(t *testing.T) {
+	h := newHarness(t, allTools(), logcheftest.AdminKey)
+
+	var pending tools.DeleteResult
+	decodeText(t, h.callTool("delete_team", map[string]any{"team_id": 1}), &pending)
+	if pending.Success || pending.ConfirmToken == "" {
+		t.Fatalf("first call = %+v, want a pending confirmation", pending)
+	}
+	for _, want := range []string{`"platform"`, "2 members lose access", "1 source (app-logs) is unlinked", "1 collection (API errors) is orphaned"} {
+		if !strings.Contains(pending.Impact, want) {
+			t.Errorf("impact %q does not mention %q", pending.Impact, want)
+		}
+	}
+	if n := h.fake.CountRequests("DELETE", "/api/v1/admin/teams/1"); n != 0 {
+		t.Fatalf("team deleted before confirmation")
+	}
+
+	// A token for a different target is rejected.
+	var other tools.DeleteResult
+	decodeText(t, h.callTool("delete_team", map[string]any{"team_id": 2, "confirm_token": pending.ConfirmToken}), &other)
+	if other.Success || !strings.Contains(other.Message, "invalid") {
+		t.Fatalf("token reused for team 2 = %+v", other)
+	}
+
+	var done tools.DeleteResult
+	decodeText(t, h.callTool("delete_team", map[string]any{"team_id": 1, "confirm_token": pending.ConfirmToken}), &done)
+	if !done.Success {
+		t.Fatalf("confirmed call = %+v", done)
+	}
+	if n := h.fake.CountRequests("DELETE", "/api/v1/admin/teams/1"); n != 1 {
+		t.Errorf("DELETE requests = %d, want 1", n)
+	}
+}
+
+func TestDeleteAPITokenLastUsedChanges(t *testing.T) {
+	h := newHarness(t, allTools(), logcheftest.AdminKey)
+
+	var pending tools.DeleteResult
+	decodeText(t, h.callTool("delete_api_token", map[string]any{"token_id": 1}), &pending)
+	if pending.Success || pending.ConfirmToken == "" || !strings.Contains(pending.Impact, "never used") {
+		t.Fatalf("first call = %+v, want a pending confirmation", pending)
+	}
+
+	// The token is used between the two calls, as a leaked token would be.
+	lastUsed := "2026-03-01T10:00:00Z"
+	h.fake.Dataset().Tokens[0].LastUsedAt = &lastUsed
+
+	var done tools.DeleteResult
+	decodeText(t, h.callTool("delete_api_token", map[string]any{"token_id": 1, "confirm_token": pending.ConfirmToken}), &done)
+	if !done.Success {
+		t.Fatalf("confirmed call after the token was used = %+v", done)
+	}
+	if n := h.fake.CountRequests("DELETE", "/api/v1/me/tokens/1"); n != 1 {
+		t.Errorf("DELETE requests = %d, want 1", n)
+	}
+}
+
+func TestDeleteElicitation(t *testing.T) {
+	t.Run("accept", func(t *testing.T) {
+		e := &elicitor{res: mcp.ElicitationResponse{
+			Action:  mcp.ElicitationResponseActionAccept,
+			Content: map[string]any{"confirm": true},
+		}}
+		h := newElicitationHarness(t, newServer(options{dt: allTools()}, nil, nil), logcheftest.AdminKey, e)
+
+		res := h.callTool("delete_source", map[string]any{"source_id": 2})
+		var done tools.DeleteResult
+		decodeText(t, res, &done)
+		if !done.Success {
+			t.Fatalf("delete_source = %+v", done)
+		}
+		if len(e.messages) != 1 || !strings.Contains(e.messages[0], `"payments-logs"`) || !strings.Contains(e.messages[0], "1 team (payments)") {
+			t.Errorf("elicitation messages = %q", e.messages)
+		}
+	})
+
+	t.Run("decline", func(t *testing.T) {
+		e := &elicitor{res: mcp.ElicitationResponse{Action: mcp.ElicitationResponseActionDecline}}
+		h := newElicitationHarness(t, newServer(options{dt: allTools()}, nil, nil), logcheftest.AdminKey, e)
+
+		res := h.callTool("delete_user", map[string]any{"user_id": 2})
+		if !res.IsError {
+			t.Fatalf("delete_user succeeded after the user declined")
+		}
+		if len(e.messages) != 1 || !strings.Contains(e.messages[0], "member@example.com") {
+			t.Errorf("elicitation messages = %q", e.messages)
+		}
+		if n := h.fake.CountRequests("DELETE", "/api/v1/admin/users/2"); n != 0 {
+			t.Errorf("DELETE requests = %d, want 0", n)
+		}
+	})
+}
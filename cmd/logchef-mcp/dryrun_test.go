@@ -0,0 +1,129 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/mr-karan/logchef-mcp/client"
+	"github.com/mr-karan/logchef-mcp/logcheftest"
+	"github.com/mr-karan/logchef-mcp/tools"
+)
+
+func TestDryRun(t *testing.T) {
+	h := newHarness(t, allTools(), logcheftest.AdminKey)
+
+	var team tools.TeamChangeResult
+	decodeText(t, h.callTool("update_team", map[string]any{"team_id": 2, "name": "billing", "dry_run": true}), &team)
+	if team.Name != "billing" || team.Description != "Payments team" || team.Plan == nil {
+		t.Fatalf("update_team dry run = %+v", team)
+	}
+	if want := []tools.FieldChange{{Field: "name", Before: "payments", After: "billing"}}; !slices.Equal(team.Plan.Changes, want) {
+		t.Errorf("update_team changes = %+v, want %+v", team.Plan.Changes, want)
+	}
+
+	var created tools.TeamChangeResult
+	decodeText(t, h.callTool("create_team", map[string]any{"name": "Platform", "dry_run": true}), &created)
+	if created.Plan == nil || len(created.Plan.Warnings) != 1 || !strings.Contains(created.Plan.Warnings[0], `"platform"`) {
+		t.Errorf("create_team dry run = %+v, want a duplicate name warning", created.Plan)
+	}
+
+	var user tools.UserChangeResult
+	decodeText(t, h.callTool("update_user", map[string]any{"user_id": 1, "role": "member", "dry_run": true}), &user)
+	if user.Role != "member" || user.Plan == nil || !slices.ContainsFunc(user.Plan.Warnings, func(w string) bool {
+		return strings.Contains(w, "lose admin access")
+	}) {
+		t.Errorf("update_user dry run on self = %+v", user)
+	}
+
+	var link tools.ChangeResult
+	decodeText(t, h.callTool("link_source_to_team", map[string]any{"team_id": 1, "source_id": 1, "dry_run": true}), &link)
+	if link.Success || link.Plan == nil || len(link.Plan.Changes) != 0 || len(link.Plan.Warnings) != 1 {
+		t.Errorf("link_source_to_team dry run for a linked source = %+v", link)
+	}
+
+	if res := h.callTool("add_team_member", map[string]any{"team_id": 1, "user_id": 2, "role": "superuser", "dry_run": true}); !res.IsError {
+		t.Error("add_team_member dry run accepted an invalid role")
+	}
+
+	for _, path := range []string{"/api/v1/admin/teams", "/api/v1/teams/2", "/api/v1/admin/users/1", "/api/v1/teams/1/sources", "/api/v1/teams/1/members"} {
+		for _, method := range []string{"POST", "PUT"} {
+			if n := h.fake.CountRequests(method, path); n != 0 {
+				t.Errorf("dry runs sent %d %s %s requests", n, method, path)
+			}
+		}
+	}
+}
+
+func TestDryRunCollection(t *testing.T) {
+	// Collections are team-scoped, so members can plan changes to them.
+	h := newHarness(t, allTools(), logcheftest.MemberKey)
+
+	var col tools.CollectionChangeResult
+	decodeText(t, h.callTool("update_collection", map[string]any{
+		"team_id": 1, "source_id": 1, "collection_id": 1,
+		"name": "API errors", "query": `level="error"`, "dry_run": true,
+	}), &col)
+	if col.Plan == nil || len(col.Plan.Changes) != 1 || col.Plan.Changes[0].Field != "query" || col.Query != `level="error"` {
+		t.Fatalf("update_collection dry run = %+v", col)
+	}
+	if n := h.fake.CountRequests("PUT", "/api/v1/teams/1/sources/1/collections/1"); n != 0 {
+		t.Errorf("dry run sent %d updates", n)
+	}
+
+	if res := h.callTool("create_user", map[string]any{
+		"email": "new@example.com", "full_name": "New", "role": "member", "status": "active", "dry_run": true,
+	}); !res.IsError {
+		t.Error("create_user dry run succeeded for a non-admin")
+	}
+}
+
+func TestDryRunMatchesTeamPermissions(t *testing.T) {
+	// Team changes are authorized by Logchef per team, not by the global
+	// admin role, so dry runs must refuse exactly what the real calls do.
+	h := newHarness(t, allTools(), logcheftest.MemberKey)
+	calls := []struct {
+		tool string
+		args map[string]any
+		warn string
+	}{
+		{"update_team", map[string]any{"team_id": 1, "description": "Platform team"}, ""},
+		{"link_source_to_team", map[string]any{"team_id": 1, "source_id": 2}, "could not check that source 2 exists"},
+		{"add_team_member", map[string]any{"team_id": 1, "user_id": 1, "role": "editor"}, "could not check that user 1 exists"},
+	}
+	run := func(teamAdmin bool) {
+		t.Helper()
+		for _, c := range calls {
+			c.args["dry_run"] = true
+			dry := h.callTool(c.tool, c.args)
+			c.args["dry_run"] = false
+			real := h.callTool(c.tool, c.args)
+			if dry.IsError != real.IsError || real.IsError == teamAdmin {
+				t.Errorf("team admin %v: %s dry run error %v, real call error %v: %v", teamAdmin, c.tool, dry.IsError, real.IsError, real.Content)
+				continue
+			}
+			if teamAdmin && c.warn != "" {
+				var plan struct {
+					Plan *tools.ChangePlan `json:"plan"`
+				}
+				decodeText(t, dry, &plan)
+				if !slices.ContainsFunc(plan.Plan.Warnings, func(w string) bool { return strings.Contains(w, c.warn) }) {
+					t.Errorf("%s dry run warnings = %q, want %q", c.tool, plan.Plan.Warnings, c.warn)
+				}
+			}
+		}
+	}
+
+	run(false)
+	// Make the member the team's only admin, leaving user 1 free to add.
+	admin := h.fake.Client(logcheftest.AdminKey)
+	for _, userID := range []int{1, 2} {
+		if err := admin.RemoveTeamMember(h.ctx, 1, userID); err != nil {
+			t.Fatalf("remove member: %v", err)
+		}
+	}
+	if err := admin.AddTeamMember(h.ctx, 1, client.TeamMemberRequest{UserID: 2, Role: "admin"}); err != nil {
+		t.Fatalf("promote member: %v", err)
+	}
+	run(true)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	mcplogchef "github.com/mr-karan/logchef-mcp"
+	"github.com/mr-karan/logchef-mcp/logcheftest"
+	"github.com/mr-karan/logchef-mcp/tools"
+)
+
+func TestReadBudget(t *testing.T) {
+	// The fake estimates every query of source 1 at its 8 fixture rows.
+	budgetHarness := func(budget mcplogchef.ReadBudget) *harness {
+		limits := toolLimits{readBudget: budget}
+		return newServerHarness(t, newServer(options{dt: allTools(), limits: limits}, nil, nil), logcheftest.MemberKey)
+	}
+	sqlArgs := map[string]any{"team_id": 1, "source_id": 1, "raw_sql": "SELECT * FROM logs.app WHERE timestamp > '2026-03-01'", "limit": 2}
+	qlArgs := map[string]any{"team_id": 1, "source_id": 1, "query": "", "limit": 2}
+
+	t.Run("refuse", func(t *testing.T) {
+		h := budgetHarness(mcplogchef.ReadBudget{MaxRows: 5})
+		for tool, args := range map[string]map[string]any{"query_logs": sqlArgs, "query_logchefql": qlArgs} {
+			res := h.callTool(tool, args)
+			if !res.IsError || !strings.Contains(fmt.Sprint(res.Content), "over the read budget of 5 rows") {
+				t.Errorf("%s over budget = %v", tool, res.Content)
+			}
+		}
+		if n := h.fake.CountRequests("POST", "/api/v1/teams/1/sources/1/logchefql/query"); n != 0 {
+			t.Errorf("query_logchefql ran %d queries over budget", n)
+		}
+
+		var est tools.EstimateResult
+		decodeText(t, h.callTool("estimate_query", sqlArgs), &est)
+		if est.Rows != 8 || !est.OverBudget || est.MaxRows != 5 || len(est.Warnings) != 1 {
+			t.Errorf("estimate_query = %+v", est)
+		}
+	})
+
+	t.Run("warn", func(t *testing.T) {
+		h := budgetHarness(mcplogchef.ReadBudget{WarnRows: 5, MaxRows: 100})
+		var page struct {
+			Data  []map[string]any `json:"data"`
+			Notes []string         `json:"notes"`
+		}
+		decodeText(t, h.callTool("query_logs", sqlArgs), &page)
+		if len(page.Data) != 2 || len(page.Notes) != 1 || !strings.Contains(page.Notes[0], "warning threshold of 5 rows") {
+			t.Errorf("query_logs over warning threshold = %+v", page)
+		}
+	})
+
+	t.Run("estimate fails", func(t *testing.T) {
+		explainFails := logcheftest.Fault{Method: "POST", Path: "/api/v1/teams/1/sources/1/logs/query", Status: 400, Message: "EXPLAIN is not allowed"}
+
+		h := budgetHarness(mcplogchef.ReadBudget{MaxRows: 100})
+		h.fake.Inject(explainFails)
+		res := h.callTool("query_logs", sqlArgs)
+		if !res.IsError || !strings.Contains(fmt.Sprint(res.Content), "could not be checked against the read budget of 100 rows") {
+			t.Errorf("query_logs with a failed estimate = %v", res.Content)
+		}
+		if n := h.fake.CountRequests("POST", "/api/v1/teams/1/sources/1/logs/query"); n != 1 {
+			t.Errorf("query_logs sent %d queries, want only the estimate", n)
+		}
+
+		h = budgetHarness(mcplogchef.ReadBudget{WarnRows: 5})
+		h.fake.Inject(explainFails)
+		var page struct {
+			Data  []map[string]any `json:"data"`
+			Notes []string         `json:"notes"`
+		}
+		decodeText(t, h.callTool("query_logs", sqlArgs), &page)
+		if len(page.Data) != 2 || len(page.Notes) != 1 || !strings.Contains(page.Notes[0], "budget was not checked") {
+			t.Errorf("query_logs with a failed estimate and no maximum = %+v", page)
+		}
+	})
+}
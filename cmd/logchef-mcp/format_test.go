@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/mr-karan/logchef-mcp/logcheftest"
+	"github.com/mr-karan/logchef-mcp/tools"
+)
+
+// contentText returns the text of every content block of a tool result.
+func contentText(t *testing.T, res *mcp.CallToolResult) []string {
+	t.Helper()
+	if res.IsError {
+		t.Fatalf("tool error: %v", res.Content)
+	}
+	var texts []string
+	for _, c := range res.Content {
+		text, ok := c.(mcp.TextContent)
+		if !ok {
+			t.Fatalf("content is %T, want text", c)
+		}
+		texts = append(texts, text.Text)
+	}
+	return texts
+}
+
+func TestLogFormats(t *testing.T) {
+	h := newHarness(t, allTools(), logcheftest.AdminKey)
+
+	texts := contentText(t, h.callTool("query_logchefql", map[string]any{
+		"team_id": 1, "source_id": 1, "query": `level="error"`, "limit": 2, "format": "logfmt",
+	}))
+	want := `timestamp="2026-03-01 10:06:00" level=error service=worker message="database connection refused" status=500` + "\n"
+	if len(texts) != 2 || !strings.HasPrefix(texts[0], want) || strings.Count(texts[0], "\n") != 2 {
+		t.Fatalf("logfmt rows = %q", texts)
+	}
+	var meta map[string]any
+	if err := json.Unmarshal([]byte(texts[1]), &meta); err != nil {
+		t.Fatalf("decode meta: %v", err)
+	}
+	if _, ok := meta["logs"]; ok || meta["has_more"] != true || meta["next_cursor"] == "" {
+		t.Errorf("logfmt meta = %v", meta)
+	}
+
+	texts = contentText(t, h.callTool("query_logs", map[string]any{
+		"team_id": 1, "source_id": 1, "format": "csv", "columns": []string{"timestamp", "message"},
+		"raw_sql": "SELECT * FROM logs.app WHERE timestamp >= '2026-03-01 00:00:00' ORDER BY timestamp DESC",
+	}))
+	if lines := strings.Split(texts[0], "\n"); len(lines) != 10 || lines[0] != "timestamp,message" {
+		t.Errorf("csv rows = %q", texts[0])
+	}
+
+	texts = contentText(t, h.callTool("get_log_context", map[string]any{
+		"team_id": 1, "source_id": 1, "timestamp": 1772359380000, "format": "ndjson", "columns": []string{"message"},
+	}))
+	if !strings.Contains(texts[0], `{"_context":"target","message":"database connection refused"}`) {
+		t.Errorf("ndjson context rows = %q", texts[0])
+	}
+
+	// A context column of the source is kept next to the _context marker.
+	ds := logcheftest.DefaultDataset()
+	for _, row := range ds.Logs[1] {
+		row["context"] = "checkout"
+	}
+	texts = contentText(t, newHarness(t, allTools(), logcheftest.AdminKey, logcheftest.WithDataset(ds)).callTool("get_log_context", map[string]any{
+		"team_id": 1, "source_id": 1, "timestamp": 1772359380000, "format": "ndjson", "columns": []string{"message", "context"},
+	}))
+	if !strings.Contains(texts[0], `{"_context":"target","context":"checkout","message":"database connection refused"}`) {
+		t.Errorf("ndjson context rows with a context column = %q", texts[0])
+	}
+
+	res := h.callTool("compare_windows", map[string]any{
+		"team_id": 1, "source_id": 1, "query": `level="error"`, "format": "markdown",
+		"window1_start": "2026-03-01 10:00:00", "window1_end": "2026-03-01 10:04:00",
+		"window2_start": "2026-03-01 10:04:00", "window2_end": "2026-03-01 10:08:00",
+	})
+	texts = contentText(t, res)
+	if !strings.HasPrefix(texts[0], "| window | start | end | row_count | row_count_percent | query_id |\n") || !strings.Contains(texts[0], "| delta |  |  | ") {
+		t.Errorf("markdown windows = %q", texts[0])
+	}
+	var result tools.CompareWindowsResult
+	b, _ := json.Marshal(res.StructuredContent)
+	if err := json.Unmarshal(b, &result); err != nil || result.Window1.RowCount == 0 {
+		t.Errorf("structured content = %s", b)
+	}
+
+	if res := h.callTool("query_logchefql", map[string]any{"team_id": 1, "source_id": 1, "query": "", "format": "yaml"}); !res.IsError {
+		t.Error("unknown format accepted")
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	mcpclient "github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	mcplogchef "github.com/mr-karan/logchef-mcp"
+	"github.com/mr-karan/logchef-mcp/logcheftest"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files in testdata/")
+
+// fixedClock pins created_at/updated_at on records created during tests so
+// golden files are stable.
+func fixedClock() time.Time {
+	return time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+}
+
+// harness drives the MCP server built by newServer through an in-process
+// mcp-go client, with a fake Logchef behind the injected client.
+type harness struct {
+	t      *testing.T
+	ctx    context.Context
+	fake   *logcheftest.Server
+	client *mcpclient.Client
+}
+
+// allTools enables every tool category, matching the flag defaults.
+func allTools() disabledTools {
+	return disabledTools{enabledTools: defaultEnabledTools}
+}
+
+// newHarness starts a fake Logchef and an initialized MCP session against
+// newServer(dt). Tool calls authenticate with apiKey.
+func newHarness(t *testing.T, dt disabledTools, apiKey string, opts ...logcheftest.Option) *harness {
+	t.Helper()
+	return newServerHarness(t, newServer(options{dt: dt}, nil, nil), apiKey, opts...)
+}
+
+// newServerHarness is newHarness for an already built MCP server.
+func newServerHarness(t *testing.T, s *server.MCPServer, apiKey string, opts ...logcheftest.Option) *harness {
+	t.Helper()
+	c, err := mcpclient.NewInProcessClient(s)
+	if err != nil {
+		t.Fatalf("new in-process client: %v", err)
+	}
+	return startHarness(t, c, apiKey, opts...)
+}
+
+// newElicitationHarness is newServerHarness with a client that declares the
+// elicitation capability and answers elicitation requests with e.
+func newElicitationHarness(t *testing.T, s *server.MCPServer, apiKey string, e mcpclient.ElicitationHandler, opts ...logcheftest.Option) *harness {
+	t.Helper()
+	tr := transport.NewInProcessTransportWithOptions(s, transport.WithElicitationHandler(e))
+	return startHarness(t, mcpclient.NewClient(tr, mcpclient.WithElicitationHandler(e)), apiKey, opts...)
+}
+
+// startHarness starts c against a new fake Logchef and initializes the session.
+func startHarness(t *testing.T, c *mcpclient.Client, apiKey string, opts ...logcheftest.Option) *harness {
+	t.Helper()
+	fake := logcheftest.NewServer(t, append([]logcheftest.Option{logcheftest.WithClock(fixedClock)}, opts...)...)
+	t.Cleanup(func() { _ = c.Close() })
+
+	ctx := mcplogchef.WithLogchefClient(context.Background(), fake.Client(apiKey))
+	if err := c.Start(ctx); err != nil {
+		t.Fatalf("start client: %v", err)
+	}
+	init := mcp.InitializeRequest{}
+	init.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	init.Params.ClientInfo = mcp.Implementation{Name: "logchef-mcp-test", Version: "0.0.0"}
+	if _, err := c.Initialize(ctx, init); err != nil {
+		t.Fatalf("initialize: %v", err)
+	}
+	return &harness{t: t, ctx: ctx, fake: fake, client: c}
+}
+
+func (h *harness) listTools() []mcp.Tool {
+	h.t.Helper()
+	res, err := h.client.ListTools(h.ctx, mcp.ListToolsRequest{})
+	if err != nil {
+		h.t.Fatalf("tools/list: %v", err)
+	}
+	return res.Tools
+}
+
+func (h *harness) callTool(name string, args map[string]any) *mcp.CallToolResult {
+	h.t.Helper()
+	req := mcp.CallToolRequest{}
+	req.Params.Name = name
+	req.Params.Arguments = args
+	res, err := h.client.CallTool(h.ctx, req)
+	if err != nil {
+		h.t.Fatalf("tools/call %s: %v", name, err)
+	}
+	return res
+}
+
+func (h *harness) readResource(uri string) *mcp.ReadResourceResult {
+	h.t.Helper()
+	req := mcp.ReadResourceRequest{}
+	req.Params.URI = uri
+	res, err := h.client.ReadResource(h.ctx, req)
+	if err != nil {
+		h.t.Fatalf("resources/read %s: %v", uri, err)
+	}
+	return res
+}
+
+func (h *harness) getPrompt(name string, args map[string]string) *mcp.GetPromptResult {
+	h.t.Helper()
+	req := mcp.GetPromptRequest{}
+	req.Params.Name = name
+	req.Params.Arguments = args
+	res, err := h.client.GetPrompt(h.ctx, req)
+	if err != nil {
+		h.t.Fatalf("prompts/get %s: %v", name, err)
+	}
+	return res
+}
+
+// decodeText unmarshals the JSON text content of a tool result into v.
+func decodeText(t *testing.T, res *mcp.CallToolResult, v any) {
+	t.Helper()
+	if len(res.Content) == 0 {
+		t.Fatal("tool result has no content")
+	}
+	text, ok := res.Content[0].(mcp.TextContent)
+	if !ok {
+		t.Fatalf("content is %T, want text", res.Content[0])
+	}
+	if err := json.Unmarshal([]byte(text.Text), v); err != nil {
+		t.Fatalf("decode tool result: %v\n%s", err, text.Text)
+	}
+}
+
+// assertGolden compares the indented JSON encoding of got with
+// testdata/<name>.golden. Run with -update to rewrite the file.
+func assertGolden(t *testing.T, name string, got any) {
+	t.Helper()
+	b, err := json.MarshalIndent(got, "", "  ")
+	if err != nil {
+		t.Fatalf("marshal %s: %v", name, err)
+	}
+	b = append(b, '\n')
+
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, b, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read golden file (run with -update to create it): %v", err)
+	}
+	if string(want) != string(b) {
+		t.Errorf("%s does not match golden file %s (run with -update to accept)\ngot:\n%s", name, path, b)
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"slices"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	mcplogchef "github.com/mr-karan/logchef-mcp"
+)
+
+// instanceArgument is the tool argument selecting a named Logchef instance.
+const instanceArgument = "instance"
+
+// instanceFileConfig is a named Logchef instance in the config file.
+type instanceFileConfig struct {
+	URL         string `yaml:"url" toml:"url"`
+	APIKey      string `yaml:"api_key,omitempty" toml:"api_key"`
+	Description string `yaml:"description,omitempty" toml:"description"`
+}
+
+// parseInstances validates the config file's instances and returns them
+// sorted by name.
+func parseInstances(cfg map[string]instanceFileConfig) ([]mcplogchef.Instance, error) {
+	var instances []mcplogchef.Instance
+	for name, ic := range cfg {
+		if name == "" || name == mcplogchef.DefaultInstance {
+			return nil, fmt.Errorf("config instances: %q is not a valid instance name", name)
+		}
+		u, err := url.Parse(ic.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("config instances.%s: url must be an http or https URL, got %q", name, ic.URL)
+		}
+		instances = append(instances, mcplogchef.Instance{
+			Name:        name,
+			URL:         ic.URL,
+			APIKey:      ic.APIKey,
+			Description: ic.Description,
+		})
+	}
+	slices.SortFunc(instances, func(a, b mcplogchef.Instance) int { return cmp.Compare(a.Name, b.Name) })
+	return instances, nil
+}
+
+// instanceMiddleware makes the named instances available to tools and swaps
+// the session's Logchef client for the instance named by the call's instance
+// argument, if any.
+func instanceMiddleware(instances []mcplogchef.Instance) server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			ctx = mcplogchef.WithInstances(ctx, instances)
+			name := req.GetString(instanceArgument, "")
+			if name == "" || name == mcplogchef.DefaultInstance {
+				return next(ctx, req)
+			}
+			c, err := mcplogchef.LogchefClientForInstance(ctx, name)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error() + ". Call list_instances to see the configured instances."), nil
+			}
+			return next(mcplogchef.WithLogchefClient(ctx, c), req)
+		}
+	}
+}
+
+// addInstanceArgument adds an optional instance argument, restricted to the
+// configured instance names, to the input schema of every registered tool.
+func addInstanceArgument(s *server.MCPServer, instances []mcplogchef.Instance) {
+	names := []any{mcplogchef.DefaultInstance}
+	for _, inst := range instances {
+		names = append(names, inst.Name)
+	}
+	property := map[string]any{
+		"type":        "string",
+		"description": "Logchef instance to query (see list_instances). Omit to use the default instance.",
+		"enum":        names,
+	}
+
+	var updated []server.ServerTool
+	for name, st := range s.ListTools() {
+		if name == "list_instances" {
+			continue
+		}
+		t := *st
+		if t.Tool.RawInputSchema != nil {
+			var schema map[string]any
+			if err := json.Unmarshal(t.Tool.RawInputSchema, &schema); err != nil {
+				slog.Warn("Cannot add instance argument to tool", "tool", name, "error", err)
+				continue
+			}
+			props, _ := schema["properties"].(map[string]any)
+			if props == nil {
+				props = make(map[string]any)
+			}
+			props[instanceArgument] = property
+			schema["properties"] = props
+			raw, err := json.Marshal(schema)
+			if err != nil {
+				slog.Warn("Cannot add instance argument to tool", "tool", name, "error", err)
+				continue
+			}
+			t.Tool.RawInputSchema = raw
+		} else {
+			if t.Tool.InputSchema.Properties == nil {
+				t.Tool.InputSchema.Properties = make(map[string]any)
+			}
+			t.Tool.InputSchema.Properties[instanceArgument] = property
+		}
+		updated = append(updated, t)
+	}
+	s.AddTools(updated...)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	mcplogchef "github.com/mr-karan/logchef-mcp"
+	"github.com/mr-karan/logchef-mcp/logcheftest"
+)
+
+func TestInstances(t *testing.T) {
+	staging := logcheftest.NewServer(t)
+	o := options{
+		dt: allTools(),
+		instances: []mcplogchef.Instance{
+			{Name: "staging", URL: staging.URL, APIKey: logcheftest.AdminKey, Description: "Staging cluster"},
+		},
+	}
+	h := newServerHarness(t, newServer(o, nil, nil), logcheftest.MemberKey)
+
+	profileEmail := func(args map[string]any) string {
+		t.Helper()
+		res := h.callTool("get_profile", args)
+		if res.IsError {
+			t.Fatalf("get_profile %v: %v", args, res.Content)
+		}
+		var p struct {
+			User struct {
+				Email string `json:"email"`
+			} `json:"user"`
+		}
+		decodeText(t, res, &p)
+		return p.User.Email
+	}
+	def := profileEmail(nil)
+	if got := profileEmail(map[string]any{"instance": "default"}); got != def {
+		t.Errorf("instance=default used %s, want %s", got, def)
+	}
+	if got := profileEmail(map[string]any{"instance": "staging"}); got == def {
+		t.Errorf("instance=staging used the default instance (%s)", got)
+	}
+	if n := staging.CountRequests(http.MethodGet, "/api/v1/me"); n != 1 {
+		t.Errorf("staging received %d profile requests, want 1", n)
+	}
+
+	res := h.callTool("get_profile", map[string]any{"instance": "prod"})
+	if !res.IsError {
+		t.Fatal("unknown instance accepted")
+	}
+	var text strings.Builder
+	for _, c := range res.Content {
+		b, _ := json.Marshal(c)
+		text.Write(b)
+	}
+	if !strings.Contains(text.String(), "list_instances") {
+		t.Errorf("unknown instance error does not mention list_instances: %s", text.String())
+	}
+
+	var instances []struct {
+		Name    string `json:"name"`
+		URL     string `json:"url"`
+		Default bool   `json:"default"`
+	}
+	decodeText(t, h.callTool("list_instances", nil), &instances)
+	if len(instances) != 2 || instances[0].Name != "default" || !instances[0].Default ||
+		instances[1].Name != "staging" || instances[1].URL != staging.URL {
+		t.Errorf("list_instances = %+v", instances)
+	}
+
+	for _, tool := range h.listTools() {
+		schema, _ := json.Marshal(tool.InputSchema)
+		if tool.RawInputSchema != nil {
+			schema = tool.RawInputSchema
+		}
+		if has := strings.Contains(string(schema), `"instance"`); has != (tool.Name != "list_instances") {
+			t.Errorf("%s: instance argument present = %v", tool.Name, has)
+		}
+	}
+}
@@ -1,16 +1,26 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
+	"errors"
 	"flag"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
 	"slices"
+	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
+	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 
 	mcplogchef "github.com/mr-karan/logchef-mcp"
+	"github.com/mr-karan/logchef-mcp/client"
 	"github.com/mr-karan/logchef-mcp/tools"
 )
 
@@ -34,6 +44,9 @@ func maybeAddTools(s *server.MCPServer, tf func(*server.MCPServer), enabledTools
 	tf(s)
 }
 
+// defaultEnabledTools lists every tool category, enabled unless --enabled-tools says otherwise.
+const defaultEnabledTools = "profile,sources,logs,logchefql,investigate,admin,analysis,telemetry,discover"
+
 // disabledTools indicates whether each category of tools should be disabled.
 type disabledTools struct {
 	enabledTools string
@@ -46,42 +59,175 @@ type disabledTools struct {
 	analysis     bool
 	telemetry    bool
 	discover     bool
+
+	// readOnly registers only tools annotated as read-only and restricts
+	// raw SQL to SELECT statements.
+	readOnly bool
+
+	// filter narrows individual tools after category registration.
+	filter toolFilter
 }
 
 // Configuration for the Logchef client.
 type logchefConfig struct {
+	// Logchef URL and API key from LOGCHEF_URL and LOGCHEF_API_KEY, or
+	// from the config file when they are unset.
+	url    string
+	apiKey string
+
 	// Whether to enable debug mode for the Logchef transport.
 	debug bool
+
+	// Retry policy for idempotent Logchef requests.
+	retryMaxAttempts    int
+	retryInitialBackoff time.Duration
+	retryMaxBackoff     time.Duration
+
+	// Per-instance load limits shared by all sessions.
+	rateLimit      float64
+	rateLimitBurst int
+	maxInFlight    int
+
+	// Whether to disable the profile/teams/sources/schema metadata cache.
+	disableCache bool
+
+	// Outbound TLS and proxy settings, built into transport by validate.
+	tc        client.TransportConfig
+	transport http.RoundTripper
+
+	// headers is a comma separated list of "Name: value" pairs sent with
+	// every Logchef request, parsed into headerMap by validate.
+	headers   string
+	headerMap map[string]string
 }
 
-func (dt *disabledTools) addFlags() {
-	flag.StringVar(&dt.enabledTools, "enabled-tools", "profile,sources,logs,logchefql,investigate,admin,analysis,telemetry,discover", "A comma separated list of tools enabled for this server.")
-	flag.BoolVar(&dt.profile, "disable-profile", false, "Disable profile tools")
-	flag.BoolVar(&dt.sources, "disable-sources", false, "Disable sources tools")
-	flag.BoolVar(&dt.logs, "disable-logs", false, "Disable logs tools")
-	flag.BoolVar(&dt.logchefql, "disable-logchefql", false, "Disable LogchefQL tools")
-	flag.BoolVar(&dt.investigate, "disable-investigate", false, "Disable investigation tools (field values, log context, alerts)")
-	flag.BoolVar(&dt.admin, "disable-admin", false, "Disable admin tools")
-	flag.BoolVar(&dt.analysis, "disable-analysis", false, "Disable analysis tools (compare_windows, top_values)")
-	flag.BoolVar(&dt.telemetry, "disable-telemetry", false, "Disable telemetry tools (query performance data)")
-	flag.BoolVar(&dt.discover, "disable-discover", false, "Disable discovery tools (AI query generation, field dimensions)")
+func (dt *disabledTools) addFlags(fs *flag.FlagSet) {
+	fs.StringVar(&dt.enabledTools, "enabled-tools", defaultEnabledTools, "A comma separated list of tools enabled for this server.")
+	fs.BoolVar(&dt.profile, "disable-profile", false, "Disable profile tools")
+	fs.BoolVar(&dt.sources, "disable-sources", false, "Disable sources tools")
+	fs.BoolVar(&dt.logs, "disable-logs", false, "Disable logs tools")
+	fs.BoolVar(&dt.logchefql, "disable-logchefql", false, "Disable LogchefQL tools")
+	fs.BoolVar(&dt.investigate, "disable-investigate", false, "Disable investigation tools (field values, log context, alerts)")
+	fs.BoolVar(&dt.admin, "disable-admin", false, "Disable admin tools")
+	fs.BoolVar(&dt.analysis, "disable-analysis", false, "Disable analysis tools (compare_windows, top_values)")
+	fs.BoolVar(&dt.telemetry, "disable-telemetry", false, "Disable telemetry tools (query performance data)")
+	fs.BoolVar(&dt.discover, "disable-discover", false, "Disable discovery tools (AI query generation, field dimensions)")
+	fs.Func("allow-tools", "Comma separated tool names or globs to keep, e.g. 'admin:list_*,query_*'; prefix with ! to exclude", func(v string) (err error) {
+		dt.filter.allow, err = parseToolPatterns(v)
+		return err
+	})
+	fs.Func("deny-tools", "Comma separated tool names or globs to remove, e.g. 'delete_*,admin:create_*'; prefix with ! to keep", func(v string) (err error) {
+		dt.filter.deny, err = parseToolPatterns(v)
+		return err
+	})
+	fs.BoolVar(&dt.readOnly, "read-only", envBool("LOGCHEF_READ_ONLY", false), "Only register read-only tools and reject non-SELECT raw SQL (env LOGCHEF_READ_ONLY)")
 }
 
-func (lc *logchefConfig) addFlags() {
-	flag.BoolVar(&lc.debug, "debug", false, "Enable debug mode for the Logchef transport")
+func (lc *logchefConfig) addFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&lc.debug, "debug", false, "Enable debug mode for the Logchef transport")
+	fs.IntVar(&lc.retryMaxAttempts, "retry-max-attempts", 3, "Maximum attempts for idempotent Logchef requests (1 disables retries)")
+	fs.DurationVar(&lc.retryInitialBackoff, "retry-initial-backoff", 250*time.Millisecond, "Delay before the first retry of a failed Logchef request")
+	fs.DurationVar(&lc.retryMaxBackoff, "retry-max-backoff", 5*time.Second, "Maximum delay between retries of a failed Logchef request")
+	fs.Float64Var(&lc.rateLimit, "rate-limit", envFloat("LOGCHEF_RATE_LIMIT", 0), "Maximum Logchef requests per second per instance, 0 for unlimited (env LOGCHEF_RATE_LIMIT)")
+	fs.IntVar(&lc.rateLimitBurst, "rate-limit-burst", envInt("LOGCHEF_RATE_LIMIT_BURST", 10), "Requests allowed to burst above --rate-limit (env LOGCHEF_RATE_LIMIT_BURST)")
+	fs.BoolVar(&lc.disableCache, "disable-cache", false, "Disable caching of profile, teams, sources, schema and meta lookups")
+	fs.StringVar(&lc.tc.CAFile, "logchef-ca", os.Getenv("LOGCHEF_CA_FILE"), "PEM CA bundle trusted for Logchef's certificate, in addition to the system roots (env LOGCHEF_CA_FILE)")
+	fs.StringVar(&lc.tc.CertFile, "logchef-client-cert", os.Getenv("LOGCHEF_CLIENT_CERT"), "PEM client certificate presented to Logchef (env LOGCHEF_CLIENT_CERT)")
+	fs.StringVar(&lc.tc.KeyFile, "logchef-client-key", os.Getenv("LOGCHEF_CLIENT_KEY"), "PEM private key for --logchef-client-cert (env LOGCHEF_CLIENT_KEY)")
+	fs.BoolVar(&lc.tc.InsecureSkipVerify, "logchef-insecure-skip-verify", envBool("LOGCHEF_INSECURE_SKIP_VERIFY", false), "Do not verify Logchef's TLS certificate; for testing only (env LOGCHEF_INSECURE_SKIP_VERIFY)")
+	fs.StringVar(&lc.tc.ProxyURL, "logchef-proxy", os.Getenv("LOGCHEF_PROXY"), "http, https or socks5 proxy URL for Logchef requests; defaults to HTTPS_PROXY/HTTP_PROXY (env LOGCHEF_PROXY)")
+	fs.StringVar(&lc.headers, "logchef-headers", os.Getenv("LOGCHEF_HEADERS"), "Comma separated 'Name: value' headers sent with every Logchef request (env LOGCHEF_HEADERS)")
+	fs.IntVar(&lc.maxInFlight, "max-in-flight", envInt("LOGCHEF_MAX_IN_FLIGHT", 8), "Maximum concurrent Logchef requests per instance, 0 for unlimited (env LOGCHEF_MAX_IN_FLIGHT)")
 }
 
-func (dt *disabledTools) addTools(s *server.MCPServer) {
+// clientConfig returns the client.Config template shared by every Logchef client.
+func (lc *logchefConfig) clientConfig() client.Config {
+	return client.Config{
+		BaseURL: lc.url,
+		APIKey:  lc.apiKey,
+		Retry: client.RetryPolicy{
+			MaxAttempts:    lc.retryMaxAttempts,
+			InitialBackoff: lc.retryInitialBackoff,
+			MaxBackoff:     lc.retryMaxBackoff,
+		},
+		Limits: client.Limits{
+			RequestsPerSecond: lc.rateLimit,
+			Burst:             lc.rateLimitBurst,
+			MaxInFlight:       lc.maxInFlight,
+		},
+		Cache: client.CacheConfig{
+			Disabled: lc.disableCache,
+		},
+		Transport: instrumentedTransport(lc.transport),
+		Headers:   lc.headerMap,
+	}
+}
+
+// parseHeaders parses a comma separated list of "Name: value" pairs.
+func parseHeaders(s string) (map[string]string, error) {
+	headers := make(map[string]string)
+	for _, h := range splitList(s) {
+		name, value, ok := strings.Cut(h, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" || strings.ContainsAny(name, " \t") {
+			return nil, fmt.Errorf("invalid header %q: want 'Name: value'", h)
+		}
+		headers[name] = strings.TrimSpace(value)
+	}
+	return headers, nil
+}
+
+// addTools registers the enabled tools and returns the category of each.
+func (dt *disabledTools) addTools(s *server.MCPServer) map[string]string {
 	enabledTools := strings.Split(dt.enabledTools, ",")
-	maybeAddTools(s, tools.AddProfileTools, enabledTools, dt.profile, "profile")
-	maybeAddTools(s, tools.AddSourcesTools, enabledTools, dt.sources, "sources")
-	maybeAddTools(s, tools.AddLogsTools, enabledTools, dt.logs, "logs")
-	maybeAddTools(s, tools.AddLogchefQLTools, enabledTools, dt.logchefql, "logchefql")
-	maybeAddTools(s, tools.AddInvestigateTools, enabledTools, dt.investigate, "investigate")
-	maybeAddTools(s, tools.AddAdminTools, enabledTools, dt.admin, "admin")
-	maybeAddTools(s, tools.AddAnalysisTools, enabledTools, dt.analysis, "analysis")
-	maybeAddTools(s, tools.AddTelemetryTools, enabledTools, dt.telemetry, "telemetry")
-	maybeAddTools(s, tools.AddDiscoverTools, enabledTools, dt.discover, "discover")
+	categories := make(map[string]string)
+	add := func(tf func(*server.MCPServer), disable bool, category string) {
+		maybeAddTools(s, tf, enabledTools, disable, category)
+		for name := range s.ListTools() {
+			if _, ok := categories[name]; !ok {
+				categories[name] = category
+			}
+		}
+	}
+	add(tools.AddProfileTools, dt.profile, "profile")
+	add(tools.AddInstanceTools, dt.profile, "profile")
+	add(tools.AddSourcesTools, dt.sources, "sources")
+	add(tools.AddLogsTools, dt.logs, "logs")
+	add(tools.AddLogchefQLTools, dt.logchefql, "logchefql")
+	add(tools.AddInvestigateTools, dt.investigate, "investigate")
+	add(tools.AddAdminTools, dt.admin, "admin")
+	add(tools.AddAnalysisTools, dt.analysis, "analysis")
+	add(tools.AddTelemetryTools, dt.telemetry, "telemetry")
+	add(tools.AddDiscoverTools, dt.discover, "discover")
+	if dt.readOnly {
+		removeMutatingTools(s)
+	}
+	dt.filter.apply(s, categories)
+	return categories
+}
+
+// removeMutatingTools unregisters every tool not annotated with
+// ReadOnlyHint(true), such as the admin CRUD tools and collection edits.
+func removeMutatingTools(s *server.MCPServer) {
+	var names []string
+	for name, t := range s.ListTools() {
+		if hint := t.Tool.Annotations.ReadOnlyHint; hint == nil || !*hint {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return
+	}
+	slices.Sort(names)
+	slog.Info("Read-only mode: disabling mutating tools", "tools", names)
+	s.DeleteTools(names...)
+}
+
+// readOnlyMiddleware marks every tool call as running on a read-only server.
+func readOnlyMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return next(mcplogchef.WithReadOnly(ctx, true), req)
+	}
 }
 
 func (dt *disabledTools) addResources(s *server.MCPServer) {
@@ -103,75 +249,263 @@ func (dt *disabledTools) addPrompts(s *server.MCPServer) {
 	}
 }
 
-func newServer(dt disabledTools) *server.MCPServer {
-	s := server.NewMCPServer(
-		"logchef-mcp",
-		version,
+// newServer builds the MCP server. Tool calls are tracked by d, if not nil,
+// for graceful shutdown, and recorded in audit, if not nil.
+func newServer(o options, d *drainer, audit *auditLog) *server.MCPServer {
+	dt, limits := o.dt, o.limits
+	opts := []server.ServerOption{
 		server.WithToolCapabilities(false),
 		server.WithResourceCapabilities(false, false),
 		server.WithPromptCapabilities(false),
 		server.WithRecovery(),
-	)
-	dt.addTools(s)
+	}
+	opts = append(opts, server.WithToolHandlerMiddleware(metricsMiddleware))
+	if d != nil {
+		opts = append(opts, server.WithToolHandlerMiddleware(d.middleware))
+	}
+	if dt.readOnly {
+		opts = append(opts, server.WithToolHandlerMiddleware(readOnlyMiddleware))
+	}
+	if limits.enabled() {
+		opts = append(opts, server.WithToolHandlerMiddleware(limits.middleware))
+	}
+	if len(o.instances) > 0 {
+		opts = append(opts, server.WithToolHandlerMiddleware(instanceMiddleware(o.instances)))
+	}
+	// Audit inside the instance middleware so the user lookup uses the
+	// selected instance's client.
+	if audit != nil {
+		opts = append(opts, server.WithToolHandlerMiddleware(audit.middleware))
+	}
+	opts = append(opts, server.WithToolHandlerMiddleware(mcplogchef.ClientErrorMiddleware))
+	s := server.NewMCPServer("logchef-mcp", version, opts...)
+	categories := dt.addTools(s)
+	if audit != nil {
+		audit.setTools(s, categories)
+	}
 	dt.addResources(s)
 	dt.addPrompts(s)
+	limits.warnUnknown(s)
+	if len(o.instances) > 0 {
+		addInstanceArgument(s, o.instances)
+	}
 	return s
 }
 
-func run(transport, addr, basePath string, endpointPath string, logLevel slog.Level, dt disabledTools, lc logchefConfig) error {
-	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: logLevel})))
-	s := newServer(dt)
+// options holds the server configuration after merging the config file,
+// environment and flags.
+type options struct {
+	configPath   string
+	transport    string
+	address      string
+	basePath     string
+	endpointPath string
+	logLevel     string
+	dt           disabledTools
+	lc           logchefConfig
+	limits       toolLimits
+
+	// metricsAddress, if set, moves the health and metrics endpoints to a
+	// separate listener.
+	metricsAddress string
+
+	// shutdownTimeout is how long shutdown waits for running tool calls
+	// before cancelling them.
+	shutdownTimeout time.Duration
+
+	// instances are the named Logchef instances from the config file,
+	// sorted by name.
+	instances []mcplogchef.Instance
+
+	// auth authenticates MCP clients on the SSE and streamable-http transports.
+	auth mcplogchef.AuthConfig
+
+	// allowedHosts and allowedSchemes are comma separated lists restricting
+	// X-Logchef-URL, parsed into urlPolicy by validate.
+	allowedHosts   string
+	allowedSchemes string
+	urlPolicy      mcplogchef.URLPolicy
+
+	// tls enables HTTPS, and optionally mTLS, on the HTTP transports.
+	tls tlsFiles
+
+	// auditPath is the audit log file, "-" for stdout, and auditCategories
+	// a comma separated list of tool categories to audit, empty for all.
+	auditPath       string
+	auditCategories string
+}
+
+func (o *options) addFlags(fs *flag.FlagSet) {
+	fs.StringVar(&o.configPath, "config", os.Getenv("LOGCHEF_MCP_CONFIG"), "Path to a YAML or TOML config file (env LOGCHEF_MCP_CONFIG)")
+	fs.StringVar(&o.transport, "t", "stdio", "Transport type (stdio, sse, or streamable-http)")
+	fs.StringVar(
+		&o.transport,
+		"transport",
+		"stdio",
+		"Transport type (stdio, sse, or streamable-http)",
+	)
+	fs.StringVar(&o.address, "address", "localhost:8000", "The host and port to start the server on")
+	fs.StringVar(&o.basePath, "base-path", "", "Base path for the sse server")
+	fs.StringVar(&o.endpointPath, "endpoint-path", "/mcp", "Endpoint path for the streamable-http server")
+	fs.StringVar(&o.logLevel, "log-level", "info", "Log level (debug, info, warn, error)")
+	fs.StringVar(&o.tls.cert, "tls-cert", os.Getenv("LOGCHEF_TLS_CERT"), "PEM certificate for serving HTTPS on the sse and streamable-http transports; reloaded when it changes (env LOGCHEF_TLS_CERT)")
+	fs.StringVar(&o.tls.key, "tls-key", os.Getenv("LOGCHEF_TLS_KEY"), "PEM private key for --tls-cert (env LOGCHEF_TLS_KEY)")
+	fs.StringVar(&o.tls.clientCA, "client-ca", os.Getenv("LOGCHEF_CLIENT_CA"), "PEM CA bundle; when set, clients must present a certificate signed by it (env LOGCHEF_CLIENT_CA)")
+	fs.StringVar(&o.auditPath, "audit-log", os.Getenv("LOGCHEF_AUDIT_LOG"), "Append a JSON line for every tool call to this file, or '-' for stdout in HTTP mode (env LOGCHEF_AUDIT_LOG)")
+	fs.StringVar(&o.auditCategories, "audit-categories", os.Getenv("LOGCHEF_AUDIT_CATEGORIES"), "Comma separated tool categories to audit, default all; destructive tools are always audited (env LOGCHEF_AUDIT_CATEGORIES)")
+	fs.DurationVar(&o.shutdownTimeout, "shutdown-timeout", envDuration("LOGCHEF_SHUTDOWN_TIMEOUT", 30*time.Second), "How long to wait for running tool calls on SIGINT or SIGTERM before cancelling them (env LOGCHEF_SHUTDOWN_TIMEOUT)")
+	fs.StringVar(&o.metricsAddress, "metrics-address", os.Getenv("LOGCHEF_METRICS_ADDRESS"), "Serve /healthz, /readyz and /metrics on this address instead of the MCP listener (env LOGCHEF_METRICS_ADDRESS)")
+	fs.StringVar(&o.limits.defaultTimezone, "default-timezone", os.Getenv("LOGCHEF_DEFAULT_TIMEZONE"), "Timezone used by tools when the caller omits one (env LOGCHEF_DEFAULT_TIMEZONE)")
+	fs.IntVar(&o.limits.readBudget.WarnRows, "warn-read-rows", envInt("LOGCHEF_WARN_READ_ROWS", 0), "Warn when query_logs or query_logchefql is estimated to read more rows than this, 0 to disable (env LOGCHEF_WARN_READ_ROWS)")
+	fs.IntVar(&o.limits.readBudget.MaxRows, "max-read-rows", envInt("LOGCHEF_MAX_READ_ROWS", 0), "Refuse query_logs and query_logchefql calls estimated to read more rows than this, 0 to disable (env LOGCHEF_MAX_READ_ROWS)")
+	fs.BoolVar(&o.auth.RequireAPIKeyHeader, "require-api-key-header", envBool("LOGCHEF_REQUIRE_API_KEY_HEADER", false), "Reject HTTP requests without an X-Logchef-API-Key header and never use the server's own API key for them (env LOGCHEF_REQUIRE_API_KEY_HEADER)")
+	o.auth.Tokens = splitList(os.Getenv("LOGCHEF_MCP_AUTH_TOKENS"))
+	fs.StringVar(&o.allowedHosts, "allowed-hosts", os.Getenv("LOGCHEF_ALLOWED_HOSTS"), "Comma separated hosts (optionally host:port) and CIDRs allowed in the X-Logchef-URL header; empty allows any (env LOGCHEF_ALLOWED_HOSTS)")
+	fs.StringVar(&o.allowedSchemes, "allowed-schemes", os.Getenv("LOGCHEF_ALLOWED_SCHEMES"), "Comma separated URL schemes allowed in the X-Logchef-URL header, default http,https (env LOGCHEF_ALLOWED_SCHEMES)")
+	o.dt.addFlags(fs)
+	o.lc.addFlags(fs)
+}
 
-	switch transport {
+// run serves MCP on the configured transport until the transport fails or
+// ctx is cancelled, then shuts down gracefully.
+func run(ctx context.Context, o options) error {
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: parseLevel(o.logLevel)})))
+	if o.configPath != "" {
+		slog.Info("Loaded config file", "path", o.configPath)
+	}
+	if o.lc.tc.InsecureSkipVerify {
+		slog.Warn("Logchef TLS certificate verification is disabled (--logchef-insecure-skip-verify)")
+	}
+	if o.tls.clientCA != "" && o.metricsAddress == "" && o.transport != "stdio" {
+		slog.Warn("/healthz, /readyz and /metrics require a client certificate on the mTLS listener; set --metrics-address to serve them to probes and scrapers")
+	}
+	audit, err := openAuditLog(o.auditPath, splitList(o.auditCategories))
+	if err != nil {
+		return err
+	}
+	if audit != nil {
+		slog.Info("Writing audit log", "path", o.auditPath, "categories", o.auditCategories)
+	}
+	d := newDrainer()
+	s := newServer(o, d, audit)
+	lc := o.lc
+
+	// Ops endpoints share the MCP listener unless --metrics-address moves
+	// them to their own, which stdio needs to expose them at all.
+	mux := http.NewServeMux()
+	if o.metricsAddress != "" {
+		opsMux := http.NewServeMux()
+		addOpsHandlers(opsMux, lc.clientConfig())
+		opsSrv := &http.Server{Addr: o.metricsAddress, Handler: opsMux}
+		go serveOps(opsSrv)
+		// Shut down last so metrics can be scraped while draining.
+		defer opsSrv.Close()
+	} else if o.transport != "stdio" {
+		addOpsHandlers(mux, lc.clientConfig())
+	}
+
+	// The certificate files are watched and reloaded in place, so renewals
+	// need no restart.
+	var tlsConfig *tls.Config
+	if o.tls.enabled() && o.transport != "stdio" {
+		r, err := newCertReloader(o.tls)
+		if err != nil {
+			return err
+		}
+		go r.watch(ctx, tlsReloadInterval)
+		tlsConfig = r.tlsConfig()
+	}
+
+	switch o.transport {
 	case "stdio":
+		if o.auth.Enabled() {
+			slog.Warn("Authentication settings only apply to the sse and streamable-http transports")
+		}
+		if o.tls.enabled() {
+			slog.Warn("TLS settings only apply to the sse and streamable-http transports")
+		}
 		slog.Info("Starting Logchef MCP server using stdio transport")
-		return server.ServeStdio(s, server.WithStdioContextFunc(mcplogchef.ComposedStdioContextFunc(lc.debug)))
+		stdioSrv := server.NewStdioServer(s)
+		server.WithStdioContextFunc(mcplogchef.ComposedStdioContextFunc(lc.debug, lc.clientConfig()))(stdioSrv)
+		// Tool calls inherit the listen context, so it is only cancelled
+		// once they have drained.
+		listenCtx, stop := context.WithCancel(context.Background())
+		defer stop()
+		errc := make(chan error, 1)
+		go func() { errc <- stdioSrv.Listen(listenCtx, os.Stdin, os.Stdout) }()
+		select {
+		case err := <-errc:
+			return err
+		case <-ctx.Done():
+		}
+		slog.Info("Shutting down", "drain_timeout", o.shutdownTimeout)
+		clean := d.drain(o.shutdownTimeout)
+		stop()
+		if err := <-errc; err != nil && !errors.Is(err, context.Canceled) {
+			return err
+		}
+		if !clean {
+			return errors.New("shutdown: tool calls were cancelled after the drain timeout")
+		}
+		return nil
 	case "sse":
+		httpSrv := &http.Server{Addr: o.address, TLSConfig: tlsConfig}
 		srv := server.NewSSEServer(s,
-			server.WithSSEContextFunc(mcplogchef.ComposedSSEContextFunc(lc.debug)),
-			server.WithStaticBasePath(basePath),
+			server.WithSSEContextFunc(mcplogchef.ComposedSSEContextFunc(lc.debug, lc.clientConfig(), o.urlPolicy)),
+			server.WithStaticBasePath(o.basePath),
+			server.WithHTTPServer(httpSrv),
 		)
-		slog.Info("Starting Logchef MCP server using SSE transport", "address", addr, "basePath", basePath)
-		if err := srv.Start(addr); err != nil {
-			return fmt.Errorf("Server error: %v", err)
-		}
+		mux.Handle("/", mcplogchef.RequireAuth(srv, o.auth))
+		httpSrv.Handler = mux
+		warnInsecure(o)
+		slog.Info("Starting Logchef MCP server using SSE transport", "address", o.address, "basePath", o.basePath, "tls", tlsConfig != nil)
+		return serveHTTP(ctx, httpSrv, srv.Shutdown, d, o.shutdownTimeout)
 	case "streamable-http":
-		srv := server.NewStreamableHTTPServer(s, server.WithHTTPContextFunc(mcplogchef.ComposedHTTPContextFunc(lc.debug)),
+		httpSrv := &http.Server{Addr: o.address, TLSConfig: tlsConfig}
+		srv := server.NewStreamableHTTPServer(s, server.WithHTTPContextFunc(mcplogchef.ComposedHTTPContextFunc(lc.debug, lc.clientConfig(), o.urlPolicy)),
 			server.WithStateLess(true),
-			server.WithEndpointPath(endpointPath),
+			server.WithEndpointPath(o.endpointPath),
+			server.WithStreamableHTTPServer(httpSrv),
 		)
-		slog.Info("Starting Logchef MCP server using StreamableHTTP transport", "address", addr, "endpointPath", endpointPath)
-		if err := srv.Start(addr); err != nil {
-			return fmt.Errorf("Server error: %v", err)
-		}
+		mux.Handle(o.endpointPath, mcplogchef.RequireAuth(srv, o.auth))
+		httpSrv.Handler = mux
+		warnInsecure(o)
+		slog.Info("Starting Logchef MCP server using StreamableHTTP transport", "address", o.address, "endpointPath", o.endpointPath, "tls", tlsConfig != nil)
+		return serveHTTP(ctx, httpSrv, srv.Shutdown, d, o.shutdownTimeout)
 	default:
 		return fmt.Errorf(
 			"Invalid transport type: %s. Must be 'stdio', 'sse', or 'streamable-http'",
-			transport,
+			o.transport,
 		)
 	}
-	return nil
+}
+
+// serveOps serves /healthz, /readyz and /metrics on a dedicated listener.
+func serveOps(srv *http.Server) {
+	slog.Info("Serving health and metrics endpoints", "address", srv.Addr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		slog.Error("Health and metrics listener failed", "address", srv.Addr, "error", err)
+	}
 }
 
 func main() {
-	var transport string
-	flag.StringVar(&transport, "t", "stdio", "Transport type (stdio, sse, or streamable-http)")
-	flag.StringVar(
-		&transport,
-		"transport",
-		"stdio",
-		"Transport type (stdio, sse, or streamable-http)",
-	)
-	addr := flag.String("address", "localhost:8000", "The host and port to start the server on")
-	basePath := flag.String("base-path", "", "Base path for the sse server")
-	endpointPath := flag.String("endpoint-path", "/mcp", "Endpoint path for the streamable-http server")
-	logLevel := flag.String("log-level", "info", "Log level (debug, info, warn, error)")
+	// "logchef-mcp config validate [flags]" prints the effective
+	// configuration instead of starting the server.
+	args := os.Args[1:]
+	validateOnly := false
+	if len(args) > 0 && args[0] == "config" {
+		if len(args) < 2 || args[1] != "validate" {
+			fmt.Fprintln(os.Stderr, "usage: logchef-mcp config validate [flags]")
+			os.Exit(2)
+		}
+		validateOnly = true
+		args = args[2:]
+	}
+
+	var o options
+	o.addFlags(flag.CommandLine)
 	showVersion := flag.Bool("version", false, "Show version information")
-	var dt disabledTools
-	dt.addFlags()
-	var lc logchefConfig
-	lc.addFlags()
-	flag.Parse()
+	_ = flag.CommandLine.Parse(args)
 
 	if *showVersion {
 		fmt.Printf("logchef-mcp %s\n", version)
@@ -180,9 +514,85 @@ func main() {
 		return
 	}
 
-	if err := run(transport, *addr, *basePath, *endpointPath, parseLevel(*logLevel), dt, lc); err != nil {
-		panic(err)
+	if err := o.load(flag.CommandLine); err != nil {
+		fmt.Fprintf(os.Stderr, "logchef-mcp: %v\n", err)
+		os.Exit(1)
 	}
+	if validateOnly {
+		if err := writeEffectiveConfig(os.Stdout, o); err != nil {
+			fmt.Fprintf(os.Stderr, "logchef-mcp: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// The first SIGINT or SIGTERM starts a graceful shutdown; a second one
+	// exits immediately.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	context.AfterFunc(ctx, stop)
+	if err := run(ctx, o); err != nil {
+		slog.Error("Logchef MCP server stopped", "error", err)
+		os.Exit(1)
+	}
+}
+
+// warnInsecure logs a warning when an HTTP transport accepts any caller,
+// since sessions then fall back to the server's own API key, or any
+// X-Logchef-URL, which lets callers point the server at arbitrary hosts.
+func warnInsecure(o options) {
+	if !o.auth.Enabled() {
+		slog.Warn("HTTP transport has no authentication; anyone who can reach it can use the server's Logchef credentials. Set security.auth_tokens or --require-api-key-header")
+	}
+	if len(o.urlPolicy.Hosts) == 0 && len(o.urlPolicy.Prefixes) == 0 {
+		slog.Warn("X-Logchef-URL header is accepted for any host. Set --allowed-hosts to restrict it")
+	}
+}
+
+// splitList splits a comma separated list, dropping empty entries.
+func splitList(s string) []string {
+	var list []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// envInt returns the integer value of the environment variable key, or def if
+// it is unset or invalid.
+func envInt(key string, def int) int {
+	if v, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return v
+	}
+	return def
+}
+
+// envBool returns the boolean value of the environment variable key, or def if
+// it is unset or invalid.
+func envBool(key string, def bool) bool {
+	if v, err := strconv.ParseBool(os.Getenv(key)); err == nil {
+		return v
+	}
+	return def
+}
+
+// envDuration returns the duration value of the environment variable key, or
+// def if it is unset or invalid.
+func envDuration(key string, def time.Duration) time.Duration {
+	if v, err := time.ParseDuration(os.Getenv(key)); err == nil {
+		return v
+	}
+	return def
+}
+
+// envFloat returns the float value of the environment variable key, or def if
+// it is unset or invalid.
+func envFloat(key string, def float64) float64 {
+	if v, err := strconv.ParseFloat(os.Getenv(key), 64); err == nil {
+		return v
+	}
+	return def
 }
 
 func parseLevel(level string) slog.Level {
@@ -191,4 +601,4 @@ func parseLevel(level string) slog.Level {
 		return slog.LevelInfo
 	}
 	return l
-}
\ No newline at end of file
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/mr-karan/logchef-mcp/logcheftest"
+)
+
+func TestToolsList(t *testing.T) {
+	h := newHarness(t, allTools(), logcheftest.AdminKey)
+	assertGolden(t, "tools_list", h.listTools())
+}
+
+func TestDisabledCategories(t *testing.T) {
+	dt := allTools()
+	dt.admin = true
+	h := newHarness(t, dt, logcheftest.AdminKey)
+
+	for _, tool := range h.listTools() {
+		if slices.Contains([]string{"list_all_users", "create_team", "delete_source"}, tool.Name) {
+			t.Errorf("admin tool %s registered with --disable-admin", tool.Name)
+		}
+	}
+}
+
+func TestToolCalls(t *testing.T) {
+	tests := []struct {
+		name   string
+		apiKey string
+		tool   string
+		args   map[string]any
+	}{
+		{name: "get_profile", apiKey: logcheftest.MemberKey, tool: "get_profile"},
+		{name: "get_teams", apiKey: logcheftest.MemberKey, tool: "get_teams"},
+		{name: "get_sources", apiKey: logcheftest.AdminKey, tool: "get_sources"},
+		{name: "get_source_schema", apiKey: logcheftest.MemberKey, tool: "get_source_schema", args: map[string]any{"team_id": 1, "source_id": 1}},
+		{name: "query_logs", apiKey: logcheftest.MemberKey, tool: "query_logs", args: map[string]any{
+			"team_id": 1, "source_id": 1, "raw_sql": "SELECT * FROM logs.app ORDER BY timestamp DESC LIMIT 2", "limit": 2,
+		}},
+		{name: "query_logchefql", apiKey: logcheftest.MemberKey, tool: "query_logchefql", args: map[string]any{
+			"team_id": 1, "source_id": 1, "query": `level="error"`,
+			"start_time": "2026-03-01 10:00:00", "end_time": "2026-03-01 11:00:00",
+		}},
+		{name: "estimate_query", apiKey: logcheftest.MemberKey, tool: "estimate_query", args: map[string]any{
+			"team_id": 1, "source_id": 1, "query": `level="error"`,
+			"start_time": "2026-03-01 10:00:00", "end_time": "2026-03-01 11:00:00", "indexes": true,
+		}},
+		{name: "get_log_histogram", apiKey: logcheftest.MemberKey, tool: "get_log_histogram", args: map[string]any{
+			"team_id": 1, "source_id": 1, "raw_sql": "SELECT * FROM logs.app", "window": "5m", "group_by": "level",
+		}},
+		{name: "create_collection", apiKey: logcheftest.MemberKey, tool: "create_collection", args: map[string]any{
+			"team_id": 1, "source_id": 1, "name": "Worker errors", "query": `service="worker"`,
+		}},
+		{name: "create_team", apiKey: logcheftest.AdminKey, tool: "create_team", args: map[string]any{"name": "sre", "description": "Site reliability"}},
+		{name: "list_all_users_forbidden", apiKey: logcheftest.MemberKey, tool: "list_all_users"},
+		{name: "unknown_source", apiKey: logcheftest.MemberKey, tool: "get_source_schema", args: map[string]any{"team_id": 1, "source_id": 42}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newHarness(t, allTools(), tt.apiKey)
+			assertGolden(t, "call_"+tt.name, h.callTool(tt.tool, tt.args))
+		})
+	}
+}
+
+func TestReadResource(t *testing.T) {
+	h := newHarness(t, allTools(), logcheftest.MemberKey)
+	assertGolden(t, "resource_schema", h.readResource("logchef://team/1/source/1/schema"))
+}
+
+func TestGetPrompt(t *testing.T) {
+	h := newHarness(t, allTools(), logcheftest.MemberKey)
+	res := h.getPrompt("investigate_error_spike", map[string]string{"team_id": "1", "source_id": "1", "time_range": "last 1h"})
+	if len(res.Messages) == 0 {
+		t.Fatal("prompt returned no messages")
+	}
+	if res.Messages[0].Role != mcp.RoleUser {
+		t.Errorf("first message role = %s, want user", res.Messages[0].Role)
+	}
+	assertGolden(t, "prompt_investigate_error_spike", res)
+}
+
+func TestReadOnlyMode(t *testing.T) {
+	dt := allTools()
+	dt.readOnly = true
+	h := newHarness(t, dt, logcheftest.AdminKey)
+
+	var names []string
+	for _, tool := range h.listTools() {
+		if hint := tool.Annotations.ReadOnlyHint; hint == nil || !*hint {
+			t.Errorf("mutating tool %s registered in read-only mode", tool.Name)
+		}
+		names = append(names, tool.Name)
+	}
+	for _, name := range []string{"create_collection", "update_collection", "delete_collection", "delete_team", "create_user"} {
+		if slices.Contains(names, name) {
+			t.Errorf("%s registered in read-only mode", name)
+		}
+	}
+	if !slices.Contains(names, "query_logs") || !slices.Contains(names, "list_all_users") {
+		t.Errorf("read-only tools missing: %v", names)
+	}
+
+	res := h.callTool("query_logs", map[string]any{"team_id": 1, "source_id": 1, "raw_sql": "SELECT 1; DROP TABLE logs.app"})
+	if !res.IsError {
+		t.Fatal("non-SELECT raw_sql accepted in read-only mode")
+	}
+	if n := len(h.fake.Requests()); n != 0 {
+		t.Errorf("rejected query reached Logchef (%d requests)", n)
+	}
+
+	res = h.callTool("query_logs", map[string]any{"team_id": 1, "source_id": 1, "raw_sql": "SELECT * FROM logs.app", "limit": 1})
+	if res.IsError {
+		t.Fatalf("SELECT rejected in read-only mode: %v", res.Content)
+	}
+}
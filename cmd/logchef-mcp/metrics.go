@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	mcplogchef "github.com/mr-karan/logchef-mcp"
+	"github.com/mr-karan/logchef-mcp/client"
+)
+
+var (
+	toolCalls = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "logchef_mcp",
+		Name:      "tool_calls_total",
+		Help:      "Tool calls handled, by tool.",
+	}, []string{"tool"})
+	toolErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "logchef_mcp",
+		Name:      "tool_errors_total",
+		Help:      "Tool calls that returned an error result, by tool.",
+	}, []string{"tool"})
+	toolDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "logchef_mcp",
+		Name:      "tool_call_duration_seconds",
+		Help:      "Tool call latency, by tool.",
+		Buckets:   []float64{.01, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60},
+	}, []string{"tool"})
+	toolsInFlight = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "logchef_mcp",
+		Name:      "tool_calls_in_flight",
+		Help:      "Tool calls currently being handled.",
+	})
+
+	upstreamRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "logchef_mcp",
+		Name:      "logchef_requests_total",
+		Help:      "HTTP requests sent to Logchef, including retries, by method and status code.",
+	}, []string{"method", "code"})
+	upstreamDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "logchef_mcp",
+		Name:      "logchef_request_duration_seconds",
+		Help:      "Latency of HTTP requests sent to Logchef, by method.",
+		Buckets:   []float64{.01, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+	}, []string{"method"})
+	upstreamInFlight = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "logchef_mcp",
+		Name:      "logchef_requests_in_flight",
+		Help:      "HTTP requests to Logchef currently awaiting a response.",
+	})
+)
+
+// metricsMiddleware records call counts, errors, latency and in-flight calls
+// for every tool.
+func metricsMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		tool := req.Params.Name
+		toolsInFlight.Inc()
+		start := time.Now()
+		res, err := next(ctx, req)
+		toolDuration.WithLabelValues(tool).Observe(time.Since(start).Seconds())
+		toolsInFlight.Dec()
+		toolCalls.WithLabelValues(tool).Inc()
+		if err != nil || res != nil && res.IsError {
+			toolErrors.WithLabelValues(tool).Inc()
+		}
+		return res, err
+	}
+}
+
+// instrumentedTransport counts requests to Logchef by status code and
+// records their latency.
+func instrumentedTransport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return promhttp.InstrumentRoundTripperInFlight(upstreamInFlight,
+		promhttp.InstrumentRoundTripperCounter(upstreamRequests,
+			promhttp.InstrumentRoundTripperDuration(upstreamDuration, next)))
+}
+
+// readinessTimeout bounds the Logchef ping behind /readyz.
+const readinessTimeout = 5 * time.Second
+
+// handleHealthz reports that the process is serving HTTP.
+func handleHealthz(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// readyzHandler reports whether the default Logchef instance answers
+// GetMeta. The check bypasses the metadata cache so it reflects Logchef's
+// current state.
+func readyzHandler(cfg client.Config) http.HandlerFunc {
+	cfg.Cache.Disabled = true
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+		defer cancel()
+		c := mcplogchef.LogchefClientFromContext(
+			mcplogchef.ExtractLogchefClientFromEnv(mcplogchef.WithLogchefClientConfig(ctx, cfg)))
+		meta, err := c.GetMeta(ctx)
+		if err != nil {
+			slog.Warn("Readiness check failed", "error", err)
+			writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "unavailable", "error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ready", "logchef_version": meta.Data.Version})
+	}
+}
+
+// addOpsHandlers registers /healthz, /readyz and /metrics on mux.
+func addOpsHandlers(mux *http.ServeMux, cfg client.Config) {
+	mux.HandleFunc("GET /healthz", handleHealthz)
+	mux.HandleFunc("GET /readyz", readyzHandler(cfg))
+	mux.Handle("GET /metrics", promhttp.Handler())
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
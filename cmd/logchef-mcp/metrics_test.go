@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/mr-karan/logchef-mcp/client"
+	"github.com/mr-karan/logchef-mcp/logcheftest"
+)
+
+func TestToolMetrics(t *testing.T) {
+	h := newHarness(t, allTools(), logcheftest.MemberKey)
+	calls := testutil.ToFloat64(toolCalls.WithLabelValues("list_all_users"))
+	errs := testutil.ToFloat64(toolErrors.WithLabelValues("list_all_users"))
+
+	h.callTool("list_all_users", nil)
+
+	if got := testutil.ToFloat64(toolCalls.WithLabelValues("list_all_users")) - calls; got != 1 {
+		t.Errorf("tool_calls_total increased by %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(toolErrors.WithLabelValues("list_all_users")) - errs; got != 1 {
+		t.Errorf("tool_errors_total increased by %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(toolsInFlight); got != 0 {
+		t.Errorf("tool_calls_in_flight = %v after call returned", got)
+	}
+}
+
+func TestUpstreamMetrics(t *testing.T) {
+	fake := logcheftest.NewServer(t)
+	ok := testutil.ToFloat64(upstreamRequests.WithLabelValues("get", "200"))
+	denied := testutil.ToFloat64(upstreamRequests.WithLabelValues("get", "403"))
+
+	c := client.New(client.Config{BaseURL: fake.URL, APIKey: logcheftest.MemberKey, Transport: instrumentedTransport(nil)})
+	if _, err := c.GetProfile(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	_, _ = c.ListAllUsers(context.Background())
+
+	if got := testutil.ToFloat64(upstreamRequests.WithLabelValues("get", "200")) - ok; got != 1 {
+		t.Errorf("200 responses increased by %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(upstreamRequests.WithLabelValues("get", "403")) - denied; got != 1 {
+		t.Errorf("403 responses increased by %v, want 1", got)
+	}
+}
+
+func TestReadyz(t *testing.T) {
+	fake := logcheftest.NewServer(t)
+	t.Setenv("LOGCHEF_URL", fake.URL)
+	t.Setenv("LOGCHEF_API_KEY", logcheftest.MemberKey)
+	mux := http.NewServeMux()
+	addOpsHandlers(mux, client.Config{Retry: client.RetryPolicy{MaxAttempts: 1}})
+
+	get := func(path string) (int, map[string]string) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		var body map[string]string
+		_ = json.Unmarshal(rec.Body.Bytes(), &body)
+		return rec.Code, body
+	}
+
+	if code, _ := get("/healthz"); code != http.StatusOK {
+		t.Errorf("/healthz = %d", code)
+	}
+	if code, body := get("/readyz"); code != http.StatusOK || body["logchef_version"] == "" {
+		t.Errorf("/readyz = %d %v", code, body)
+	}
+
+	fake.Inject(logcheftest.Fault{Method: http.MethodGet, Path: "/api/v1/meta", Status: http.StatusBadGateway, Times: -1})
+	if code, body := get("/readyz"); code != http.StatusServiceUnavailable || body["error"] == "" {
+		t.Errorf("/readyz with Logchef down = %d %v", code, body)
+	}
+	if n := fake.CountRequests(http.MethodGet, "/api/v1/meta"); n != 2 {
+		t.Errorf("readiness made %d meta requests, want 2 (uncached)", n)
+	}
+
+	if code, _ := get("/metrics"); code != http.StatusOK {
+		t.Errorf("/metrics = %d", code)
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/mr-karan/logchef-mcp/client"
+	"github.com/mr-karan/logchef-mcp/logcheftest"
+)
+
+type logPage struct {
+	Data       []client.LogEntry `json:"data"`
+	Logs       []client.LogEntry `json:"logs"`
+	HasMore    bool              `json:"has_more"`
+	NextCursor string            `json:"next_cursor"`
+}
+
+// pageThrough calls tool with args until has_more is false and returns the
+// messages of every row in order along with the number of pages.
+func pageThrough(t *testing.T, h *harness, tool string, args map[string]any) (messages []string, pages int) {
+	t.Helper()
+	for cursor := ""; ; pages++ {
+		if pages > 20 {
+			t.Fatalf("%s did not finish paging", tool)
+		}
+		args["cursor"] = cursor
+		var p logPage
+		decodeText(t, h.callTool(tool, args), &p)
+		for _, row := range append(p.Data, p.Logs...) {
+			messages = append(messages, row["message"].(string))
+		}
+		if !p.HasMore {
+			if p.NextCursor != "" {
+				t.Errorf("last page has next_cursor %q", p.NextCursor)
+			}
+			return messages, pages + 1
+		}
+		cursor = p.NextCursor
+	}
+}
+
+func TestQueryLogchefQLPagination(t *testing.T) {
+	h := newHarness(t, allTools(), logcheftest.AdminKey)
+	args := map[string]any{
+		"team_id": 1, "source_id": 1, "query": `level!="debug"`,
+		"start_time": "2026-03-01 00:00:00", "end_time": "2026-03-02 00:00:00",
+	}
+
+	want, _ := pageThrough(t, h, "query_logchefql", args)
+	args["limit"] = 3
+	got, pages := pageThrough(t, h, "query_logchefql", args)
+	if !slices.Equal(got, want) {
+		t.Errorf("paged rows = %q, want %q", got, want)
+	}
+	if wantPages := (len(want) + 2) / 3; pages != wantPages {
+		t.Errorf("pages = %d, want %d", pages, wantPages)
+	}
+
+	var p logPage
+	decodeText(t, h.callTool("query_logchefql", map[string]any{"team_id": 1, "source_id": 1, "query": "", "limit": 3}), &p)
+	res := h.callTool("query_logchefql", map[string]any{"team_id": 1, "source_id": 1, "query": `level="error"`, "limit": 3, "cursor": p.NextCursor})
+	if !res.IsError {
+		t.Error("cursor accepted for a different query")
+	}
+}
+
+func TestQueryLogsPagination(t *testing.T) {
+	// Rows share timestamps across page boundaries, so pages must tell them
+	// apart by more than the timestamp.
+	rows := []client.LogEntry{
+		{"timestamp": "2026-03-01 10:03:00", "message": "a"},
+		{"timestamp": "2026-03-01 10:02:00", "message": "b"},
+		{"timestamp": "2026-03-01 10:02:00", "message": "c"},
+		{"timestamp": "2026-03-01 10:02:00", "message": "d"},
+		{"timestamp": "2026-03-01 10:02:00", "message": "e"},
+		{"timestamp": "2026-03-01 10:01:00", "message": "f"},
+	}
+	before := regexp.MustCompile("WHERE `timestamp` <= '([^']+)'")
+	var queries []string
+	h := newHarness(t, allTools(), logcheftest.AdminKey, logcheftest.WithQueryFunc(func(_ int, sql string) ([]client.LogEntry, error) {
+		queries = append(queries, sql)
+		m := before.FindStringSubmatch(sql)
+		if m == nil {
+			return slices.Clone(rows), nil
+		}
+		return slices.DeleteFunc(slices.Clone(rows), func(row client.LogEntry) bool {
+			return row["timestamp"].(string) > m[1]
+		}), nil
+	}))
+
+	got, pages := pageThrough(t, h, "query_logs", map[string]any{
+		"team_id": 1, "source_id": 1, "limit": 2,
+		"raw_sql": "SELECT * FROM logs.app WHERE timestamp >= '2026-03-01 00:00:00' ORDER BY timestamp DESC;",
+	})
+	if want := []string{"a", "b", "c", "d", "e", "f"}; !slices.Equal(got, want) {
+		t.Errorf("paged rows = %q, want %q", got, want)
+	}
+	if pages != 3 {
+		t.Errorf("pages = %d, want 3", pages)
+	}
+	if !strings.Contains(queries[1], "FROM (\nSELECT * FROM logs.app WHERE timestamp >= '2026-03-01 00:00:00' ORDER BY timestamp DESC\n) WHERE `timestamp` <= '2026-03-01 10:02:00'") {
+		t.Errorf("second page query = %q", queries[1])
+	}
+}
+
+func TestTamperedCursor(t *testing.T) {
+	h := newHarness(t, allTools(), logcheftest.AdminKey)
+	args := map[string]any{
+		"team_id": 1, "source_id": 1, "limit": 2,
+		"raw_sql": "SELECT * FROM logs.app WHERE timestamp >= '2026-03-01 00:00:00' ORDER BY timestamp DESC",
+	}
+	var p logPage
+	decodeText(t, h.callTool("query_logs", args), &p)
+
+	b, err := base64.RawURLEncoding.DecodeString(p.NextCursor)
+	if err != nil {
+		t.Fatalf("decode cursor: %v", err)
+	}
+	var cur map[string]any
+	if err := json.Unmarshal(b, &cur); err != nil {
+		t.Fatalf("decode cursor: %v", err)
+	}
+	cur["ts"] = `x\' OR 1 UNION ALL SELECT name FROM system.users --`
+	b, _ = json.Marshal(cur)
+	args["cursor"] = base64.RawURLEncoding.EncodeToString(b)
+
+	queries := h.fake.CountRequests("POST", "/api/v1/teams/1/sources/1/logs/query")
+	if res := h.callTool("query_logs", args); !res.IsError || !strings.Contains(fmt.Sprint(res.Content), "invalid cursor") {
+		t.Errorf("tampered cursor = %v", res.Content)
+	}
+	if n := h.fake.CountRequests("POST", "/api/v1/teams/1/sources/1/logs/query"); n != queries {
+		t.Errorf("tampered cursor sent %d queries", n-queries)
+	}
+}
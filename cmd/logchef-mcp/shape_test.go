@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/mr-karan/logchef-mcp/client"
+	"github.com/mr-karan/logchef-mcp/logcheftest"
+)
+
+func TestQueryLogsShaping(t *testing.T) {
+	var rows []client.LogEntry
+	for i := range 6 {
+		rows = append(rows, client.LogEntry{
+			"timestamp":  fmt.Sprintf("2026-03-01 10:0%d:00", 9-i),
+			"message":    fmt.Sprintf("m%d", i),
+			"body":       strings.Repeat("x", 5000),
+			"attributes": map[string]any{"http": map[string]any{"status": 500}, "user": "u1"},
+		})
+	}
+	before := regexp.MustCompile("WHERE `timestamp` <= '([^']+)'")
+	h := newHarness(t, allTools(), logcheftest.AdminKey, logcheftest.WithQueryFunc(func(_ int, sql string) ([]client.LogEntry, error) {
+		m := before.FindStringSubmatch(sql)
+		return slices.DeleteFunc(slices.Clone(rows), func(row client.LogEntry) bool {
+			return m != nil && row["timestamp"].(string) > m[1]
+		}), nil
+	}))
+	args := map[string]any{
+		"team_id": 1, "source_id": 1,
+		"raw_sql": "SELECT * FROM logs.app WHERE timestamp >= '2026-03-01 00:00:00' ORDER BY timestamp DESC",
+	}
+
+	args["columns"] = []string{"message", "body", "attributes.http"}
+	args["flatten"] = true
+	args["max_field_length"] = 10
+	var page struct {
+		Data  []map[string]any `json:"data"`
+		Notes []string         `json:"notes"`
+	}
+	decodeText(t, h.callTool("query_logs", args), &page)
+	want := map[string]any{"message": "m0", "body": "xxxxxxxxxx…[truncated 4990 chars]", "attributes.http.status": 500.0}
+	if len(page.Data) != 6 || fmt.Sprint(page.Data[0]) != fmt.Sprint(want) {
+		t.Errorf("shaped row = %v, want %v", page.Data[0], want)
+	}
+	if len(page.Notes) != 1 || !strings.Contains(page.Notes[0], "6 values were cut") {
+		t.Errorf("notes = %q", page.Notes)
+	}
+
+	// Bodies are cut to 2000 characters by default, so a budget of 4500
+	// bytes fits two rows a page.
+	delete(args, "columns")
+	delete(args, "flatten")
+	delete(args, "max_field_length")
+	args["max_bytes"] = 4500
+	var first struct {
+		RowsDropped int      `json:"rows_dropped"`
+		Notes       []string `json:"notes"`
+	}
+	decodeText(t, h.callTool("query_logs", args), &first)
+	if first.RowsDropped != 4 || len(first.Notes) != 2 || !strings.Contains(first.Notes[1], "max_bytes (4500 bytes)") {
+		t.Errorf("first page = %+v", first)
+	}
+	got, pages := pageThrough(t, h, "query_logs", args)
+	if want := []string{"m0", "m1", "m2", "m3", "m4", "m5"}; !slices.Equal(got, want) || pages != 3 {
+		t.Errorf("paged rows = %q over %d pages, want %q over 3", got, pages, want)
+	}
+}
+
+func TestLogContextShaping(t *testing.T) {
+	h := newHarness(t, allTools(), logcheftest.AdminKey)
+	var res struct {
+		Before      []map[string]any `json:"before_logs"`
+		Target      []map[string]any `json:"target_logs"`
+		After       []map[string]any `json:"after_logs"`
+		RowsDropped int              `json:"rows_dropped"`
+	}
+	decodeText(t, h.callTool("get_log_context", map[string]any{
+		"team_id": 1, "source_id": 1, "timestamp": 1772359380000,
+		"columns": []string{"message"}, "max_bytes": 150,
+	}), &res)
+	if len(res.Target) != 1 || len(res.Target[0]) != 1 || res.Target[0]["message"] != "database connection refused" {
+		t.Errorf("target_logs = %v", res.Target)
+	}
+	total := len(res.Before) + len(res.Target) + len(res.After)
+	if total+res.RowsDropped != 8 || res.RowsDropped == 0 {
+		t.Errorf("returned %d rows and dropped %d, want 8 in all with some dropped", total, res.RowsDropped)
+	}
+}
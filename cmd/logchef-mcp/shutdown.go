@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// cancelGrace is how long shutdown waits for tool calls to return once their
+// contexts have been cancelled at the end of the drain timeout.
+const cancelGrace = 5 * time.Second
+
+// drainer tracks running tool calls so that shutdown can wait for them to
+// finish and cancel those still running when the drain timeout expires.
+type drainer struct {
+	mu       sync.Mutex
+	running  int
+	draining bool
+	idle     chan struct{} // closed once draining with no calls running
+
+	// ctx is cancelled when the drain timeout expires, cancelling the
+	// contexts of all running tool calls.
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func newDrainer() *drainer {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &drainer{idle: make(chan struct{}), ctx: ctx, cancel: cancel}
+}
+
+// middleware rejects tool calls once shutdown has started and ties the
+// context of each accepted call to the drainer.
+func (d *drainer) middleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if !d.start() {
+			return mcp.NewToolResultError("The server is shutting down; retry the call shortly"), nil
+		}
+		defer d.done()
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		stop := context.AfterFunc(d.ctx, cancel)
+		defer stop()
+		return next(ctx, req)
+	}
+}
+
+func (d *drainer) start() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.draining {
+		return false
+	}
+	d.running++
+	return true
+}
+
+func (d *drainer) done() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.running--
+	if d.draining && d.running == 0 {
+		close(d.idle)
+	}
+}
+
+// drain stops accepting tool calls and waits up to timeout for running ones
+// to finish. Calls still running after that are cancelled and given
+// cancelGrace to return. It reports whether every call finished on its own.
+func (d *drainer) drain(timeout time.Duration) bool {
+	d.mu.Lock()
+	if !d.draining {
+		d.draining = true
+		if d.running == 0 {
+			close(d.idle)
+		} else {
+			slog.Info("Waiting for running tool calls to finish", "count", d.running, "timeout", timeout)
+		}
+	}
+	d.mu.Unlock()
+
+	select {
+	case <-d.idle:
+		return true
+	case <-time.After(timeout):
+	}
+	d.mu.Lock()
+	slog.Warn("Drain timeout expired, cancelling running tool calls", "count", d.running)
+	d.mu.Unlock()
+	d.cancel()
+	select {
+	case <-d.idle:
+	case <-time.After(cancelGrace):
+		slog.Error("Tool calls did not return after being cancelled")
+	}
+	return false
+}
+
+// serveHTTP serves httpSrv, over TLS if it has a TLSConfig, until it fails
+// or ctx is cancelled. On cancellation it stops accepting connections,
+// drains tool calls and then calls closeSessions to end the remaining
+// sessions and streams.
+func serveHTTP(ctx context.Context, httpSrv *http.Server, closeSessions func(context.Context) error, d *drainer, timeout time.Duration) error {
+	errc := make(chan error, 1)
+	go func() {
+		if httpSrv.TLSConfig != nil {
+			errc <- httpSrv.ListenAndServeTLS("", "")
+			return
+		}
+		errc <- httpSrv.ListenAndServe()
+	}()
+	select {
+	case err := <-errc:
+		return fmt.Errorf("server error: %w", err)
+	case <-ctx.Done():
+	}
+
+	slog.Info("Shutting down", "drain_timeout", timeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout+cancelGrace)
+	defer cancel()
+	// Shutdown closes the listeners at once, then waits for open
+	// connections, including SSE streams, to finish.
+	stopped := make(chan error, 1)
+	go func() { stopped <- httpSrv.Shutdown(shutdownCtx) }()
+	clean := d.drain(timeout)
+	if err := closeSessions(shutdownCtx); err != nil {
+		// Errors repeat those of the Shutdown call above.
+		slog.Debug("Closing sessions", "error", err)
+	}
+	if err := <-stopped; err != nil {
+		return fmt.Errorf("shutdown: %w", err)
+	}
+	if err := <-errc; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("server error: %w", err)
+	}
+	if !clean {
+		return errors.New("shutdown: tool calls were cancelled after the drain timeout")
+	}
+	slog.Info("Shutdown complete")
+	return nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestDrainer(t *testing.T) {
+	callTool := func(handler func(ctx context.Context) error, d *drainer) (*mcp.CallToolResult, error) {
+		return d.middleware(func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			if err := handler(ctx); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			return mcp.NewToolResultText("ok"), nil
+		})(context.Background(), mcp.CallToolRequest{})
+	}
+
+	t.Run("finishes within timeout", func(t *testing.T) {
+		d := newDrainer()
+		started, release := make(chan struct{}), make(chan struct{})
+		result := make(chan *mcp.CallToolResult, 1)
+		go func() {
+			res, _ := callTool(func(ctx context.Context) error {
+				close(started)
+				<-release
+				return ctx.Err()
+			}, d)
+			result <- res
+		}()
+		<-started
+
+		drained := make(chan bool, 1)
+		go func() { drained <- d.drain(time.Minute) }()
+		for !d.isDraining() {
+			time.Sleep(time.Millisecond)
+		}
+		// New calls are rejected while draining.
+		if res, _ := callTool(func(context.Context) error { return nil }, d); !res.IsError {
+			t.Error("call accepted after shutdown started")
+		}
+		close(release)
+		if !<-drained {
+			t.Error("drain reported cancelled calls")
+		}
+		if res := <-result; res.IsError {
+			t.Errorf("running call failed: %v", res.Content)
+		}
+	})
+
+	t.Run("cancelled after timeout", func(t *testing.T) {
+		d := newDrainer()
+		started := make(chan struct{})
+		result := make(chan error, 1)
+		go func() {
+			_, _ = callTool(func(ctx context.Context) error {
+				close(started)
+				<-ctx.Done()
+				result <- ctx.Err()
+				return ctx.Err()
+			}, d)
+		}()
+		<-started
+
+		if d.drain(10 * time.Millisecond) {
+			t.Error("drain reported success for a cancelled call")
+		}
+		if err := <-result; !errors.Is(err, context.Canceled) {
+			t.Errorf("call context error = %v, want context.Canceled", err)
+		}
+	})
+
+	t.Run("idle", func(t *testing.T) {
+		if !newDrainer().drain(time.Minute) {
+			t.Error("drain with no running calls reported cancelled calls")
+		}
+	})
+}
+
+func (d *drainer) isDraining() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.draining
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// tlsReloadInterval is how often the certificate, key and client CA files
+// are checked for changes.
+const tlsReloadInterval = 10 * time.Second
+
+// tlsFiles are the PEM files for serving TLS. clientCA is optional and, when
+// set, requires clients to present a certificate signed by it.
+type tlsFiles struct {
+	cert     string
+	key      string
+	clientCA string
+}
+
+func (f tlsFiles) enabled() bool {
+	return f.cert != "" || f.key != ""
+}
+
+func (f tlsFiles) validate() error {
+	if (f.cert == "") != (f.key == "") {
+		return errors.New("--tls-cert and --tls-key must be set together")
+	}
+	if f.clientCA != "" && f.cert == "" {
+		return errors.New("--client-ca requires --tls-cert and --tls-key")
+	}
+	return nil
+}
+
+// certReloader serves a TLS configuration that follows changes to the
+// certificate, key and client CA files, so renewed certificates are picked
+// up without a restart.
+type certReloader struct {
+	files tlsFiles
+
+	mu       sync.RWMutex
+	config   *tls.Config
+	modTimes []time.Time
+}
+
+// newCertReloader loads the files once, failing if they are missing or
+// invalid.
+func newCertReloader(files tlsFiles) (*certReloader, error) {
+	r := &certReloader{files: files}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// tlsConfig returns the configuration to set on the http.Server. Each
+// handshake uses the most recently loaded files.
+func (r *certReloader) tlsConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			r.mu.RLock()
+			defer r.mu.RUnlock()
+			return r.config, nil
+		},
+	}
+}
+
+// reload loads the files. On error the previous configuration stays in use.
+func (r *certReloader) reload() error {
+	modTimes := r.stat()
+	cert, err := tls.LoadX509KeyPair(r.files.cert, r.files.key)
+	if err != nil {
+		return fmt.Errorf("load TLS certificate: %w", err)
+	}
+	cfg := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+	}
+	if r.files.clientCA != "" {
+		pem, err := os.ReadFile(r.files.clientCA)
+		if err != nil {
+			return fmt.Errorf("load client CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("load client CA: no certificates found in %s", r.files.clientCA)
+		}
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	r.mu.Lock()
+	r.config = cfg
+	r.modTimes = modTimes
+	r.mu.Unlock()
+	return nil
+}
+
+// stat returns the modification times of the files, zero for files that
+// cannot be read.
+func (r *certReloader) stat() []time.Time {
+	var times []time.Time
+	for _, name := range []string{r.files.cert, r.files.key, r.files.clientCA} {
+		var t time.Time
+		if fi, err := os.Stat(name); err == nil {
+			t = fi.ModTime()
+		}
+		times = append(times, t)
+	}
+	return times
+}
+
+func (r *certReloader) changed() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for i, t := range r.stat() {
+		if !t.Equal(r.modTimes[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// watch reloads the files whenever they change until ctx is cancelled.
+func (r *certReloader) watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if !r.changed() {
+			continue
+		}
+		if err := r.reload(); err != nil {
+			// A renewal may replace the certificate and key one at a time;
+			// keep serving the old pair and retry on the next tick.
+			slog.Warn("Reloading TLS files failed, keeping the current certificate", "error", err)
+			continue
+		}
+		slog.Info("Reloaded TLS certificate", "cert", r.files.cert)
+	}
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testCA issues certificates for the TLS tests.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns a PEM certificate and key for name, valid for 127.0.0.1.
+func (ca *testCA) issue(t *testing.T, name string, usage x509.ExtKeyUsage) (certPEM, keyPEM []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func writeFile(t *testing.T, path string, data []byte, mtime time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// serveTLS serves a no-op handler with r's configuration and returns its URL.
+func serveTLS(t *testing.T, r *certReloader) string {
+	t.Helper()
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", r.tlsConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &http.Server{Handler: http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})}
+	go func() { _ = srv.Serve(ln) }()
+	t.Cleanup(func() { _ = srv.Close() })
+	return "https://" + ln.Addr().String()
+}
+
+func tlsClient(ca *testCA, certs ...tls.Certificate) *http.Client {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	return &http.Client{Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{RootCAs: pool, Certificates: certs},
+	}}
+}
+
+func TestCertReload(t *testing.T) {
+	ca := newTestCA(t)
+	dir := t.TempDir()
+	files := tlsFiles{cert: filepath.Join(dir, "tls.crt"), key: filepath.Join(dir, "tls.key")}
+	start := time.Now().Add(-time.Minute)
+	certPEM, keyPEM := ca.issue(t, "first", x509.ExtKeyUsageServerAuth)
+	writeFile(t, files.cert, certPEM, start)
+	writeFile(t, files.key, keyPEM, start)
+
+	r, err := newCertReloader(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	url := serveTLS(t, r)
+	servedName := func() string {
+		t.Helper()
+		// A new client per call forces a fresh handshake.
+		resp, err := tlsClient(ca).Get(url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.TLS.PeerCertificates[0].Subject.CommonName
+	}
+	if got := servedName(); got != "first" {
+		t.Fatalf("served certificate %q, want first", got)
+	}
+
+	// A half-written renewal keeps the old certificate.
+	writeFile(t, files.cert, []byte("not a certificate"), start.Add(time.Second))
+	if !r.changed() {
+		t.Fatal("change not detected")
+	}
+	if err := r.reload(); err == nil {
+		t.Error("reload of an invalid certificate succeeded")
+	}
+	if got := servedName(); got != "first" {
+		t.Errorf("served certificate %q after failed reload, want first", got)
+	}
+
+	certPEM, keyPEM = ca.issue(t, "second", x509.ExtKeyUsageServerAuth)
+	writeFile(t, files.cert, certPEM, start.Add(2*time.Second))
+	writeFile(t, files.key, keyPEM, start.Add(2*time.Second))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.watch(ctx, 10*time.Millisecond)
+	deadline := time.Now().Add(5 * time.Second)
+	for servedName() != "second" {
+		if time.Now().After(deadline) {
+			t.Fatal("renewed certificate was not picked up")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestClientCA(t *testing.T) {
+	ca := newTestCA(t)
+	dir := t.TempDir()
+	files := tlsFiles{
+		cert:     filepath.Join(dir, "tls.crt"),
+		key:      filepath.Join(dir, "tls.key"),
+		clientCA: filepath.Join(dir, "ca.crt"),
+	}
+	certPEM, keyPEM := ca.issue(t, "server", x509.ExtKeyUsageServerAuth)
+	writeFile(t, files.cert, certPEM, time.Now())
+	writeFile(t, files.key, keyPEM, time.Now())
+	writeFile(t, files.clientCA, ca.pem, time.Now())
+
+	r, err := newCertReloader(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	url := serveTLS(t, r)
+
+	if _, err := tlsClient(ca).Get(url); err == nil {
+		t.Error("request without a client certificate succeeded")
+	}
+	other := newTestCA(t)
+	strangerCert, strangerKey := other.issue(t, "stranger", x509.ExtKeyUsageClientAuth)
+	stranger, _ := tls.X509KeyPair(strangerCert, strangerKey)
+	if _, err := tlsClient(ca, stranger).Get(url); err == nil {
+		t.Error("request with a certificate from another CA succeeded")
+	}
+	clientCert, clientKey := ca.issue(t, "client", x509.ExtKeyUsageClientAuth)
+	client, _ := tls.X509KeyPair(clientCert, clientKey)
+	resp, err := tlsClient(ca, client).Get(url)
+	if err != nil {
+		t.Fatalf("request with a valid client certificate: %v", err)
+	}
+	resp.Body.Close()
+}
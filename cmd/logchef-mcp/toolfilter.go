@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// toolPattern matches tools by name glob, optionally scoped to a category
+// ("admin:list_*") and optionally negated ("!delete_*").
+type toolPattern struct {
+	raw      string
+	negate   bool
+	category string
+	glob     string
+}
+
+// parseToolPatterns parses a comma separated list of tool patterns.
+func parseToolPatterns(list string) ([]toolPattern, error) {
+	var patterns []toolPattern
+	for _, raw := range strings.Split(list, ",") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		p := toolPattern{raw: raw}
+		rest := raw
+		if r, ok := strings.CutPrefix(rest, "!"); ok {
+			p.negate = true
+			rest = r
+		}
+		if category, glob, ok := strings.Cut(rest, ":"); ok {
+			p.category = category
+			rest = glob
+		}
+		if rest == "" {
+			return nil, fmt.Errorf("invalid tool pattern %q: empty name", raw)
+		}
+		if _, err := path.Match(rest, ""); err != nil {
+			return nil, fmt.Errorf("invalid tool pattern %q: %w", raw, err)
+		}
+		p.glob = rest
+		patterns = append(patterns, p)
+	}
+	return patterns, nil
+}
+
+func (p toolPattern) matches(name, category string) bool {
+	if p.category != "" && p.category != category {
+		return false
+	}
+	ok, _ := path.Match(p.glob, name)
+	return ok
+}
+
+func (p toolPattern) matchesAny(categories map[string]string) bool {
+	for name, category := range categories {
+		if p.matches(name, category) {
+			return true
+		}
+	}
+	return false
+}
+
+// lastMatch returns the last pattern in patterns matching the tool. Later
+// patterns override earlier ones, so "admin:*,!delete_*" selects every admin
+// tool except the deletes.
+func lastMatch(patterns []toolPattern, name, category string) (toolPattern, bool) {
+	for _, p := range slices.Backward(patterns) {
+		if p.matches(name, category) {
+			return p, true
+		}
+	}
+	return toolPattern{}, false
+}
+
+// toolFilter narrows the registered tools after category registration.
+type toolFilter struct {
+	// allow, when non-empty, keeps only tools whose last matching pattern
+	// is not negated. A list made only of negated patterns keeps everything
+	// it does not exclude.
+	allow []toolPattern
+	// deny removes tools whose last matching pattern is not negated.
+	deny []toolPattern
+}
+
+func (f toolFilter) keep(name, category string) bool {
+	if len(f.allow) > 0 {
+		p, ok := lastMatch(f.allow, name, category)
+		// An allow list of only exclusions ("!delete_*") starts from every tool.
+		onlyNegated := !slices.ContainsFunc(f.allow, func(p toolPattern) bool { return !p.negate })
+		if ok && p.negate || !ok && !onlyNegated {
+			return false
+		}
+	}
+	if p, ok := lastMatch(f.deny, name, category); ok && !p.negate {
+		return false
+	}
+	return true
+}
+
+// apply unregisters tools rejected by the filter. categories maps each
+// registered tool name to the category that registered it.
+func (f toolFilter) apply(s *server.MCPServer, categories map[string]string) {
+	if len(f.allow) == 0 && len(f.deny) == 0 {
+		return
+	}
+	var removed []string
+	for name := range s.ListTools() {
+		if !f.keep(name, categories[name]) {
+			removed = append(removed, name)
+		}
+	}
+	for _, p := range slices.Concat(f.allow, f.deny) {
+		if !p.matchesAny(categories) {
+			slog.Warn("Tool pattern matches no registered tools", "pattern", p.raw)
+		}
+	}
+	if len(removed) == 0 {
+		return
+	}
+	slices.Sort(removed)
+	slog.Info("Disabling tools by allow/deny list", "tools", removed)
+	s.DeleteTools(removed...)
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/mr-karan/logchef-mcp/logcheftest"
+)
+
+func mustPatterns(t *testing.T, list string) []toolPattern {
+	t.Helper()
+	p, err := parseToolPatterns(list)
+	if err != nil {
+		t.Fatalf("parseToolPatterns(%q): %v", list, err)
+	}
+	return p
+}
+
+func TestToolFilterKeep(t *testing.T) {
+	tests := []struct {
+		allow, deny string
+		name        string
+		category    string
+		want        bool
+	}{
+		{name: "delete_team", category: "admin", want: true},
+		{allow: "admin:*", name: "list_all_users", category: "admin", want: true},
+		{allow: "admin:*", name: "query_logs", category: "logs", want: false},
+		{allow: "admin:*,!delete_*", name: "delete_team", category: "admin", want: false},
+		{allow: "!delete_*", name: "query_logs", category: "logs", want: true},
+		{allow: "!delete_*", name: "delete_collection", category: "logs", want: false},
+		{allow: "list_all_users", name: "create_user", category: "admin", want: false},
+		{deny: "admin:create_*,admin:update_*", name: "create_user", category: "admin", want: false},
+		{deny: "admin:create_*", name: "create_collection", category: "logs", want: true},
+		{deny: "*_collection,!get_collection", name: "get_collection", category: "logs", want: true},
+		{allow: "query_*", deny: "query_logs", name: "query_logs", category: "logs", want: false},
+	}
+	for _, tt := range tests {
+		f := toolFilter{allow: mustPatterns(t, tt.allow), deny: mustPatterns(t, tt.deny)}
+		if got := f.keep(tt.name, tt.category); got != tt.want {
+			t.Errorf("allow=%q deny=%q keep(%s) = %v, want %v", tt.allow, tt.deny, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseToolPatternsInvalid(t *testing.T) {
+	for _, list := range []string{"admin:", "!", "query_[logs"} {
+		if _, err := parseToolPatterns(list); err == nil {
+			t.Errorf("parseToolPatterns(%q) succeeded, want error", list)
+		}
+	}
+}
+
+func TestAllowDenyTools(t *testing.T) {
+	dt := allTools()
+	dt.filter = toolFilter{
+		allow: mustPatterns(t, "admin:list_*,query_*,*_collection"),
+		deny:  mustPatterns(t, "create_collection,update_collection,delete_collection"),
+	}
+	h := newHarness(t, dt, logcheftest.AdminKey)
+
+	var names []string
+	for _, tool := range h.listTools() {
+		names = append(names, tool.Name)
+	}
+	want := []string{"get_collection", "list_all_sources", "list_all_teams", "list_all_users", "list_api_tokens", "list_team_members", "query_logchefql", "query_logs"}
+	if !slices.Equal(names, want) {
+		t.Errorf("tools = %v, want %v", names, want)
+	}
+}
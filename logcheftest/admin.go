@@ -0,0 +1,249 @@
+package logcheftest
+
+import (
+	"cmp"
+	"net/http"
+	"slices"
+
+	"github.com/mr-karan/logchef-mcp/client"
+)
+
+func (s *Server) handleAdminListTeams(w http.ResponseWriter, r *http.Request, user *client.User) {
+	teams := []client.Team{}
+	for _, t := range s.data.Teams {
+		teams = append(teams, s.teamWithCount(t))
+	}
+	writeData(w, http.StatusOK, teams)
+}
+
+func (s *Server) handleAdminCreateTeam(w http.ResponseWriter, r *http.Request, user *client.User) {
+	var req client.TeamRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	if req.Name == "" {
+		writeError(w, http.StatusBadRequest, "team name is required")
+		return
+	}
+	if slices.ContainsFunc(s.data.Teams, func(t client.Team) bool { return t.Name == req.Name }) {
+		writeError(w, http.StatusConflict, "team name already exists")
+		return
+	}
+	id := 1
+	for _, t := range s.data.Teams {
+		id = max(id, t.ID+1)
+	}
+	now := s.timestamp()
+	team := client.Team{ID: id, Name: req.Name, Description: req.Description, CreatedAt: now, UpdatedAt: now}
+	s.data.Teams = append(s.data.Teams, team)
+	writeData(w, http.StatusCreated, team)
+}
+
+func (s *Server) handleAdminDeleteTeam(w http.ResponseWriter, r *http.Request, user *client.User) {
+	id, ok := pathID(w, r, "teamID")
+	if !ok {
+		return
+	}
+	i := slices.IndexFunc(s.data.Teams, func(t client.Team) bool { return t.ID == id })
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "team not found")
+		return
+	}
+	s.data.Teams = slices.Delete(s.data.Teams, i, i+1)
+	s.data.Members = slices.DeleteFunc(s.data.Members, func(m client.TeamMember) bool { return m.TeamID == id })
+	s.data.TeamSources = slices.DeleteFunc(s.data.TeamSources, func(ts TeamSource) bool { return ts.TeamID == id })
+	writeData(w, http.StatusOK, map[string]string{"message": "team deleted"})
+}
+
+func (s *Server) handleAdminListUsers(w http.ResponseWriter, r *http.Request, user *client.User) {
+	writeData(w, http.StatusOK, append([]client.User{}, s.data.Users...))
+}
+
+func (s *Server) handleAdminGetUser(w http.ResponseWriter, r *http.Request, user *client.User) {
+	id, ok := pathID(w, r, "userID")
+	if !ok {
+		return
+	}
+	u, ok := s.data.user(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "user not found")
+		return
+	}
+	writeData(w, http.StatusOK, *u)
+}
+
+func (s *Server) handleAdminCreateUser(w http.ResponseWriter, r *http.Request, user *client.User) {
+	var req client.UserRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	if req.Email == "" {
+		writeError(w, http.StatusBadRequest, "email is required")
+		return
+	}
+	if slices.ContainsFunc(s.data.Users, func(u client.User) bool { return u.Email == req.Email }) {
+		writeError(w, http.StatusConflict, "user with this email already exists")
+		return
+	}
+	id := 1
+	for _, u := range s.data.Users {
+		id = max(id, u.ID+1)
+	}
+	now := s.timestamp()
+	u := client.User{
+		ID:        id,
+		Email:     req.Email,
+		FullName:  req.FullName,
+		Role:      cmp.Or(req.Role, "member"),
+		Status:    cmp.Or(req.Status, "active"),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	s.data.Users = append(s.data.Users, u)
+	writeData(w, http.StatusCreated, u)
+}
+
+func (s *Server) handleAdminUpdateUser(w http.ResponseWriter, r *http.Request, user *client.User) {
+	id, ok := pathID(w, r, "userID")
+	if !ok {
+		return
+	}
+	u, ok := s.data.user(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "user not found")
+		return
+	}
+	var req client.UserUpdateRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	if req.Email != nil {
+		u.Email = *req.Email
+	}
+	if req.FullName != nil {
+		u.FullName = *req.FullName
+	}
+	if req.Role != nil {
+		u.Role = *req.Role
+	}
+	if req.Status != nil {
+		u.Status = *req.Status
+	}
+	u.UpdatedAt = s.timestamp()
+	writeData(w, http.StatusOK, *u)
+}
+
+func (s *Server) handleAdminDeleteUser(w http.ResponseWriter, r *http.Request, user *client.User) {
+	id, ok := pathID(w, r, "userID")
+	if !ok {
+		return
+	}
+	if id == user.ID {
+		writeError(w, http.StatusBadRequest, "cannot delete your own user")
+		return
+	}
+	i := slices.IndexFunc(s.data.Users, func(u client.User) bool { return u.ID == id })
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "user not found")
+		return
+	}
+	s.data.Users = slices.Delete(s.data.Users, i, i+1)
+	s.data.Members = slices.DeleteFunc(s.data.Members, func(m client.TeamMember) bool { return m.UserID == id })
+	s.data.Tokens = slices.DeleteFunc(s.data.Tokens, func(t Token) bool { return t.UserID == id })
+	writeData(w, http.StatusOK, map[string]string{"message": "user deleted"})
+}
+
+func (s *Server) handleAdminListSources(w http.ResponseWriter, r *http.Request, user *client.User) {
+	writeData(w, http.StatusOK, append([]client.Source{}, s.data.Sources...))
+}
+
+func (s *Server) handleAdminCreateSource(w http.ResponseWriter, r *http.Request, user *client.User) {
+	var req client.SourceRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	if req.Name == "" || req.Connection.Host == "" || req.Connection.TableName == "" {
+		writeError(w, http.StatusBadRequest, "name, connection.host and connection.table_name are required")
+		return
+	}
+	id := 1
+	for _, src := range s.data.Sources {
+		id = max(id, src.ID+1)
+	}
+	now := s.timestamp()
+	src := client.Source{
+		ID:                id,
+		Name:              req.Name,
+		Description:       req.Description,
+		Connection:        req.Connection,
+		MetaIsAutoCreated: req.MetaIsAutoCreated,
+		MetaTsField:       cmp.Or(req.MetaTsField, "timestamp"),
+		MetaSeverityField: req.MetaSeverityField,
+		TTLDays:           req.TTLDays,
+		IsConnected:       true,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+	s.data.Sources = append(s.data.Sources, src)
+	if len(req.Schema) > 0 {
+		s.data.Schemas[id] = req.Schema
+	}
+	writeData(w, http.StatusCreated, src)
+}
+
+func (s *Server) handleAdminValidateSource(w http.ResponseWriter, r *http.Request, user *client.User) {
+	var req client.SourceValidationRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	var problems []string
+	if req.Host == "" {
+		problems = append(problems, "host is required")
+	}
+	if req.TableName == "" {
+		problems = append(problems, "table_name is required")
+	}
+	if len(problems) > 0 {
+		writeData(w, http.StatusOK, client.SourceValidationResult{
+			IsValid:      false,
+			Message:      "validation failed",
+			ErrorDetails: problems,
+		})
+		return
+	}
+	writeData(w, http.StatusOK, client.SourceValidationResult{
+		IsValid:     true,
+		Message:     "connection successful",
+		TableExists: true,
+	})
+}
+
+func (s *Server) handleAdminDeleteSource(w http.ResponseWriter, r *http.Request, user *client.User) {
+	id, ok := pathID(w, r, "sourceID")
+	if !ok {
+		return
+	}
+	i := slices.IndexFunc(s.data.Sources, func(src client.Source) bool { return src.ID == id })
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "source not found")
+		return
+	}
+	s.data.Sources = slices.Delete(s.data.Sources, i, i+1)
+	s.data.TeamSources = slices.DeleteFunc(s.data.TeamSources, func(ts TeamSource) bool { return ts.SourceID == id })
+	delete(s.data.Schemas, id)
+	delete(s.data.Logs, id)
+	writeData(w, http.StatusOK, map[string]string{"message": "source deleted"})
+}
+
+func (s *Server) handleAdminSourceStats(w http.ResponseWriter, r *http.Request, user *client.User) {
+	id, ok := pathID(w, r, "sourceID")
+	if !ok {
+		return
+	}
+	src, ok := s.data.source(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "source not found")
+		return
+	}
+	writeData(w, http.StatusOK, s.stats(src))
+}
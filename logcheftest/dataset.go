@@ -0,0 +1,157 @@
+package logcheftest
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/mr-karan/logchef-mcp/client"
+)
+
+//go:embed fixtures/default.json
+var defaultFixture []byte
+
+// Well-known API keys in the default fixture.
+const (
+	// AdminKey authenticates as the global admin user (ID 1).
+	AdminKey = "admin-key"
+	// MemberKey authenticates as a regular member of the "platform" team (ID 2).
+	MemberKey = "member-key"
+)
+
+// Dataset is the in-memory state served by a fake Logchef server. It is
+// loaded from JSON fixtures and mutated by the admin and collection endpoints.
+type Dataset struct {
+	Version      string                     `json:"version"`
+	Users        []client.User              `json:"users"`
+	Tokens       []Token                    `json:"tokens"`
+	Teams        []client.Team              `json:"teams"`
+	Members      []client.TeamMember        `json:"members"`
+	Sources      []client.Source            `json:"sources"`
+	TeamSources  []TeamSource               `json:"team_sources"`
+	Schemas      map[int][]client.LogColumn `json:"schemas"`
+	Logs         map[int][]client.LogEntry  `json:"logs"`
+	Collections  []client.Collection        `json:"collections"`
+	Alerts       []Alert                    `json:"alerts"`
+	AlertHistory []client.AlertHistoryEntry `json:"alert_history"`
+}
+
+// Token is an API token together with the secret used to authenticate with it.
+type Token struct {
+	Key string `json:"key"`
+	client.APIToken
+}
+
+// TeamSource links a source to a team.
+type TeamSource struct {
+	TeamID   int `json:"team_id"`
+	SourceID int `json:"source_id"`
+}
+
+// Alert is an alert rule configured on a team's source.
+type Alert struct {
+	TeamID   int `json:"team_id"`
+	SourceID int `json:"source_id"`
+	client.AlertItem
+}
+
+// DefaultDataset returns a fresh copy of the built-in fixture: an admin and a
+// member user, two teams, two sources with schemas and logs, collections,
+// alerts and alert history.
+func DefaultDataset() *Dataset {
+	d, err := ParseDataset(defaultFixture)
+	if err != nil {
+		panic(fmt.Errorf("parse default fixture: %w", err))
+	}
+	return d
+}
+
+// LoadDataset reads a JSON fixture from path.
+func LoadDataset(path string) (*Dataset, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read fixture: %w", err)
+	}
+	return ParseDataset(b)
+}
+
+// ParseDataset decodes a JSON fixture.
+func ParseDataset(b []byte) (*Dataset, error) {
+	var d Dataset
+	if err := json.Unmarshal(b, &d); err != nil {
+		return nil, fmt.Errorf("unmarshal fixture: %w", err)
+	}
+	if d.Schemas == nil {
+		d.Schemas = map[int][]client.LogColumn{}
+	}
+	if d.Logs == nil {
+		d.Logs = map[int][]client.LogEntry{}
+	}
+	return &d, nil
+}
+
+func (d *Dataset) user(id int) (*client.User, bool) {
+	for i := range d.Users {
+		if d.Users[i].ID == id {
+			return &d.Users[i], true
+		}
+	}
+	return nil, false
+}
+
+func (d *Dataset) team(id int) (*client.Team, bool) {
+	for i := range d.Teams {
+		if d.Teams[i].ID == id {
+			return &d.Teams[i], true
+		}
+	}
+	return nil, false
+}
+
+func (d *Dataset) source(id int) (*client.Source, bool) {
+	for i := range d.Sources {
+		if d.Sources[i].ID == id {
+			return &d.Sources[i], true
+		}
+	}
+	return nil, false
+}
+
+func (d *Dataset) tokenByKey(key string) (*Token, bool) {
+	for i := range d.Tokens {
+		if d.Tokens[i].Key == key {
+			return &d.Tokens[i], true
+		}
+	}
+	return nil, false
+}
+
+// memberRole returns the user's role in the team, if they are a member.
+func (d *Dataset) memberRole(teamID, userID int) (string, bool) {
+	for _, m := range d.Members {
+		if m.TeamID == teamID && m.UserID == userID {
+			return m.Role, true
+		}
+	}
+	return "", false
+}
+
+func (d *Dataset) teamHasSource(teamID, sourceID int) bool {
+	for _, ts := range d.TeamSources {
+		if ts.TeamID == teamID && ts.SourceID == sourceID {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *Dataset) memberCount(teamID int) int {
+	n := 0
+	for _, m := range d.Members {
+		if m.TeamID == teamID {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,605 @@
+package logcheftest
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/mr-karan/logchef-mcp/client"
+)
+
+func (s *Server) routes() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /api/v1/meta", s.serve(s.handleMeta))
+	mux.HandleFunc("GET /api/v1/me", s.serve(s.handleProfile))
+	mux.HandleFunc("GET /api/v1/me/teams", s.serve(s.handleMyTeams))
+	mux.HandleFunc("GET /api/v1/me/tokens", s.serve(s.handleListTokens))
+	mux.HandleFunc("POST /api/v1/me/tokens", s.serve(s.handleCreateToken))
+	mux.HandleFunc("DELETE /api/v1/me/tokens/{tokenID}", s.serve(s.handleDeleteToken))
+
+	mux.HandleFunc("GET /api/v1/teams/{teamID}", s.serve(s.handleGetTeam))
+	mux.HandleFunc("PUT /api/v1/teams/{teamID}", s.serve(s.handleUpdateTeam))
+	mux.HandleFunc("GET /api/v1/teams/{teamID}/members", s.serve(s.handleListMembers))
+	mux.HandleFunc("POST /api/v1/teams/{teamID}/members", s.serve(s.handleAddMember))
+	mux.HandleFunc("DELETE /api/v1/teams/{teamID}/members/{userID}", s.serve(s.handleRemoveMember))
+	mux.HandleFunc("GET /api/v1/teams/{teamID}/sources", s.serve(s.handleTeamSources))
+	mux.HandleFunc("POST /api/v1/teams/{teamID}/sources", s.serve(s.handleLinkSource))
+	mux.HandleFunc("DELETE /api/v1/teams/{teamID}/sources/{sourceID}", s.serve(s.handleUnlinkSource))
+
+	src := "/api/v1/teams/{teamID}/sources/{sourceID}"
+	mux.HandleFunc("GET "+src+"/schema", s.serve(s.handleSchema))
+	mux.HandleFunc("GET "+src+"/stats", s.serve(s.handleSourceStats))
+	mux.HandleFunc("POST "+src+"/logs/query", s.serve(s.handleQueryLogs))
+	mux.HandleFunc("POST "+src+"/logs/histogram", s.serve(s.handleHistogram))
+	mux.HandleFunc("POST "+src+"/logs/context", s.serve(s.handleLogContext))
+	mux.HandleFunc("POST "+src+"/logchefql/query", s.serve(s.handleLogchefQLQuery))
+	mux.HandleFunc("POST "+src+"/logchefql/translate", s.serve(s.handleLogchefQLTranslate))
+	mux.HandleFunc("POST "+src+"/logchefql/validate", s.serve(s.handleLogchefQLValidate))
+	mux.HandleFunc("GET "+src+"/fields/values", s.serve(s.handleAllFieldValues))
+	mux.HandleFunc("GET "+src+"/fields/{field}/values", s.serve(s.handleFieldValues))
+	mux.HandleFunc("POST "+src+"/generate-sql", s.serve(s.handleGenerateSQL))
+	mux.HandleFunc("GET "+src+"/alerts", s.serve(s.handleListAlerts))
+	mux.HandleFunc("GET "+src+"/alerts/{alertID}/history", s.serve(s.handleAlertHistory))
+	mux.HandleFunc("GET "+src+"/collections", s.serve(s.handleListCollections))
+	mux.HandleFunc("POST "+src+"/collections", s.serve(s.handleCreateCollection))
+	mux.HandleFunc("GET "+src+"/collections/{collectionID}", s.serve(s.handleGetCollection))
+	mux.HandleFunc("PUT "+src+"/collections/{collectionID}", s.serve(s.handleUpdateCollection))
+	mux.HandleFunc("DELETE "+src+"/collections/{collectionID}", s.serve(s.handleDeleteCollection))
+
+	mux.HandleFunc("GET /api/v1/admin/teams", s.serve(s.admin(s.handleAdminListTeams)))
+	mux.HandleFunc("POST /api/v1/admin/teams", s.serve(s.admin(s.handleAdminCreateTeam)))
+	mux.HandleFunc("DELETE /api/v1/admin/teams/{teamID}", s.serve(s.admin(s.handleAdminDeleteTeam)))
+	mux.HandleFunc("GET /api/v1/admin/users", s.serve(s.admin(s.handleAdminListUsers)))
+	mux.HandleFunc("POST /api/v1/admin/users", s.serve(s.admin(s.handleAdminCreateUser)))
+	mux.HandleFunc("GET /api/v1/admin/users/{userID}", s.serve(s.admin(s.handleAdminGetUser)))
+	mux.HandleFunc("PUT /api/v1/admin/users/{userID}", s.serve(s.admin(s.handleAdminUpdateUser)))
+	mux.HandleFunc("DELETE /api/v1/admin/users/{userID}", s.serve(s.admin(s.handleAdminDeleteUser)))
+	mux.HandleFunc("GET /api/v1/admin/sources", s.serve(s.admin(s.handleAdminListSources)))
+	mux.HandleFunc("POST /api/v1/admin/sources", s.serve(s.admin(s.handleAdminCreateSource)))
+	mux.HandleFunc("POST /api/v1/admin/sources/validate", s.serve(s.admin(s.handleAdminValidateSource)))
+	mux.HandleFunc("DELETE /api/v1/admin/sources/{sourceID}", s.serve(s.admin(s.handleAdminDeleteSource)))
+	mux.HandleFunc("GET /api/v1/admin/sources/{sourceID}/stats", s.serve(s.admin(s.handleAdminSourceStats)))
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, "route not found: "+r.Method+" "+r.URL.Path)
+	})
+	return mux
+}
+
+type handler func(w http.ResponseWriter, r *http.Request, user *client.User)
+
+// admin restricts h to global admins.
+func (s *Server) admin(h handler) handler {
+	return func(w http.ResponseWriter, r *http.Request, user *client.User) {
+		if user.Role != "admin" {
+			writeError(w, http.StatusForbidden, "admin access required")
+			return
+		}
+		h(w, r, user)
+	}
+}
+
+// pathID parses the named path parameter, writing a 400 if it is not an int.
+func pathID(w http.ResponseWriter, r *http.Request, name string) (int, bool) {
+	id, err := strconv.Atoi(r.PathValue(name))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid "+name)
+		return 0, false
+	}
+	return id, true
+}
+
+// teamAccess resolves {teamID} and checks the user may read it. When
+// manage is set, the user must also be a team admin or global admin.
+func (s *Server) teamAccess(w http.ResponseWriter, r *http.Request, user *client.User, manage bool) (*client.Team, bool) {
+	teamID, ok := pathID(w, r, "teamID")
+	if !ok {
+		return nil, false
+	}
+	team, ok := s.data.team(teamID)
+	if !ok {
+		writeError(w, http.StatusNotFound, "team not found")
+		return nil, false
+	}
+	if user.Role == "admin" {
+		return team, true
+	}
+	role, member := s.data.memberRole(teamID, user.ID)
+	if !member {
+		writeError(w, http.StatusForbidden, "user is not a member of this team")
+		return nil, false
+	}
+	if manage && role != "admin" {
+		writeError(w, http.StatusForbidden, "team admin access required")
+		return nil, false
+	}
+	return team, true
+}
+
+// sourceAccess resolves {teamID}/{sourceID} and checks the source is linked
+// to a team the user belongs to.
+func (s *Server) sourceAccess(w http.ResponseWriter, r *http.Request, user *client.User) (*client.Team, *client.Source, bool) {
+	team, ok := s.teamAccess(w, r, user, false)
+	if !ok {
+		return nil, nil, false
+	}
+	sourceID, ok := pathID(w, r, "sourceID")
+	if !ok {
+		return nil, nil, false
+	}
+	src, ok := s.data.source(sourceID)
+	if !ok || !s.data.teamHasSource(team.ID, sourceID) {
+		writeError(w, http.StatusNotFound, "source not found")
+		return nil, nil, false
+	}
+	return team, src, true
+}
+
+func (s *Server) handleMeta(w http.ResponseWriter, r *http.Request, user *client.User) {
+	writeData(w, http.StatusOK, map[string]string{
+		"version":             s.data.Version,
+		"http_server_timeout": "60s",
+	})
+}
+
+func (s *Server) handleProfile(w http.ResponseWriter, r *http.Request, user *client.User) {
+	key := r.Header.Get("Authorization")[len("Bearer "):]
+	tok, _ := s.data.tokenByKey(key)
+	lastUsed := ""
+	if tok.LastUsedAt != nil {
+		lastUsed = *tok.LastUsedAt
+	}
+	lastLogin := ""
+	if user.LastLoginAt != nil {
+		lastLogin = *user.LastLoginAt
+	}
+	writeData(w, http.StatusOK, map[string]any{
+		"auth_method": "api_token",
+		"api_token": map[string]any{
+			"id":           tok.ID,
+			"name":         tok.Name,
+			"prefix":       tok.Prefix,
+			"created_at":   tok.CreatedAt,
+			"last_used_at": lastUsed,
+		},
+		"user": map[string]any{
+			"id":            user.ID,
+			"email":         user.Email,
+			"full_name":     user.FullName,
+			"role":          user.Role,
+			"status":        user.Status,
+			"last_login_at": lastLogin,
+			"created_at":    user.CreatedAt,
+			"updated_at":    user.UpdatedAt,
+		},
+	})
+}
+
+func (s *Server) handleMyTeams(w http.ResponseWriter, r *http.Request, user *client.User) {
+	teams := []client.UserTeamDetails{}
+	for _, t := range s.data.Teams {
+		role, ok := s.data.memberRole(t.ID, user.ID)
+		if !ok {
+			continue
+		}
+		teams = append(teams, client.UserTeamDetails{
+			ID:          t.ID,
+			Name:        t.Name,
+			Role:        role,
+			MemberCount: s.data.memberCount(t.ID),
+			CreatedAt:   t.CreatedAt,
+			UpdatedAt:   t.UpdatedAt,
+		})
+	}
+	writeData(w, http.StatusOK, teams)
+}
+
+func (s *Server) handleListTokens(w http.ResponseWriter, r *http.Request, user *client.User) {
+	tokens := []client.APIToken{}
+	for _, t := range s.data.Tokens {
+		if t.UserID == user.ID {
+			tokens = append(tokens, t.APIToken)
+		}
+	}
+	writeData(w, http.StatusOK, tokens)
+}
+
+func (s *Server) handleCreateToken(w http.ResponseWriter, r *http.Request, user *client.User) {
+	var req client.APITokenRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	if req.Name == "" {
+		writeError(w, http.StatusBadRequest, "token name is required")
+		return
+	}
+	id := 1
+	for _, t := range s.data.Tokens {
+		id = max(id, t.ID+1)
+	}
+	now := s.timestamp()
+	tok := Token{
+		Key: "lc_fake_" + strconv.Itoa(id),
+		APIToken: client.APIToken{
+			ID:        id,
+			UserID:    user.ID,
+			Name:      req.Name,
+			Prefix:    "lc_fak",
+			ExpiresAt: req.ExpiresAt,
+			CreatedAt: now,
+			UpdatedAt: now,
+		},
+	}
+	s.data.Tokens = append(s.data.Tokens, tok)
+	writeData(w, http.StatusCreated, client.APITokenCreateResponse{Token: tok.Key, APIToken: tok.APIToken})
+}
+
+func (s *Server) handleDeleteToken(w http.ResponseWriter, r *http.Request, user *client.User) {
+	id, ok := pathID(w, r, "tokenID")
+	if !ok {
+		return
+	}
+	i := slices.IndexFunc(s.data.Tokens, func(t Token) bool { return t.ID == id && t.UserID == user.ID })
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "token not found")
+		return
+	}
+	s.data.Tokens = slices.Delete(s.data.Tokens, i, i+1)
+	writeData(w, http.StatusOK, map[string]string{"message": "token deleted"})
+}
+
+func (s *Server) teamWithCount(t client.Team) client.Team {
+	t.MemberCount = s.data.memberCount(t.ID)
+	return t
+}
+
+func (s *Server) handleGetTeam(w http.ResponseWriter, r *http.Request, user *client.User) {
+	team, ok := s.teamAccess(w, r, user, false)
+	if !ok {
+		return
+	}
+	writeData(w, http.StatusOK, s.teamWithCount(*team))
+}
+
+func (s *Server) handleUpdateTeam(w http.ResponseWriter, r *http.Request, user *client.User) {
+	team, ok := s.teamAccess(w, r, user, true)
+	if !ok {
+		return
+	}
+	var req client.TeamUpdateRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	if req.Name != nil {
+		team.Name = *req.Name
+	}
+	if req.Description != nil {
+		team.Description = *req.Description
+	}
+	team.UpdatedAt = s.timestamp()
+	writeData(w, http.StatusOK, s.teamWithCount(*team))
+}
+
+func (s *Server) handleListMembers(w http.ResponseWriter, r *http.Request, user *client.User) {
+	team, ok := s.teamAccess(w, r, user, false)
+	if !ok {
+		return
+	}
+	members := []client.TeamMember{}
+	for _, m := range s.data.Members {
+		if m.TeamID != team.ID {
+			continue
+		}
+		if u, ok := s.data.user(m.UserID); ok {
+			m.Email = u.Email
+			m.FullName = u.FullName
+		}
+		members = append(members, m)
+	}
+	writeData(w, http.StatusOK, members)
+}
+
+func (s *Server) handleAddMember(w http.ResponseWriter, r *http.Request, user *client.User) {
+	team, ok := s.teamAccess(w, r, user, true)
+	if !ok {
+		return
+	}
+	var req client.TeamMemberRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	if _, ok := s.data.user(req.UserID); !ok {
+		writeError(w, http.StatusNotFound, "user not found")
+		return
+	}
+	if _, ok := s.data.memberRole(team.ID, req.UserID); ok {
+		writeError(w, http.StatusConflict, "user is already a member of this team")
+		return
+	}
+	role := req.Role
+	if role == "" {
+		role = "member"
+	}
+	s.data.Members = append(s.data.Members, client.TeamMember{
+		TeamID:    team.ID,
+		UserID:    req.UserID,
+		Role:      role,
+		CreatedAt: s.timestamp(),
+	})
+	writeData(w, http.StatusCreated, map[string]string{"message": "member added"})
+}
+
+func (s *Server) handleRemoveMember(w http.ResponseWriter, r *http.Request, user *client.User) {
+	team, ok := s.teamAccess(w, r, user, true)
+	if !ok {
+		return
+	}
+	userID, ok := pathID(w, r, "userID")
+	if !ok {
+		return
+	}
+	i := slices.IndexFunc(s.data.Members, func(m client.TeamMember) bool { return m.TeamID == team.ID && m.UserID == userID })
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "member not found")
+		return
+	}
+	s.data.Members = slices.Delete(s.data.Members, i, i+1)
+	writeData(w, http.StatusOK, map[string]string{"message": "member removed"})
+}
+
+func (s *Server) handleTeamSources(w http.ResponseWriter, r *http.Request, user *client.User) {
+	team, ok := s.teamAccess(w, r, user, false)
+	if !ok {
+		return
+	}
+	sources := []client.Source{}
+	for _, src := range s.data.Sources {
+		if s.data.teamHasSource(team.ID, src.ID) {
+			sources = append(sources, src)
+		}
+	}
+	writeData(w, http.StatusOK, sources)
+}
+
+func (s *Server) handleLinkSource(w http.ResponseWriter, r *http.Request, user *client.User) {
+	team, ok := s.teamAccess(w, r, user, true)
+	if !ok {
+		return
+	}
+	var req client.TeamSourceRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	if _, ok := s.data.source(req.SourceID); !ok {
+		writeError(w, http.StatusNotFound, "source not found")
+		return
+	}
+	if s.data.teamHasSource(team.ID, req.SourceID) {
+		writeError(w, http.StatusConflict, "source is already linked to this team")
+		return
+	}
+	s.data.TeamSources = append(s.data.TeamSources, TeamSource{TeamID: team.ID, SourceID: req.SourceID})
+	writeData(w, http.StatusCreated, map[string]string{"message": "source linked"})
+}
+
+func (s *Server) handleUnlinkSource(w http.ResponseWriter, r *http.Request, user *client.User) {
+	team, ok := s.teamAccess(w, r, user, true)
+	if !ok {
+		return
+	}
+	sourceID, ok := pathID(w, r, "sourceID")
+	if !ok {
+		return
+	}
+	i := slices.IndexFunc(s.data.TeamSources, func(ts TeamSource) bool { return ts.TeamID == team.ID && ts.SourceID == sourceID })
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "source is not linked to this team")
+		return
+	}
+	s.data.TeamSources = slices.Delete(s.data.TeamSources, i, i+1)
+	writeData(w, http.StatusOK, map[string]string{"message": "source unlinked"})
+}
+
+func (s *Server) handleSchema(w http.ResponseWriter, r *http.Request, user *client.User) {
+	_, src, ok := s.sourceAccess(w, r, user)
+	if !ok {
+		return
+	}
+	writeData(w, http.StatusOK, s.schema(src.ID))
+}
+
+func (s *Server) handleSourceStats(w http.ResponseWriter, r *http.Request, user *client.User) {
+	_, src, ok := s.sourceAccess(w, r, user)
+	if !ok {
+		return
+	}
+	writeData(w, http.StatusOK, s.stats(src))
+}
+
+func (s *Server) schema(sourceID int) []client.LogColumn {
+	if cols := s.data.Schemas[sourceID]; cols != nil {
+		return cols
+	}
+	return []client.LogColumn{}
+}
+
+func (s *Server) stats(src *client.Source) map[string]any {
+	cols := []client.ColumnStats{}
+	for _, c := range s.schema(src.ID) {
+		cols = append(cols, client.ColumnStats{Name: c.Name, Type: c.Type})
+	}
+	return map[string]any{
+		"table_stats": client.TableStats{
+			Database:     src.Connection.Database,
+			Table:        src.Connection.TableName,
+			Compressed:   "1.00 KiB",
+			Uncompressed: "4.00 KiB",
+			ComprRate:    4,
+			Rows:         int64(len(s.data.Logs[src.ID])),
+			PartCount:    1,
+		},
+		"column_stats": cols,
+	}
+}
+
+func (s *Server) handleListAlerts(w http.ResponseWriter, r *http.Request, user *client.User) {
+	team, src, ok := s.sourceAccess(w, r, user)
+	if !ok {
+		return
+	}
+	alerts := []client.AlertItem{}
+	for _, a := range s.data.Alerts {
+		if a.TeamID == team.ID && a.SourceID == src.ID {
+			alerts = append(alerts, a.AlertItem)
+		}
+	}
+	writeData(w, http.StatusOK, alerts)
+}
+
+func (s *Server) handleAlertHistory(w http.ResponseWriter, r *http.Request, user *client.User) {
+	team, src, ok := s.sourceAccess(w, r, user)
+	if !ok {
+		return
+	}
+	alertID, ok := pathID(w, r, "alertID")
+	if !ok {
+		return
+	}
+	if !slices.ContainsFunc(s.data.Alerts, func(a Alert) bool {
+		return a.ID == alertID && a.TeamID == team.ID && a.SourceID == src.ID
+	}) {
+		writeError(w, http.StatusNotFound, "alert not found")
+		return
+	}
+	history := []client.AlertHistoryEntry{}
+	for _, h := range s.data.AlertHistory {
+		if h.AlertID == alertID {
+			history = append(history, h)
+		}
+	}
+	writeData(w, http.StatusOK, history)
+}
+
+func (s *Server) collection(w http.ResponseWriter, r *http.Request, user *client.User) (int, bool) {
+	team, src, ok := s.sourceAccess(w, r, user)
+	if !ok {
+		return 0, false
+	}
+	id, ok := pathID(w, r, "collectionID")
+	if !ok {
+		return 0, false
+	}
+	i := slices.IndexFunc(s.data.Collections, func(c client.Collection) bool {
+		return c.ID == id && c.TeamID == team.ID && c.SourceID == src.ID
+	})
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "collection not found")
+		return 0, false
+	}
+	return i, true
+}
+
+func (s *Server) handleListCollections(w http.ResponseWriter, r *http.Request, user *client.User) {
+	team, src, ok := s.sourceAccess(w, r, user)
+	if !ok {
+		return
+	}
+	collections := []client.Collection{}
+	for _, c := range s.data.Collections {
+		if c.TeamID == team.ID && c.SourceID == src.ID {
+			collections = append(collections, c)
+		}
+	}
+	writeData(w, http.StatusOK, collections)
+}
+
+func (s *Server) handleCreateCollection(w http.ResponseWriter, r *http.Request, user *client.User) {
+	team, src, ok := s.sourceAccess(w, r, user)
+	if !ok {
+		return
+	}
+	var req client.CollectionRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	if req.Name == "" || req.Query == "" {
+		writeError(w, http.StatusBadRequest, "name and query are required")
+		return
+	}
+	id := 1
+	for _, c := range s.data.Collections {
+		id = max(id, c.ID+1)
+	}
+	now := s.timestamp()
+	c := client.Collection{
+		ID:          id,
+		Name:        req.Name,
+		Description: req.Description,
+		TeamID:      team.ID,
+		SourceID:    src.ID,
+		Query:       req.Query,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	s.data.Collections = append(s.data.Collections, c)
+	writeData(w, http.StatusCreated, c)
+}
+
+func (s *Server) handleGetCollection(w http.ResponseWriter, r *http.Request, user *client.User) {
+	i, ok := s.collection(w, r, user)
+	if !ok {
+		return
+	}
+	writeData(w, http.StatusOK, s.data.Collections[i])
+}
+
+func (s *Server) handleUpdateCollection(w http.ResponseWriter, r *http.Request, user *client.User) {
+	i, ok := s.collection(w, r, user)
+	if !ok {
+		return
+	}
+	var req client.CollectionRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	c := &s.data.Collections[i]
+	if req.Name != "" {
+		c.Name = req.Name
+	}
+	if req.Description != "" {
+		c.Description = req.Description
+	}
+	if req.Query != "" {
+		c.Query = req.Query
+	}
+	c.UpdatedAt = s.timestamp()
+	writeData(w, http.StatusOK, *c)
+}
+
+func (s *Server) handleDeleteCollection(w http.ResponseWriter, r *http.Request, user *client.User) {
+	i, ok := s.collection(w, r, user)
+	if !ok {
+		return
+	}
+	s.data.Collections = slices.Delete(s.data.Collections, i, i+1)
+	writeData(w, http.StatusOK, map[string]string{"message": "collection deleted"})
+}
+
+func (s *Server) handleGenerateSQL(w http.ResponseWriter, r *http.Request, user *client.User) {
+	_, src, ok := s.sourceAccess(w, r, user)
+	if !ok {
+		return
+	}
+	var req client.GenerateSQLRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	if req.NaturalLanguageQuery == "" {
+		writeError(w, http.StatusBadRequest, "natural_language_query is required")
+		return
+	}
+	writeData(w, http.StatusOK, map[string]string{
+		"sql_query": "SELECT * FROM " + tableName(src) + " ORDER BY " + src.MetaTsField + " DESC LIMIT 100",
+	})
+}
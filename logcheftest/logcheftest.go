@@ -0,0 +1,281 @@
+// Package logcheftest provides a fake Logchef server for tests.
+//
+// The server implements the /api/v1 endpoints used by client.Client on top of
+// an in-memory Dataset loaded from JSON fixtures, so the client and the MCP
+// tool handlers can be exercised end to end without a live Logchef or
+// ClickHouse:
+//
+//	srv := logcheftest.NewServer(t)
+//	c := srv.Client(logcheftest.AdminKey)
+//	teams, err := c.GetTeams(ctx)
+//
+// Raw SQL is not executed. Queries return the source's fixture rows (newest
+// first) unless a QueryFunc is installed with WithQueryFunc. EXPLAIN
+// ESTIMATE reports the number of rows the query would return. LogchefQL
+// supports field comparisons (=, !=, ~, !~, >, >=, <, <=) joined with "and"
+// and grouped with parentheses.
+package logcheftest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mr-karan/logchef-mcp/client"
+)
+
+// QueryFunc produces the rows returned for a raw SQL query against a source.
+type QueryFunc func(sourceID int, sql string) ([]client.LogEntry, error)
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithDataset seeds the server with d instead of the default fixture.
+func WithDataset(d *Dataset) Option {
+	return func(s *Server) {
+		s.data = d
+	}
+}
+
+// WithQueryFunc overrides how raw SQL queries (logs/query, logs/histogram)
+// resolve to rows. Returning an error produces a 400 response.
+func WithQueryFunc(fn QueryFunc) Option {
+	return func(s *Server) {
+		s.query = fn
+	}
+}
+
+// WithClock sets the clock used for created_at/updated_at on new records.
+func WithClock(now func() time.Time) Option {
+	return func(s *Server) {
+		s.now = now
+	}
+}
+
+// Request is a request received by the server.
+type Request struct {
+	Method string
+	Path   string
+	Query  string
+	Body   []byte
+}
+
+// Fault makes matching requests fail with the given status.
+type Fault struct {
+	Method string
+	Path   string
+	Status int
+	// Message is returned in the Logchef error envelope.
+	Message string
+	// RetryAfter, if set, is sent as the Retry-After header.
+	RetryAfter string
+	// Times is how many matching requests fail. Zero means once; a negative
+	// value fails every matching request.
+	Times int
+}
+
+// Server is a fake Logchef API server.
+type Server struct {
+	// URL is the base URL of the server, suitable for client.Config.BaseURL.
+	URL string
+
+	srv   *httptest.Server
+	query QueryFunc
+	now   func() time.Time
+
+	mu       sync.Mutex
+	data     *Dataset
+	faults   []*Fault
+	requests []Request
+	queries  int
+}
+
+// NewServer starts a fake Logchef server seeded with the default fixture
+// unless WithDataset is given. It is closed when the test finishes.
+func NewServer(tb testing.TB, opts ...Option) *Server {
+	tb.Helper()
+	s := &Server{now: time.Now}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.data == nil {
+		s.data = DefaultDataset()
+	}
+	if s.query == nil {
+		s.query = s.sourceRows
+	}
+	s.srv = httptest.NewServer(s.routes())
+	s.URL = s.srv.URL
+	tb.Cleanup(s.Close)
+	return s
+}
+
+// Close shuts the server down.
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// Client returns a client for the server authenticated with apiKey. Retries
+// use millisecond backoffs so fault-injection tests stay fast.
+func (s *Server) Client(apiKey string) *client.Client {
+	return client.New(client.Config{
+		BaseURL: s.URL,
+		APIKey:  apiKey,
+		Retry: client.RetryPolicy{
+			InitialBackoff: time.Millisecond,
+			MaxBackoff:     10 * time.Millisecond,
+		},
+	})
+}
+
+// Inject registers a fault for subsequent requests.
+func (s *Server) Inject(f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if f.Times == 0 {
+		f.Times = 1
+	}
+	s.faults = append(s.faults, &f)
+}
+
+// Requests returns the requests received so far, in order.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// CountRequests returns how many requests matched method and path.
+func (s *Server) CountRequests(method, path string) int {
+	n := 0
+	for _, r := range s.Requests() {
+		if r.Method == method && r.Path == path {
+			n++
+		}
+	}
+	return n
+}
+
+// Dataset returns the live dataset. Callers must not modify it while
+// requests are in flight.
+func (s *Server) Dataset() *Dataset {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data
+}
+
+// serve records the request, applies faults and authentication, and runs h
+// with the dataset locked.
+func (s *Server) serve(h func(w http.ResponseWriter, r *http.Request, user *client.User)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		r.Body = io.NopCloser(strings.NewReader(string(body)))
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		s.requests = append(s.requests, Request{Method: r.Method, Path: r.URL.Path, Query: r.URL.RawQuery, Body: body})
+
+		if f := s.takeFault(r.Method, r.URL.Path); f != nil {
+			if f.RetryAfter != "" {
+				w.Header().Set("Retry-After", f.RetryAfter)
+			}
+			msg := f.Message
+			if msg == "" {
+				msg = http.StatusText(f.Status)
+			}
+			writeError(w, f.Status, msg)
+			return
+		}
+
+		key, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || key == "" {
+			writeError(w, http.StatusUnauthorized, "missing API token")
+			return
+		}
+		tok, ok := s.data.tokenByKey(key)
+		if !ok {
+			writeError(w, http.StatusUnauthorized, "invalid API token")
+			return
+		}
+		user, ok := s.data.user(tok.UserID)
+		if !ok || user.Status != "active" {
+			writeError(w, http.StatusUnauthorized, "user is not active")
+			return
+		}
+
+		w.Header().Set("X-Request-ID", fmt.Sprintf("fake-%d", len(s.requests)))
+		h(w, r, user)
+	}
+}
+
+func (s *Server) takeFault(method, path string) *Fault {
+	for i, f := range s.faults {
+		if f.Method != method || f.Path != path {
+			continue
+		}
+		if f.Times > 0 {
+			f.Times--
+			if f.Times == 0 {
+				s.faults = append(s.faults[:i], s.faults[i+1:]...)
+			}
+		}
+		return f
+	}
+	return nil
+}
+
+func (s *Server) timestamp() string {
+	return s.now().UTC().Format(time.RFC3339)
+}
+
+// writeData writes a Logchef success envelope.
+func writeData(w http.ResponseWriter, status int, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{"status": "success", "data": data})
+}
+
+// writeError writes a Logchef error envelope.
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"status":     "error",
+		"message":    message,
+		"error_type": errorType(status),
+	})
+}
+
+func errorType(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return "ValidationError"
+	case http.StatusUnauthorized:
+		return "AuthenticationError"
+	case http.StatusForbidden:
+		return "AuthorizationError"
+	case http.StatusNotFound:
+		return "NotFoundError"
+	case http.StatusConflict:
+		return "ConflictError"
+	case http.StatusTooManyRequests:
+		return "RateLimitError"
+	default:
+		return "GeneralError"
+	}
+}
+
+func decode(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return false
+	}
+	return true
+}
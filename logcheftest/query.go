@@ -0,0 +1,605 @@
+package logcheftest
+
+import (
+	"cmp"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mr-karan/logchef-mcp/client"
+)
+
+// timeLayouts are the timestamp formats accepted in fixture rows and in
+// LogchefQL start/end times.
+var timeLayouts = []string{
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04:05.000",
+	time.RFC3339Nano,
+}
+
+func parseTime(v any) (time.Time, bool) {
+	s, ok := v.(string)
+	if !ok {
+		return time.Time{}, false
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func tableName(src *client.Source) string {
+	return src.Connection.Database + "." + src.Connection.TableName
+}
+
+// sourceRows is the default QueryFunc: every fixture row of the source.
+func (s *Server) sourceRows(sourceID int, _ string) ([]client.LogEntry, error) {
+	return slices.Clone(s.data.Logs[sourceID]), nil
+}
+
+// newestFirst sorts rows by the source's timestamp field, newest first.
+func newestFirst(rows []client.LogEntry, tsField string) {
+	slices.SortStableFunc(rows, func(a, b client.LogEntry) int {
+		ta, _ := parseTime(a[tsField])
+		tb, _ := parseTime(b[tsField])
+		return tb.Compare(ta)
+	})
+}
+
+func (s *Server) nextQueryID() string {
+	s.queries++
+	return fmt.Sprintf("fake-query-%d", s.queries)
+}
+
+func (s *Server) handleQueryLogs(w http.ResponseWriter, r *http.Request, user *client.User) {
+	_, src, ok := s.sourceAccess(w, r, user)
+	if !ok {
+		return
+	}
+	var req client.LogQueryRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	if strings.TrimSpace(req.RawSQL) == "" {
+		writeError(w, http.StatusBadRequest, "raw_sql is required")
+		return
+	}
+	if kind, inner, ok := cutExplain(req.RawSQL); ok {
+		s.writeExplain(w, src, kind, inner)
+		return
+	}
+	rows, err := s.query(src.ID, req.RawSQL)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	read := len(rows)
+	newestFirst(rows, src.MetaTsField)
+	if req.Limit > 0 && len(rows) > req.Limit {
+		rows = rows[:req.Limit]
+	}
+	writeData(w, http.StatusOK, map[string]any{
+		"data":     nonNil(rows),
+		"stats":    client.LogQueryStats{ExecutionTimeMs: 1, RowsRead: read},
+		"columns":  s.schema(src.ID),
+		"query_id": s.nextQueryID(),
+	})
+}
+
+// cutExplain splits an EXPLAIN query into its kind ("ESTIMATE" or the
+// settings such as "indexes = 1") and the query being explained.
+func cutExplain(sql string) (kind, inner string, ok bool) {
+	sql = strings.TrimSpace(sql)
+	if len(sql) < 8 || !strings.EqualFold(sql[:8], "EXPLAIN ") {
+		return "", "", false
+	}
+	rest := strings.TrimSpace(sql[8:])
+	upper := strings.ToUpper(rest)
+	i := -1
+	for _, start := range []string{"SELECT", "WITH", "("} {
+		if j := strings.Index(upper, start); j >= 0 && (i < 0 || j < i) {
+			i = j
+		}
+	}
+	if i < 0 {
+		return "", "", false
+	}
+	return strings.TrimSpace(rest[:i]), rest[i:], true
+}
+
+// writeExplain answers EXPLAIN ESTIMATE with the number of rows the query
+// function returns for the inner query, in 8192-row granules, and any
+// other EXPLAIN with a canned index plan.
+func (s *Server) writeExplain(w http.ResponseWriter, src *client.Source, kind, inner string) {
+	var rows []client.LogEntry
+	var columns []client.LogColumn
+	if strings.EqualFold(kind, "ESTIMATE") {
+		matched, err := s.query(src.ID, inner)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		n := len(matched)
+		rows = []client.LogEntry{{
+			"database": src.Connection.Database,
+			"table":    src.Connection.TableName,
+			"parts":    strconv.Itoa(min(n, 1)),
+			"rows":     strconv.Itoa(n),
+			"marks":    strconv.Itoa((n + 8191) / 8192),
+		}}
+		columns = []client.LogColumn{
+			{Name: "database", Type: "String"}, {Name: "table", Type: "String"},
+			{Name: "parts", Type: "UInt64"}, {Name: "rows", Type: "UInt64"}, {Name: "marks", Type: "UInt64"},
+		}
+	} else {
+		for _, line := range []string{
+			"Expression ((Projection + Before ORDER BY))",
+			"  ReadFromMergeTree (" + tableName(src) + ")",
+			"  Indexes:",
+			"    PrimaryKey",
+			"      Condition: true",
+			"      Parts: 1/1",
+			"      Granules: 1/1",
+		} {
+			rows = append(rows, client.LogEntry{"explain": line})
+		}
+		columns = []client.LogColumn{{Name: "explain", Type: "String"}}
+	}
+	writeData(w, http.StatusOK, map[string]any{
+		"data":     rows,
+		"stats":    client.LogQueryStats{ExecutionTimeMs: 1},
+		"columns":  columns,
+		"query_id": s.nextQueryID(),
+	})
+}
+
+func (s *Server) handleHistogram(w http.ResponseWriter, r *http.Request, user *client.User) {
+	_, src, ok := s.sourceAccess(w, r, user)
+	if !ok {
+		return
+	}
+	var req client.HistogramRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	window := cmp.Or(req.Window, "1m")
+	size, err := time.ParseDuration(window)
+	if err != nil || size <= 0 {
+		writeError(w, http.StatusBadRequest, "invalid window: "+window)
+		return
+	}
+	rows, err := s.query(src.ID, req.RawSQL)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	type key struct {
+		bucket time.Time
+		group  string
+	}
+	counts := map[key]int64{}
+	for _, row := range rows {
+		ts, ok := parseTime(row[src.MetaTsField])
+		if !ok {
+			continue
+		}
+		k := key{bucket: ts.Truncate(size)}
+		if req.GroupBy != "" {
+			k.group = fmt.Sprint(row[req.GroupBy])
+		}
+		counts[k]++
+	}
+	points := []client.HistogramDataPoint{}
+	for k, n := range counts {
+		points = append(points, client.HistogramDataPoint{
+			Bucket:     k.bucket.Format(time.RFC3339),
+			LogCount:   n,
+			GroupValue: k.group,
+		})
+	}
+	slices.SortFunc(points, func(a, b client.HistogramDataPoint) int {
+		return cmp.Or(cmp.Compare(a.Bucket, b.Bucket), cmp.Compare(a.GroupValue, b.GroupValue))
+	})
+	writeData(w, http.StatusOK, map[string]any{"granularity": window, "data": points})
+}
+
+func (s *Server) handleLogContext(w http.ResponseWriter, r *http.Request, user *client.User) {
+	_, src, ok := s.sourceAccess(w, r, user)
+	if !ok {
+		return
+	}
+	var req client.LogContextRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	target := time.UnixMilli(req.Timestamp).UTC()
+	before, at, after := []client.LogEntry{}, []client.LogEntry{}, []client.LogEntry{}
+	rows := slices.Clone(s.data.Logs[src.ID])
+	newestFirst(rows, src.MetaTsField)
+	for _, row := range rows {
+		ts, ok := parseTime(row[src.MetaTsField])
+		if !ok {
+			continue
+		}
+		switch ts.Compare(target) {
+		case -1:
+			if len(before) < req.BeforeLimit {
+				before = append(before, row)
+			}
+		case 0:
+			at = append(at, row)
+		}
+	}
+	// After logs are the closest rows following the target, oldest first.
+	for _, row := range slices.Backward(rows) {
+		if ts, ok := parseTime(row[src.MetaTsField]); ok && ts.After(target) && len(after) < req.AfterLimit {
+			after = append(after, row)
+		}
+	}
+	writeData(w, http.StatusOK, map[string]any{
+		"target_timestamp": req.Timestamp,
+		"before_logs":      before,
+		"target_logs":      at,
+		"after_logs":       after,
+		"stats":            client.LogQueryStats{ExecutionTimeMs: 1, RowsRead: len(rows)},
+	})
+}
+
+func (s *Server) handleLogchefQLQuery(w http.ResponseWriter, r *http.Request, user *client.User) {
+	_, src, ok := s.sourceAccess(w, r, user)
+	if !ok {
+		return
+	}
+	var req client.LogchefQLQueryRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	conds, err := parseLogchefQL(req.Query)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	start, end, err := timeRange(req.StartTime, req.EndTime)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	limit := cmp.Or(req.Limit, 100)
+
+	var rows []client.LogEntry
+	all := s.data.Logs[src.ID]
+	for _, row := range all {
+		if inRange(row, src.MetaTsField, start, end) && matchAll(row, conds) {
+			rows = append(rows, row)
+		}
+	}
+	newestFirst(rows, src.MetaTsField)
+	if len(rows) > limit {
+		rows = rows[:limit]
+	}
+	writeData(w, http.StatusOK, map[string]any{
+		"logs":          nonNil(rows),
+		"columns":       s.schema(src.ID),
+		"stats":         client.LogQueryStats{ExecutionTimeMs: 1, RowsRead: len(all)},
+		"query_id":      s.nextQueryID(),
+		"generated_sql": generateSQL(src, conds, req.StartTime, req.EndTime, limit),
+	})
+}
+
+func (s *Server) handleLogchefQLTranslate(w http.ResponseWriter, r *http.Request, user *client.User) {
+	_, src, ok := s.sourceAccess(w, r, user)
+	if !ok {
+		return
+	}
+	var req client.LogchefQLTranslateRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	conds, err := parseLogchefQL(req.Query)
+	if err != nil {
+		writeData(w, http.StatusOK, map[string]any{"sql": "", "valid": false})
+		return
+	}
+	writeData(w, http.StatusOK, map[string]any{
+		"sql":   generateSQL(src, conds, req.StartTime, req.EndTime, cmp.Or(req.Limit, 100)),
+		"valid": true,
+	})
+}
+
+func (s *Server) handleLogchefQLValidate(w http.ResponseWriter, r *http.Request, user *client.User) {
+	if _, _, ok := s.sourceAccess(w, r, user); !ok {
+		return
+	}
+	var req client.LogchefQLValidateRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	if _, err := parseLogchefQL(req.Query); err != nil {
+		writeData(w, http.StatusOK, map[string]any{"valid": false, "error": err.Error()})
+		return
+	}
+	writeData(w, http.StatusOK, map[string]any{"valid": true})
+}
+
+func (s *Server) handleFieldValues(w http.ResponseWriter, r *http.Request, user *client.User) {
+	_, src, ok := s.sourceAccess(w, r, user)
+	if !ok {
+		return
+	}
+	field := r.PathValue("field")
+	if !slices.ContainsFunc(s.schema(src.ID), func(c client.LogColumn) bool { return c.Name == field }) {
+		writeError(w, http.StatusBadRequest, "unknown field: "+field)
+		return
+	}
+	start, end, err := timeRange(r.URL.Query().Get("start_time"), r.URL.Query().Get("end_time"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	writeData(w, http.StatusOK, s.fieldValues(src, field, start, end, cmp.Or(limit, 10)))
+}
+
+func (s *Server) handleAllFieldValues(w http.ResponseWriter, r *http.Request, user *client.User) {
+	_, src, ok := s.sourceAccess(w, r, user)
+	if !ok {
+		return
+	}
+	start, end, err := timeRange(r.URL.Query().Get("start_time"), r.URL.Query().Get("end_time"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	fields := map[string]any{}
+	for _, c := range s.schema(src.ID) {
+		if strings.HasPrefix(c.Type, "LowCardinality(") {
+			fields[c.Name] = s.fieldValues(src, c.Name, start, end, cmp.Or(limit, 10))
+		}
+	}
+	writeData(w, http.StatusOK, fields)
+}
+
+// fieldValues counts distinct values of field, most frequent first.
+func (s *Server) fieldValues(src *client.Source, field string, start, end time.Time, limit int) map[string]any {
+	counts := map[string]int{}
+	for _, row := range s.data.Logs[src.ID] {
+		if v, ok := row[field]; ok && inRange(row, src.MetaTsField, start, end) {
+			counts[fmt.Sprint(v)]++
+		}
+	}
+	values := []client.FieldValue{}
+	for v, n := range counts {
+		values = append(values, client.FieldValue{Value: v, Count: n})
+	}
+	slices.SortFunc(values, func(a, b client.FieldValue) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.Value, b.Value))
+	})
+	total := len(values)
+	if len(values) > limit {
+		values = values[:limit]
+	}
+	return map[string]any{"values": values, "total": total}
+}
+
+func timeRange(startStr, endStr string) (start, end time.Time, err error) {
+	if startStr != "" {
+		var ok bool
+		if start, ok = parseTime(startStr); !ok {
+			return start, end, fmt.Errorf("invalid start_time: %s", startStr)
+		}
+	}
+	if endStr != "" {
+		var ok bool
+		if end, ok = parseTime(endStr); !ok {
+			return start, end, fmt.Errorf("invalid end_time: %s", endStr)
+		}
+	}
+	return start, end, nil
+}
+
+// inRange reports whether row falls in [start, end]. Zero bounds are open.
+func inRange(row client.LogEntry, tsField string, start, end time.Time) bool {
+	if start.IsZero() && end.IsZero() {
+		return true
+	}
+	ts, ok := parseTime(row[tsField])
+	if !ok {
+		return false
+	}
+	return (start.IsZero() || !ts.Before(start)) && (end.IsZero() || !ts.After(end))
+}
+
+func nonNil(rows []client.LogEntry) []client.LogEntry {
+	if rows == nil {
+		return []client.LogEntry{}
+	}
+	return rows
+}
+
+// condition is a single LogchefQL comparison.
+type condition struct {
+	field string
+	op    string
+	value string
+}
+
+// operators in match order: two-character operators before their prefixes.
+var operators = []string{"!=", "!~", ">=", "<=", "=", "~", ">", "<"}
+
+// parseLogchefQL parses the subset of LogchefQL supported by the fake:
+// comparisons joined with "and", optionally grouped in parentheses. An empty
+// query matches everything.
+func parseLogchefQL(q string) ([]condition, error) {
+	q = strings.TrimSpace(q)
+	if q == "" {
+		return nil, nil
+	}
+	var conds []condition
+	for _, clause := range splitAnd(q) {
+		clause = strings.TrimSpace(clause)
+		if inner, ok := grouped(clause); ok {
+			group, err := parseLogchefQL(inner)
+			if err != nil {
+				return nil, err
+			}
+			conds = append(conds, group...)
+			continue
+		}
+		c, ok := parseCondition(clause)
+		if !ok {
+			return nil, fmt.Errorf("invalid LogchefQL expression: %q", clause)
+		}
+		conds = append(conds, c)
+	}
+	return conds, nil
+}
+
+// splitAnd splits q on the "and" keyword outside quoted strings and
+// parentheses.
+func splitAnd(q string) []string {
+	var parts []string
+	var quote byte
+	depth, last := 0, 0
+	for i := 0; i < len(q); i++ {
+		switch ch := q[i]; {
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			}
+		case ch == '"' || ch == '\'':
+			quote = ch
+		case ch == '(':
+			depth++
+		case ch == ')':
+			depth--
+		case depth == 0 && ch == ' ' && i+5 <= len(q) && strings.EqualFold(q[i:i+5], " and "):
+			parts = append(parts, q[last:i])
+			last = i + 5
+			i += 4
+		}
+	}
+	return append(parts, q[last:])
+}
+
+// grouped returns the inside of clause if the whole clause is wrapped in one
+// pair of parentheses.
+func grouped(clause string) (string, bool) {
+	if !strings.HasPrefix(clause, "(") || !strings.HasSuffix(clause, ")") {
+		return "", false
+	}
+	var quote byte
+	depth := 0
+	for i := 0; i < len(clause)-1; i++ {
+		switch ch := clause[i]; {
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			}
+		case ch == '"' || ch == '\'':
+			quote = ch
+		case ch == '(':
+			depth++
+		case ch == ')':
+			if depth--; depth == 0 {
+				return "", false
+			}
+		}
+	}
+	return clause[1 : len(clause)-1], true
+}
+
+func parseCondition(clause string) (condition, bool) {
+	for i := 0; i < len(clause); i++ {
+		for _, op := range operators {
+			if !strings.HasPrefix(clause[i:], op) {
+				continue
+			}
+			field := strings.TrimSpace(clause[:i])
+			value := strings.TrimSpace(clause[i+len(op):])
+			if field == "" || value == "" || strings.ContainsAny(field, " \"'") {
+				return condition{}, false
+			}
+			if n := len(value); n >= 2 && (value[0] == '"' || value[0] == '\'') {
+				if value[n-1] != value[0] {
+					return condition{}, false
+				}
+				value = value[1 : n-1]
+			}
+			return condition{field: field, op: op, value: value}, true
+		}
+	}
+	return condition{}, false
+}
+
+func matchAll(row client.LogEntry, conds []condition) bool {
+	for _, c := range conds {
+		if !c.match(row) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c condition) match(row client.LogEntry) bool {
+	raw, ok := row[c.field]
+	if !ok {
+		return c.op == "!=" || c.op == "!~"
+	}
+	got := fmt.Sprint(raw)
+	switch c.op {
+	case "=":
+		return got == c.value
+	case "!=":
+		return got != c.value
+	case "~":
+		return strings.Contains(got, c.value)
+	case "!~":
+		return !strings.Contains(got, c.value)
+	}
+	cmpResult := strings.Compare(got, c.value)
+	if a, errA := strconv.ParseFloat(got, 64); errA == nil {
+		if b, errB := strconv.ParseFloat(c.value, 64); errB == nil {
+			cmpResult = cmp.Compare(a, b)
+		}
+	}
+	switch c.op {
+	case ">":
+		return cmpResult > 0
+	case ">=":
+		return cmpResult >= 0
+	case "<":
+		return cmpResult < 0
+	default:
+		return cmpResult <= 0
+	}
+}
+
+// generateSQL renders the ClickHouse query Logchef would run for conds.
+func generateSQL(src *client.Source, conds []condition, start, end string, limit int) string {
+	var where []string
+	if start != "" && end != "" {
+		where = append(where, fmt.Sprintf("`%s` BETWEEN toDateTime('%s') AND toDateTime('%s')", src.MetaTsField, start, end))
+	}
+	for _, c := range conds {
+		switch c.op {
+		case "~":
+			where = append(where, fmt.Sprintf("positionCaseInsensitive(`%s`, '%s') > 0", c.field, c.value))
+		case "!~":
+			where = append(where, fmt.Sprintf("positionCaseInsensitive(`%s`, '%s') = 0", c.field, c.value))
+		default:
+			where = append(where, fmt.Sprintf("`%s` %s '%s'", c.field, c.op, c.value))
+		}
+	}
+	sql := "SELECT * FROM " + tableName(src)
+	if len(where) > 0 {
+		sql += " WHERE " + strings.Join(where, " AND ")
+	}
+	return fmt.Sprintf("%s ORDER BY `%s` DESC LIMIT %d", sql, src.MetaTsField, limit)
+}
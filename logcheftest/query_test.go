@@ -0,0 +1,37 @@
+package logcheftest
+
+import "testing"
+
+func TestParseLogchefQL(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []condition
+		err   bool
+	}{
+		{query: "", want: nil},
+		{query: `level="error"`, want: []condition{{"level", "=", "error"}}},
+		{query: `status>=500 AND message~"and more"`, want: []condition{{"status", ">=", "500"}, {"message", "~", "and more"}}},
+		{query: `service!='api'`, want: []condition{{"service", "!=", "api"}}},
+		{query: `(level="error" and service="api") and timestamp <= "2026-03-01 10:05:00"`, want: []condition{{"level", "=", "error"}, {"service", "=", "api"}, {"timestamp", "<=", "2026-03-01 10:05:00"}}},
+		{query: `(level="(and)")`, want: []condition{{"level", "=", "(and)"}}},
+		{query: `level`, err: true},
+		{query: `(level="error") and (service`, err: true},
+		{query: `level="error`, err: true},
+	}
+	for _, tt := range tests {
+		got, err := parseLogchefQL(tt.query)
+		if (err != nil) != tt.err {
+			t.Errorf("%q: err = %v, want error %v", tt.query, err, tt.err)
+			continue
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%q: got %v, want %v", tt.query, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%q: condition %d = %v, want %v", tt.query, i, got[i], tt.want[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,268 @@
+// Package logformat renders log rows as text for tool results.
+//
+// Rows are maps decoded from Logchef's JSON responses. Besides indented
+// JSON, they can be rendered as newline-delimited JSON, CSV, a Markdown
+// table, or logfmt-style lines that lead with the timestamp, severity,
+// service and message of each log:
+//
+//	text, err := logformat.Render(logformat.Logfmt, rows, []string{"timestamp", "level"})
+package logformat
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Format names an output format.
+type Format string
+
+const (
+	JSON     Format = "json"
+	NDJSON   Format = "ndjson"
+	CSV      Format = "csv"
+	Markdown Format = "markdown"
+	Logfmt   Format = "logfmt"
+)
+
+// Formats lists the supported formats.
+var Formats = []Format{JSON, NDJSON, CSV, Markdown, Logfmt}
+
+// Parse returns the format named s, which is JSON when s is empty.
+func Parse(s string) (Format, error) {
+	if s == "" {
+		return JSON, nil
+	}
+	f := Format(strings.ToLower(strings.TrimSpace(s)))
+	if !slices.Contains(Formats, f) {
+		return "", fmt.Errorf("unknown format %q; use one of json, ndjson, csv, markdown or logfmt", s)
+	}
+	return f, nil
+}
+
+// leadFields are the fields a logfmt line starts with, in order. Each is
+// the first of its names present in the row.
+var leadFields = [][]string{
+	{"timestamp", "ts", "time", "@timestamp", "_timestamp", "event_time"},
+	{"severity_text", "severity", "level", "log_level"},
+	{"service_name", "service", "app"},
+	{"message", "msg", "body"},
+}
+
+// Render renders rows in format f. columns orders the fields of each row:
+// the named columns come first, each followed by the fields flattened from
+// it, then any other fields by name. Columns missing from every row are
+// left out. Nested values are written as compact JSON, except in logfmt,
+// which flattens them into dotted keys.
+func Render[R ~map[string]any](f Format, rows []R, columns []string) (string, error) {
+	switch f {
+	case JSON:
+		b, err := json.MarshalIndent(rows, "", "  ")
+		return string(b), err
+	case NDJSON:
+		var buf bytes.Buffer
+		for _, row := range rows {
+			b, err := json.Marshal(row)
+			if err != nil {
+				return "", err
+			}
+			buf.Write(b)
+			buf.WriteByte('\n')
+		}
+		return buf.String(), nil
+	case CSV:
+		return renderCSV(rows, columns)
+	case Markdown:
+		return renderMarkdown(rows, columns), nil
+	case Logfmt:
+		return renderLogfmt(rows, columns), nil
+	}
+	return "", fmt.Errorf("unknown format %q", f)
+}
+
+func renderCSV[R ~map[string]any](rows []R, columns []string) (string, error) {
+	fields := fieldOrder(rows, columns)
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if len(fields) > 0 {
+		w.Write(fields)
+	}
+	record := make([]string, len(fields))
+	for _, row := range rows {
+		for i, f := range fields {
+			record[i] = text(row[f])
+		}
+		w.Write(record)
+	}
+	w.Flush()
+	return buf.String(), w.Error()
+}
+
+func renderMarkdown[R ~map[string]any](rows []R, columns []string) string {
+	fields := fieldOrder(rows, columns)
+	if len(fields) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	line := func(cells []string) {
+		b.WriteString("|")
+		for _, c := range cells {
+			b.WriteString(" " + c + " |")
+		}
+		b.WriteString("\n")
+	}
+	header := make([]string, len(fields))
+	rule := make([]string, len(fields))
+	for i, f := range fields {
+		header[i], rule[i] = markdownCell(f), "---"
+	}
+	line(header)
+	line(rule)
+	cells := make([]string, len(fields))
+	for _, row := range rows {
+		for i, f := range fields {
+			cells[i] = markdownCell(text(row[f]))
+		}
+		line(cells)
+	}
+	return b.String()
+}
+
+var markdownEscaper = strings.NewReplacer("|", `\|`, "\r\n", "<br>", "\n", "<br>")
+
+func markdownCell(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+func renderLogfmt[R ~map[string]any](rows []R, columns []string) string {
+	flat := make([]map[string]any, len(rows))
+	for i, row := range rows {
+		flat[i] = Flatten(row)
+	}
+	fields := fieldOrder(flat, columns)
+
+	var b strings.Builder
+	for _, row := range flat {
+		var lead []string
+		for _, names := range leadFields {
+			if i := slices.IndexFunc(names, func(n string) bool { _, ok := row[n]; return ok }); i >= 0 {
+				lead = append(lead, names[i])
+			}
+		}
+		writeLogfmt(&b, row, lead, fields)
+	}
+	return b.String()
+}
+
+// writeLogfmt writes one logfmt line for row: the lead fields, then the
+// other fields in order.
+func writeLogfmt(b *strings.Builder, row map[string]any, lead, fields []string) {
+	first := true
+	write := func(k string) {
+		v, ok := row[k]
+		if !ok {
+			return
+		}
+		if !first {
+			b.WriteByte(' ')
+		}
+		first = false
+		b.WriteString(logfmtValue(k))
+		b.WriteByte('=')
+		b.WriteString(logfmtValue(text(v)))
+	}
+	for _, k := range lead {
+		write(k)
+	}
+	for _, k := range fields {
+		if !slices.Contains(lead, k) {
+			write(k)
+		}
+	}
+	b.WriteByte('\n')
+}
+
+// logfmtValue quotes s when it is empty or holds spaces, quotes, equals
+// signs or control characters.
+func logfmtValue(s string) string {
+	if s == "" || strings.ContainsFunc(s, func(r rune) bool {
+		return r <= ' ' || r == '=' || r == '"' || r == '\\' || r == 0x7f
+	}) {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// fieldOrder returns the fields present in rows, ordered by columns.
+func fieldOrder[R ~map[string]any](rows []R, columns []string) []string {
+	present := make(map[string]bool)
+	for _, row := range rows {
+		for k := range row {
+			present[k] = true
+		}
+	}
+	rest := make([]string, 0, len(present))
+	for k := range present {
+		rest = append(rest, k)
+	}
+	slices.Sort(rest)
+
+	fields := make([]string, 0, len(rest))
+	take := func(keep func(string) bool) {
+		rest = slices.DeleteFunc(rest, func(k string) bool {
+			if keep(k) {
+				fields = append(fields, k)
+				return true
+			}
+			return false
+		})
+	}
+	for _, c := range columns {
+		take(func(k string) bool { return k == c })
+		take(func(k string) bool { return strings.HasPrefix(k, c+".") })
+	}
+	return append(fields, rest...)
+}
+
+// text returns v as the text of a cell: strings as they are, numbers
+// without exponents, null as empty and anything else as compact JSON.
+func text(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+// Flatten returns a copy of row with nested maps flattened into keys
+// joined by dots, such as attributes.http.status. Empty maps are kept as
+// they are.
+func Flatten[R ~map[string]any](row R) R {
+	out := make(R, len(row))
+	flattenInto(out, "", row)
+	return out
+}
+
+func flattenInto[R ~map[string]any](dst R, prefix string, m map[string]any) {
+	for k, v := range m {
+		if nested, ok := v.(map[string]any); ok && len(nested) > 0 {
+			flattenInto(dst, prefix+k+".", nested)
+			continue
+		}
+		dst[prefix+k] = v
+	}
+}
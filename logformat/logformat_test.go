@@ -0,0 +1,66 @@
+package logformat
+
+import "testing"
+
+func TestRender(t *testing.T) {
+	rows := []map[string]any{
+		{
+			"timestamp": "2026-03-01 10:03:00", "level": "error", "service": "api",
+			"message": "database connection refused", "status": 500.0,
+			"attributes": map[string]any{"http": map[string]any{"path": "/orders"}},
+		},
+		{"timestamp": "2026-03-01 10:02:00", "level": "warn", "message": "a | b\nc", "status": nil},
+	}
+	columns := []string{"timestamp", "message", "attributes", "missing"}
+	tests := []struct {
+		format Format
+		want   string
+	}{
+		{NDJSON, `{"attributes":{"http":{"path":"/orders"}},"level":"error","message":"database connection refused","service":"api","status":500,"timestamp":"2026-03-01 10:03:00"}
+{"level":"warn","message":"a | b\nc","status":null,"timestamp":"2026-03-01 10:02:00"}
+`},
+		{CSV, `timestamp,message,attributes,level,service,status
+2026-03-01 10:03:00,database connection refused,"{""http"":{""path"":""/orders""}}",error,api,500
+2026-03-01 10:02:00,"a | b
+c",,warn,,
+`},
+		{Markdown, `| timestamp | message | attributes | level | service | status |
+| --- | --- | --- | --- | --- | --- |
+| 2026-03-01 10:03:00 | database connection refused | {"http":{"path":"/orders"}} | error | api | 500 |
+| 2026-03-01 10:02:00 | a \| b<br>c |  | warn |  |  |
+`},
+		{Logfmt, `timestamp="2026-03-01 10:03:00" level=error service=api message="database connection refused" attributes.http.path=/orders status=500
+timestamp="2026-03-01 10:02:00" level=warn message="a | b\nc" status=""
+`},
+	}
+	for _, tt := range tests {
+		got, err := Render(tt.format, rows, columns)
+		if err != nil {
+			t.Errorf("%s: %v", tt.format, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s:\n got %s\nwant %s", tt.format, got, tt.want)
+		}
+	}
+
+	for _, f := range Formats {
+		if f == JSON {
+			continue
+		}
+		if got, err := Render(f, []map[string]any{}, columns); err != nil || got != "" {
+			t.Errorf("%s of no rows = %q, %v", f, got, err)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	for in, want := range map[string]Format{"": JSON, "json": JSON, "NDJSON": NDJSON, " logfmt ": Logfmt, "markdown": Markdown} {
+		if got, err := Parse(in); err != nil || got != want {
+			t.Errorf("Parse(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := Parse("yaml"); err == nil {
+		t.Error("Parse(yaml) succeeded")
+	}
+}
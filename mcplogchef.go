@@ -8,6 +8,7 @@ import (
 	"net/url"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/mark3labs/mcp-go/server"
 
@@ -31,6 +32,21 @@ func urlAndAPIKeyFromEnv() (string, string) {
 	return u, apiKey
 }
 
+// urlAndAPIKeyFromEnvOrConfig returns the Logchef URL and API key from the
+// environment, falling back to the BaseURL and APIKey of the client.Config
+// template (set from the config file) for whichever is unset.
+func urlAndAPIKeyFromEnvOrConfig(ctx context.Context) (string, string) {
+	u, apiKey := urlAndAPIKeyFromEnv()
+	cfg := LogchefClientConfigFromContext(ctx)
+	if u == "" {
+		u = strings.TrimRight(cfg.BaseURL, "/")
+	}
+	if apiKey == "" {
+		apiKey = cfg.APIKey
+	}
+	return u, apiKey
+}
+
 func urlAndAPIKeyFromHeaders(req *http.Request) (string, string) {
 	u := req.Header.Get(logchefURLHeader)
 	apiKey := req.Header.Get(logchefAPIKeyHeader)
@@ -60,10 +76,138 @@ func LogchefDebugFromContext(ctx context.Context) bool {
 	return false
 }
 
+// logchefReadOnlyKey is the context key for the server's read-only mode.
+type logchefReadOnlyKey struct{}
+
+// WithReadOnly marks the context as serving a read-only server. Tools that
+// accept raw SQL reject anything other than a SELECT statement.
+func WithReadOnly(ctx context.Context, readOnly bool) context.Context {
+	return context.WithValue(ctx, logchefReadOnlyKey{}, readOnly)
+}
+
+// ReadOnlyFromContext reports whether the server is in read-only mode.
+func ReadOnlyFromContext(ctx context.Context) bool {
+	readOnly, _ := ctx.Value(logchefReadOnlyKey{}).(bool)
+	return readOnly
+}
+
+// ToolLimit bounds what a single call of a tool may ask of Logchef. Zero
+// fields leave the tool's built-in limits in place.
+type ToolLimit struct {
+	// MaxRows caps the row limit a caller may request.
+	MaxRows int
+	// QueryTimeout is the default ClickHouse query timeout and the longest
+	// timeout a caller may request.
+	QueryTimeout time.Duration
+}
+
+// ReadBudget bounds how many rows a query may read in ClickHouse, as
+// estimated with EXPLAIN ESTIMATE before it runs. Zero fields are unset.
+type ReadBudget struct {
+	// WarnRows adds a warning to queries estimated to read more rows.
+	WarnRows int
+	// MaxRows refuses queries estimated to read more rows.
+	MaxRows int
+}
+
+// Enabled reports whether any limit is set.
+func (b ReadBudget) Enabled() bool { return b.WarnRows > 0 || b.MaxRows > 0 }
+
+type logchefToolLimitKey struct{}
+type logchefTimezoneKey struct{}
+type logchefReadBudgetKey struct{}
+
+// WithToolLimit adds the operator's limits for the current tool call to the context.
+func WithToolLimit(ctx context.Context, limit ToolLimit) context.Context {
+	return context.WithValue(ctx, logchefToolLimitKey{}, limit)
+}
+
+// ToolLimitFromContext extracts the limits for the current tool call. If none
+// are set, it returns the zero ToolLimit.
+func ToolLimitFromContext(ctx context.Context) ToolLimit {
+	limit, _ := ctx.Value(logchefToolLimitKey{}).(ToolLimit)
+	return limit
+}
+
+// WithDefaultTimezone adds the timezone tools use when the caller omits one.
+func WithDefaultTimezone(ctx context.Context, tz string) context.Context {
+	return context.WithValue(ctx, logchefTimezoneKey{}, tz)
+}
+
+// DefaultTimezoneFromContext extracts the default timezone, or "" if none is set.
+func DefaultTimezoneFromContext(ctx context.Context) string {
+	tz, _ := ctx.Value(logchefTimezoneKey{}).(string)
+	return tz
+}
+
+// WithReadBudget adds the operator's read budget to the context.
+func WithReadBudget(ctx context.Context, budget ReadBudget) context.Context {
+	return context.WithValue(ctx, logchefReadBudgetKey{}, budget)
+}
+
+// ReadBudgetFromContext extracts the read budget. If none is set, it returns
+// the zero ReadBudget.
+func ReadBudgetFromContext(ctx context.Context) ReadBudget {
+	budget, _ := ctx.Value(logchefReadBudgetKey{}).(ReadBudget)
+	return budget
+}
+
+// DefaultInstance names the Logchef instance configured by LOGCHEF_URL, the
+// request headers or the config file's logchef section.
+const DefaultInstance = "default"
+
+// Instance is a named Logchef instance from the server configuration.
+type Instance struct {
+	Name        string
+	URL         string
+	APIKey      string // optional; the session's API key is used when empty
+	Description string
+}
+
+type logchefInstancesKey struct{}
+
+// WithInstances adds the named Logchef instances to the context.
+func WithInstances(ctx context.Context, instances []Instance) context.Context {
+	return context.WithValue(ctx, logchefInstancesKey{}, instances)
+}
+
+// InstancesFromContext extracts the named Logchef instances from the context.
+func InstancesFromContext(ctx context.Context) []Instance {
+	instances, _ := ctx.Value(logchefInstancesKey{}).([]Instance)
+	return instances
+}
+
+// LogchefClientForInstance returns a client for the named instance, or the
+// session's client for DefaultInstance. It fails if no instance has that name.
+// Requests admitted by RequireAuth with RequireAPIKeyHeader always use the
+// caller's API key, never the instance's configured one.
+func LogchefClientForInstance(ctx context.Context, name string) (*client.Client, error) {
+	if name == "" || name == DefaultInstance {
+		return LogchefClientFromContext(ctx), nil
+	}
+	instances := InstancesFromContext(ctx)
+	for _, inst := range instances {
+		if inst.Name != name {
+			continue
+		}
+		// With RequireAPIKeyHeader, callers never borrow the operator's keys.
+		apiKey := inst.APIKey
+		if apiKey == "" || headerAPIKeyOnly(ctx) {
+			apiKey = LogchefAPIKeyFromContext(ctx)
+		}
+		return NewLogchefClient(ctx, inst.URL, apiKey), nil
+	}
+	names := []string{DefaultInstance}
+	for _, inst := range instances {
+		names = append(names, inst.Name)
+	}
+	return nil, fmt.Errorf("unknown Logchef instance %q (available: %s)", name, strings.Join(names, ", "))
+}
+
 // ExtractLogchefInfoFromEnv is a StdioContextFunc that extracts Logchef configuration
 // from environment variables and injects the configuration into the context.
 var ExtractLogchefInfoFromEnv server.StdioContextFunc = func(ctx context.Context) context.Context {
-	u, apiKey := urlAndAPIKeyFromEnv()
+	u, apiKey := urlAndAPIKeyFromEnvOrConfig(ctx)
 	if u == "" {
 		u = defaultLogchefURL
 	}
@@ -84,14 +228,14 @@ type httpContextFunc func(ctx context.Context, req *http.Request) context.Contex
 // from request headers and injects the configuration into the context.
 var ExtractLogchefInfoFromHeaders httpContextFunc = func(ctx context.Context, req *http.Request) context.Context {
 	u, apiKey := urlAndAPIKeyFromHeaders(req)
-	uEnv, apiKeyEnv := urlAndAPIKeyFromEnv()
+	uEnv, apiKeyEnv := urlAndAPIKeyFromEnvOrConfig(ctx)
 	if u == "" {
 		u = uEnv
 	}
 	if u == "" {
 		u = defaultLogchefURL
 	}
-	if apiKey == "" {
+	if apiKey == "" && !headerAPIKeyOnly(ctx) {
 		apiKey = apiKeyEnv
 	}
 	return WithLogchefURL(WithLogchefAPIKey(ctx, apiKey), u)
@@ -125,6 +269,26 @@ func LogchefAPIKeyFromContext(ctx context.Context) string {
 
 type logchefClientKey struct{}
 
+// logchefClientConfigKey is the context key for the server-wide client.Config
+// template used by NewLogchefClient.
+type logchefClientConfigKey struct{}
+
+// WithLogchefClientConfig adds a client.Config template to the context. Clients
+// created by NewLogchefClient start from this template and then override the
+// URL, API key and debug flag for the current session.
+func WithLogchefClientConfig(ctx context.Context, cfg client.Config) context.Context {
+	return context.WithValue(ctx, logchefClientConfigKey{}, cfg)
+}
+
+// LogchefClientConfigFromContext extracts the client.Config template from the
+// context. If none is set, it returns the zero Config, which uses client defaults.
+func LogchefClientConfigFromContext(ctx context.Context) client.Config {
+	if cfg, ok := ctx.Value(logchefClientConfigKey{}).(client.Config); ok {
+		return cfg
+	}
+	return client.Config{}
+}
+
 // NewLogchefClient creates a Logchef client with the provided URL and API key.
 func NewLogchefClient(ctx context.Context, logchefURL, apiKey string) *client.Client {
 	if logchefURL == "" {
@@ -137,21 +301,21 @@ func NewLogchefClient(ctx context.Context, logchefURL, apiKey string) *client.Cl
 	}
 
 	slog.Debug("Creating Logchef client", "url", parsedURL.Redacted(), "api_key_set", apiKey != "")
-	return client.New(client.Config{
-		BaseURL: logchefURL,
-		APIKey:  apiKey,
-	})
+	cfg := LogchefClientConfigFromContext(ctx)
+	cfg.BaseURL = logchefURL
+	cfg.APIKey = apiKey
+	cfg.Debug = LogchefDebugFromContext(ctx)
+	return client.New(cfg)
 }
 
 // ExtractLogchefClientFromEnv is a StdioContextFunc that extracts Logchef configuration
 // from environment variables and injects a configured client into the context.
 var ExtractLogchefClientFromEnv server.StdioContextFunc = func(ctx context.Context) context.Context {
-	// Extract transport config from env vars
-	logchefURL, ok := os.LookupEnv(logchefURLEnvVar)
-	if !ok {
+	// Extract transport config from env vars, then the config file.
+	logchefURL, apiKey := urlAndAPIKeyFromEnvOrConfig(ctx)
+	if logchefURL == "" {
 		logchefURL = defaultLogchefURL
 	}
-	apiKey := os.Getenv(logchefAPIEnvVar)
 
 	logchefClient := NewLogchefClient(ctx, logchefURL, apiKey)
 	return context.WithValue(ctx, logchefClientKey{}, logchefClient)
@@ -162,14 +326,22 @@ var ExtractLogchefClientFromEnv server.StdioContextFunc = func(ctx context.Conte
 var ExtractLogchefClientFromHeaders httpContextFunc = func(ctx context.Context, req *http.Request) context.Context {
 	// Extract transport config from request headers, and set it on the context.
 	u, apiKey := urlAndAPIKeyFromHeaders(req)
-	uEnv, apiKeyEnv := urlAndAPIKeyFromEnv()
+	if u != "" {
+		// Callers choose where their credentials go; only allowlisted URLs
+		// may receive them.
+		if err := URLPolicyFromContext(ctx).Check(u); err != nil {
+			slog.Warn("Rejected X-Logchef-URL header", "remote_addr", req.RemoteAddr, "error", err)
+			return withLogchefClientError(ctx, err)
+		}
+	}
+	uEnv, apiKeyEnv := urlAndAPIKeyFromEnvOrConfig(ctx)
 	if u == "" {
 		u = uEnv
 	}
 	if u == "" {
 		u = defaultLogchefURL
 	}
-	if apiKey == "" {
+	if apiKey == "" && !headerAPIKeyOnly(ctx) {
 		apiKey = apiKeyEnv
 	}
 
@@ -224,35 +396,39 @@ func ComposeHTTPContextFuncs(funcs ...httpContextFunc) server.HTTPContextFunc {
 }
 
 // ComposedStdioContextFunc returns a StdioContextFunc that comprises all predefined StdioContextFuncs,
-// as well as the Logchef debug flag.
-func ComposedStdioContextFunc(debug bool) server.StdioContextFunc {
+// as well as the Logchef debug flag and client.Config template.
+func ComposedStdioContextFunc(debug bool, cfg client.Config) server.StdioContextFunc {
 	return ComposeStdioContextFuncs(
 		func(ctx context.Context) context.Context {
-			return WithLogchefDebug(ctx, debug)
+			return WithLogchefClientConfig(WithLogchefDebug(ctx, debug), cfg)
 		},
 		ExtractLogchefInfoFromEnv,
 		ExtractLogchefClientFromEnv,
 	)
 }
 
-// ComposedSSEContextFunc is a SSEContextFunc that comprises all predefined SSEContextFuncs.
-func ComposedSSEContextFunc(debug bool) server.SSEContextFunc {
+// ComposedSSEContextFunc is a SSEContextFunc that comprises all predefined SSEContextFuncs,
+// as well as the Logchef debug flag, client.Config template and the policy for
+// header-supplied Logchef URLs.
+func ComposedSSEContextFunc(debug bool, cfg client.Config, policy URLPolicy) server.SSEContextFunc {
 	return ComposeSSEContextFuncs(
 		func(ctx context.Context, req *http.Request) context.Context {
-			return WithLogchefDebug(ctx, debug)
+			return WithURLPolicy(WithLogchefClientConfig(WithLogchefDebug(ctx, debug), cfg), policy)
 		},
 		ExtractLogchefInfoFromHeaders,
 		ExtractLogchefClientFromHeaders,
 	)
 }
 
-// ComposedHTTPContextFunc is a HTTPContextFunc that comprises all predefined HTTPContextFuncs.
-func ComposedHTTPContextFunc(debug bool) server.HTTPContextFunc {
+// ComposedHTTPContextFunc is a HTTPContextFunc that comprises all predefined HTTPContextFuncs,
+// as well as the Logchef debug flag, client.Config template and the policy for
+// header-supplied Logchef URLs.
+func ComposedHTTPContextFunc(debug bool, cfg client.Config, policy URLPolicy) server.HTTPContextFunc {
 	return ComposeHTTPContextFuncs(
 		func(ctx context.Context, req *http.Request) context.Context {
-			return WithLogchefDebug(ctx, debug)
+			return WithURLPolicy(WithLogchefClientConfig(WithLogchefDebug(ctx, debug), cfg), policy)
 		},
 		ExtractLogchefInfoFromHeaders,
 		ExtractLogchefClientFromHeaders,
 	)
-}
\ No newline at end of file
+}
@@ -23,16 +23,19 @@ type GetTeamParams struct {
 type CreateTeamParams struct {
 	Name        string `json:"name" jsonschema:"Name of the team"`
 	Description string `json:"description,omitempty" jsonschema:"Optional description of the team"`
+	DryRun      bool   `json:"dry_run,omitempty" jsonschema:"Validate the change and return the would-be result with a diff, without applying it"`
 }
 
 type UpdateTeamParams struct {
 	TeamID      int     `json:"team_id" jsonschema:"The ID of the team to update"`
 	Name        *string `json:"name,omitempty" jsonschema:"New name for the team"`
 	Description *string `json:"description,omitempty" jsonschema:"New description for the team"`
+	DryRun      bool    `json:"dry_run,omitempty" jsonschema:"Validate the change and return the would-be result with a diff, without applying it"`
 }
 
 type DeleteTeamParams struct {
-	TeamID int `json:"team_id" jsonschema:"The ID of the team to delete"`
+	TeamID       int    `json:"team_id" jsonschema:"The ID of the team to delete"`
+	ConfirmToken string `json:"confirm_token,omitempty" jsonschema:"Token from a previous call, passed back only after the user confirmed the impact it described"`
 }
 
 type ListTeamMembersParams struct {
@@ -43,6 +46,7 @@ type AddTeamMemberParams struct {
 	TeamID int    `json:"team_id" jsonschema:"The ID of the team to add the member to"`
 	UserID int    `json:"user_id" jsonschema:"The ID of the user to add to the team"`
 	Role   string `json:"role" jsonschema:"Role to assign: owner admin editor or member"`
+	DryRun bool   `json:"dry_run,omitempty" jsonschema:"Validate the change and return the would-be result with a diff, without applying it"`
 }
 
 type RemoveTeamMemberParams struct {
@@ -51,8 +55,9 @@ type RemoveTeamMemberParams struct {
 }
 
 type LinkSourceToTeamParams struct {
-	TeamID   int `json:"team_id" jsonschema:"The ID of the team to link the source to"`
-	SourceID int `json:"source_id" jsonschema:"The ID of the source to link to the team"`
+	TeamID   int  `json:"team_id" jsonschema:"The ID of the team to link the source to"`
+	SourceID int  `json:"source_id" jsonschema:"The ID of the source to link to the team"`
+	DryRun   bool `json:"dry_run,omitempty" jsonschema:"Validate the change and return the would-be result with a diff, without applying it"`
 }
 
 type UnlinkSourceFromTeamParams struct {
@@ -71,6 +76,7 @@ type CreateUserParams struct {
 	FullName string `json:"full_name" jsonschema:"Full name of the user"`
 	Role     string `json:"role" jsonschema:"Role of the user: admin or member"`
 	Status   string `json:"status" jsonschema:"Status of the user: active or inactive"`
+	DryRun   bool   `json:"dry_run,omitempty" jsonschema:"Validate the change and return the would-be result with a diff, without applying it"`
 }
 
 type UpdateUserParams struct {
@@ -79,10 +85,12 @@ type UpdateUserParams struct {
 	FullName *string `json:"full_name,omitempty" jsonschema:"New full name"`
 	Role     *string `json:"role,omitempty" jsonschema:"New role: admin or member"`
 	Status   *string `json:"status,omitempty" jsonschema:"New status: active or inactive"`
+	DryRun   bool    `json:"dry_run,omitempty" jsonschema:"Validate the change and return the would-be result with a diff, without applying it"`
 }
 
 type DeleteUserParams struct {
-	UserID int `json:"user_id" jsonschema:"The ID of the user to delete"`
+	UserID       int    `json:"user_id" jsonschema:"The ID of the user to delete"`
+	ConfirmToken string `json:"confirm_token,omitempty" jsonschema:"Token from a previous call, passed back only after the user confirmed the impact it described"`
 }
 
 type ListAPITokensParams struct{}
@@ -93,7 +101,8 @@ type CreateAPITokenParams struct {
 }
 
 type DeleteAPITokenParams struct {
-	TokenID int `json:"token_id" jsonschema:"The ID of the API token to delete"`
+	TokenID      int    `json:"token_id" jsonschema:"The ID of the API token to delete"`
+	ConfirmToken string `json:"confirm_token,omitempty" jsonschema:"Token from a previous call, passed back only after the user confirmed the impact it described"`
 }
 
 type ListAllSourcesParams struct{}
@@ -109,6 +118,7 @@ type CreateSourceParams struct {
 	MetaSeverityField string                   `json:"_meta_severity_field,omitempty" jsonschema:"Optional severity field name"`
 	TTLDays           int                      `json:"ttl_days" jsonschema:"Time-to-live in days for log data"`
 	Schema            []map[string]interface{} `json:"schema,omitempty" jsonschema:"Optional table schema for auto-creation"`
+	DryRun            bool                     `json:"dry_run,omitempty" jsonschema:"Validate the change and return the would-be result with a diff, without applying it"`
 }
 
 type ValidateSourceConnectionParams struct {
@@ -120,7 +130,8 @@ type ValidateSourceConnectionParams struct {
 }
 
 type DeleteSourceParams struct {
-	SourceID int `json:"source_id" jsonschema:"The ID of the source to delete"`
+	SourceID     int    `json:"source_id" jsonschema:"The ID of the source to delete"`
+	ConfirmToken string `json:"confirm_token,omitempty" jsonschema:"Token from a previous call, passed back only after the user confirmed the impact it described"`
 }
 
 type GetAdminSourceStatsParams struct {
@@ -182,7 +193,7 @@ type ValidationResult struct {
 func checkAdminRole(ctx context.Context, c *client.Client) error {
 	profile, err := c.GetProfile(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to get user profile: %w", err)
+		return toolError("failed to get user profile", err)
 	}
 	if profile.Data.User.Role != "admin" {
 		return fmt.Errorf("access denied: admin role required")
@@ -233,7 +244,7 @@ func handleListAllTeams(ctx context.Context, request mcp.CallToolRequest, args L
 	}
 	teams, err := c.ListAllTeams(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("list all teams: %w", err)
+		return nil, toolError("list all teams", err)
 	}
 	result := make([]AdminTeamResult, len(teams.Data))
 	for i, t := range teams.Data {
@@ -249,50 +260,66 @@ func handleGetTeam(ctx context.Context, request mcp.CallToolRequest, args GetTea
 	}
 	team, err := c.GetTeamByID(ctx, args.TeamID)
 	if err != nil {
-		return AdminTeamResult{}, fmt.Errorf("get team: %w", err)
+		return AdminTeamResult{}, toolError("get team", err)
 	}
 	return teamToAdminResult(team.Data), nil
 }
 
-func handleCreateTeam(ctx context.Context, request mcp.CallToolRequest, args CreateTeamParams) (AdminTeamResult, error) {
+func handleCreateTeam(ctx context.Context, request mcp.CallToolRequest, args CreateTeamParams) (TeamChangeResult, error) {
 	c := mcplogchef.LogchefClientFromContext(ctx)
 	if c == nil {
-		return AdminTeamResult{}, fmt.Errorf("logchef client not configured")
+		return TeamChangeResult{}, fmt.Errorf("logchef client not configured")
 	}
 	if err := checkAdminRole(ctx, c); err != nil {
-		return AdminTeamResult{}, err
+		return TeamChangeResult{}, err
+	}
+	if args.DryRun {
+		return planCreateTeam(ctx, c, args)
 	}
 	team, err := c.CreateTeam(ctx, client.TeamRequest{Name: args.Name, Description: args.Description})
 	if err != nil {
-		return AdminTeamResult{}, fmt.Errorf("create team: %w", err)
+		return TeamChangeResult{}, toolError("create team", err)
 	}
-	return teamToAdminResult(team.Data), nil
+	c.InvalidateCache()
+	return TeamChangeResult{AdminTeamResult: teamToAdminResult(team.Data)}, nil
 }
 
-func handleUpdateTeam(ctx context.Context, request mcp.CallToolRequest, args UpdateTeamParams) (AdminTeamResult, error) {
+func handleUpdateTeam(ctx context.Context, request mcp.CallToolRequest, args UpdateTeamParams) (TeamChangeResult, error) {
 	c := mcplogchef.LogchefClientFromContext(ctx)
 	if c == nil {
-		return AdminTeamResult{}, fmt.Errorf("logchef client not configured")
+		return TeamChangeResult{}, fmt.Errorf("logchef client not configured")
+	}
+	if args.DryRun {
+		return planUpdateTeam(ctx, c, args)
 	}
 	team, err := c.UpdateTeam(ctx, args.TeamID, client.TeamUpdateRequest{Name: args.Name, Description: args.Description})
 	if err != nil {
-		return AdminTeamResult{}, fmt.Errorf("update team: %w", err)
+		return TeamChangeResult{}, toolError("update team", err)
 	}
-	return teamToAdminResult(team.Data), nil
+	c.InvalidateCache()
+	return TeamChangeResult{AdminTeamResult: teamToAdminResult(team.Data)}, nil
 }
 
-func handleDeleteTeam(ctx context.Context, request mcp.CallToolRequest, args DeleteTeamParams) (SuccessResult, error) {
+func handleDeleteTeam(ctx context.Context, request mcp.CallToolRequest, args DeleteTeamParams) (DeleteResult, error) {
 	c := mcplogchef.LogchefClientFromContext(ctx)
 	if c == nil {
-		return SuccessResult{}, fmt.Errorf("logchef client not configured")
+		return DeleteResult{}, fmt.Errorf("logchef client not configured")
 	}
 	if err := checkAdminRole(ctx, c); err != nil {
-		return SuccessResult{}, err
+		return DeleteResult{}, err
+	}
+	impact, err := describeTeamDeletion(ctx, c, args.TeamID)
+	if err != nil {
+		return DeleteResult{}, err
+	}
+	if ok, pending, err := confirmDeletion(ctx, c, "delete_team", args.TeamID, impact, "", args.ConfirmToken); !ok {
+		return pending, err
 	}
 	if err := c.DeleteTeam(ctx, args.TeamID); err != nil {
-		return SuccessResult{}, fmt.Errorf("delete team: %w", err)
+		return DeleteResult{}, toolError("delete team", err)
 	}
-	return SuccessResult{Success: true, Message: "Team deleted successfully"}, nil
+	c.InvalidateCache()
+	return DeleteResult{Success: true, Message: "Team deleted successfully"}, nil
 }
 
 func handleListTeamMembers(ctx context.Context, request mcp.CallToolRequest, args ListTeamMembersParams) ([]TeamMemberResult, error) {
@@ -302,7 +329,7 @@ func handleListTeamMembers(ctx context.Context, request mcp.CallToolRequest, arg
 	}
 	members, err := c.ListTeamMembers(ctx, args.TeamID)
 	if err != nil {
-		return nil, fmt.Errorf("list team members: %w", err)
+		return nil, toolError("list team members", err)
 	}
 	result := make([]TeamMemberResult, len(members.Data))
 	for i, m := range members.Data {
@@ -311,15 +338,19 @@ func handleListTeamMembers(ctx context.Context, request mcp.CallToolRequest, arg
 	return result, nil
 }
 
-func handleAddTeamMember(ctx context.Context, request mcp.CallToolRequest, args AddTeamMemberParams) (SuccessResult, error) {
+func handleAddTeamMember(ctx context.Context, request mcp.CallToolRequest, args AddTeamMemberParams) (ChangeResult, error) {
 	c := mcplogchef.LogchefClientFromContext(ctx)
 	if c == nil {
-		return SuccessResult{}, fmt.Errorf("logchef client not configured")
+		return ChangeResult{}, fmt.Errorf("logchef client not configured")
+	}
+	if args.DryRun {
+		return planAddTeamMember(ctx, c, args)
 	}
 	if err := c.AddTeamMember(ctx, args.TeamID, client.TeamMemberRequest{UserID: args.UserID, Role: args.Role}); err != nil {
-		return SuccessResult{}, fmt.Errorf("add team member: %w", err)
+		return ChangeResult{}, toolError("add team member", err)
 	}
-	return SuccessResult{Success: true, Message: "Team member added successfully"}, nil
+	c.InvalidateCache()
+	return ChangeResult{SuccessResult: SuccessResult{Success: true, Message: "Team member added successfully"}}, nil
 }
 
 func handleRemoveTeamMember(ctx context.Context, request mcp.CallToolRequest, args RemoveTeamMemberParams) (SuccessResult, error) {
@@ -328,20 +359,25 @@ func handleRemoveTeamMember(ctx context.Context, request mcp.CallToolRequest, ar
 		return SuccessResult{}, fmt.Errorf("logchef client not configured")
 	}
 	if err := c.RemoveTeamMember(ctx, args.TeamID, args.UserID); err != nil {
-		return SuccessResult{}, fmt.Errorf("remove team member: %w", err)
+		return SuccessResult{}, toolError("remove team member", err)
 	}
+	c.InvalidateCache()
 	return SuccessResult{Success: true, Message: "Team member removed successfully"}, nil
 }
 
-func handleLinkSourceToTeam(ctx context.Context, request mcp.CallToolRequest, args LinkSourceToTeamParams) (SuccessResult, error) {
+func handleLinkSourceToTeam(ctx context.Context, request mcp.CallToolRequest, args LinkSourceToTeamParams) (ChangeResult, error) {
 	c := mcplogchef.LogchefClientFromContext(ctx)
 	if c == nil {
-		return SuccessResult{}, fmt.Errorf("logchef client not configured")
+		return ChangeResult{}, fmt.Errorf("logchef client not configured")
+	}
+	if args.DryRun {
+		return planLinkSourceToTeam(ctx, c, args)
 	}
 	if err := c.LinkSourceToTeam(ctx, args.TeamID, client.TeamSourceRequest{SourceID: args.SourceID}); err != nil {
-		return SuccessResult{}, fmt.Errorf("link source to team: %w", err)
+		return ChangeResult{}, toolError("link source to team", err)
 	}
-	return SuccessResult{Success: true, Message: "Source linked to team successfully"}, nil
+	c.InvalidateCache()
+	return ChangeResult{SuccessResult: SuccessResult{Success: true, Message: "Source linked to team successfully"}}, nil
 }
 
 func handleUnlinkSourceFromTeam(ctx context.Context, request mcp.CallToolRequest, args UnlinkSourceFromTeamParams) (SuccessResult, error) {
@@ -350,8 +386,9 @@ func handleUnlinkSourceFromTeam(ctx context.Context, request mcp.CallToolRequest
 		return SuccessResult{}, fmt.Errorf("logchef client not configured")
 	}
 	if err := c.UnlinkSourceFromTeam(ctx, args.TeamID, args.SourceID); err != nil {
-		return SuccessResult{}, fmt.Errorf("unlink source from team: %w", err)
+		return SuccessResult{}, toolError("unlink source from team", err)
 	}
+	c.InvalidateCache()
 	return SuccessResult{Success: true, Message: "Source unlinked from team successfully"}, nil
 }
 
@@ -367,7 +404,7 @@ func handleListAllUsers(ctx context.Context, request mcp.CallToolRequest, args L
 	}
 	users, err := c.ListAllUsers(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("list all users: %w", err)
+		return nil, toolError("list all users", err)
 	}
 	result := make([]AdminUserResult, len(users.Data))
 	for i, u := range users.Data {
@@ -386,57 +423,73 @@ func handleGetUser(ctx context.Context, request mcp.CallToolRequest, args GetUse
 	}
 	user, err := c.GetUserByID(ctx, args.UserID)
 	if err != nil {
-		return AdminUserResult{}, fmt.Errorf("get user: %w", err)
+		return AdminUserResult{}, toolError("get user", err)
 	}
 	return userToResult(user.Data), nil
 }
 
-func handleCreateUser(ctx context.Context, request mcp.CallToolRequest, args CreateUserParams) (AdminUserResult, error) {
+func handleCreateUser(ctx context.Context, request mcp.CallToolRequest, args CreateUserParams) (UserChangeResult, error) {
 	c := mcplogchef.LogchefClientFromContext(ctx)
 	if c == nil {
-		return AdminUserResult{}, fmt.Errorf("logchef client not configured")
+		return UserChangeResult{}, fmt.Errorf("logchef client not configured")
 	}
 	if err := checkAdminRole(ctx, c); err != nil {
-		return AdminUserResult{}, err
+		return UserChangeResult{}, err
+	}
+	if args.DryRun {
+		return planCreateUser(ctx, c, args)
 	}
 	user, err := c.CreateUser(ctx, client.UserRequest{
 		Email: args.Email, FullName: args.FullName, Role: args.Role, Status: args.Status,
 	})
 	if err != nil {
-		return AdminUserResult{}, fmt.Errorf("create user: %w", err)
+		return UserChangeResult{}, toolError("create user", err)
 	}
-	return userToResult(user.Data), nil
+	c.InvalidateCache()
+	return UserChangeResult{AdminUserResult: userToResult(user.Data)}, nil
 }
 
-func handleUpdateUser(ctx context.Context, request mcp.CallToolRequest, args UpdateUserParams) (AdminUserResult, error) {
+func handleUpdateUser(ctx context.Context, request mcp.CallToolRequest, args UpdateUserParams) (UserChangeResult, error) {
 	c := mcplogchef.LogchefClientFromContext(ctx)
 	if c == nil {
-		return AdminUserResult{}, fmt.Errorf("logchef client not configured")
+		return UserChangeResult{}, fmt.Errorf("logchef client not configured")
 	}
 	if err := checkAdminRole(ctx, c); err != nil {
-		return AdminUserResult{}, err
+		return UserChangeResult{}, err
+	}
+	if args.DryRun {
+		return planUpdateUser(ctx, c, args)
 	}
 	user, err := c.UpdateUser(ctx, args.UserID, client.UserUpdateRequest{
 		Email: args.Email, FullName: args.FullName, Role: args.Role, Status: args.Status,
 	})
 	if err != nil {
-		return AdminUserResult{}, fmt.Errorf("update user: %w", err)
+		return UserChangeResult{}, toolError("update user", err)
 	}
-	return userToResult(user.Data), nil
+	c.InvalidateCache()
+	return UserChangeResult{AdminUserResult: userToResult(user.Data)}, nil
 }
 
-func handleDeleteUser(ctx context.Context, request mcp.CallToolRequest, args DeleteUserParams) (SuccessResult, error) {
+func handleDeleteUser(ctx context.Context, request mcp.CallToolRequest, args DeleteUserParams) (DeleteResult, error) {
 	c := mcplogchef.LogchefClientFromContext(ctx)
 	if c == nil {
-		return SuccessResult{}, fmt.Errorf("logchef client not configured")
+		return DeleteResult{}, fmt.Errorf("logchef client not configured")
 	}
 	if err := checkAdminRole(ctx, c); err != nil {
-		return SuccessResult{}, err
+		return DeleteResult{}, err
+	}
+	impact, err := describeUserDeletion(ctx, c, args.UserID)
+	if err != nil {
+		return DeleteResult{}, err
+	}
+	if ok, pending, err := confirmDeletion(ctx, c, "delete_user", args.UserID, impact, "", args.ConfirmToken); !ok {
+		return pending, err
 	}
 	if err := c.DeleteUser(ctx, args.UserID); err != nil {
-		return SuccessResult{}, fmt.Errorf("delete user: %w", err)
+		return DeleteResult{}, toolError("delete user", err)
 	}
-	return SuccessResult{Success: true, Message: "User deleted successfully"}, nil
+	c.InvalidateCache()
+	return DeleteResult{Success: true, Message: "User deleted successfully"}, nil
 }
 
 // --- API Token handlers ---
@@ -448,7 +501,7 @@ func handleListAPITokens(ctx context.Context, request mcp.CallToolRequest, args
 	}
 	tokens, err := c.ListAPITokens(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("list API tokens: %w", err)
+		return nil, toolError("list API tokens", err)
 	}
 	result := make([]APITokenResult, len(tokens.Data))
 	for i, t := range tokens.Data {
@@ -464,7 +517,7 @@ func handleCreateAPIToken(ctx context.Context, request mcp.CallToolRequest, args
 	}
 	token, err := c.CreateAPIToken(ctx, client.APITokenRequest{Name: args.Name, ExpiresAt: args.ExpiresAt})
 	if err != nil {
-		return APITokenCreateResult{}, fmt.Errorf("create API token: %w", err)
+		return APITokenCreateResult{}, toolError("create API token", err)
 	}
 	return APITokenCreateResult{
 		Token: token.Data.Token,
@@ -472,15 +525,23 @@ func handleCreateAPIToken(ctx context.Context, request mcp.CallToolRequest, args
 	}, nil
 }
 
-func handleDeleteAPIToken(ctx context.Context, request mcp.CallToolRequest, args DeleteAPITokenParams) (SuccessResult, error) {
+func handleDeleteAPIToken(ctx context.Context, request mcp.CallToolRequest, args DeleteAPITokenParams) (DeleteResult, error) {
 	c := mcplogchef.LogchefClientFromContext(ctx)
 	if c == nil {
-		return SuccessResult{}, fmt.Errorf("logchef client not configured")
+		return DeleteResult{}, fmt.Errorf("logchef client not configured")
+	}
+	impact, status, err := describeAPITokenDeletion(ctx, c, args.TokenID)
+	if err != nil {
+		return DeleteResult{}, err
+	}
+	if ok, pending, err := confirmDeletion(ctx, c, "delete_api_token", args.TokenID, impact, status, args.ConfirmToken); !ok {
+		return pending, err
 	}
 	if err := c.DeleteAPIToken(ctx, args.TokenID); err != nil {
-		return SuccessResult{}, fmt.Errorf("delete API token: %w", err)
+		return DeleteResult{}, toolError("delete API token", err)
 	}
-	return SuccessResult{Success: true, Message: "API token deleted successfully"}, nil
+	c.InvalidateCache()
+	return DeleteResult{Success: true, Message: "API token deleted successfully"}, nil
 }
 
 // --- Admin source handlers ---
@@ -495,7 +556,7 @@ func handleListAllSources(ctx context.Context, request mcp.CallToolRequest, args
 	}
 	sources, err := c.ListAllSources(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("list all sources: %w", err)
+		return nil, toolError("list all sources", err)
 	}
 	result := make([]SourceResult, len(sources.Data))
 	for i, s := range sources.Data {
@@ -508,13 +569,16 @@ func handleListAllSources(ctx context.Context, request mcp.CallToolRequest, args
 	return result, nil
 }
 
-func handleCreateSource(ctx context.Context, request mcp.CallToolRequest, args CreateSourceParams) (SourceResult, error) {
+func handleCreateSource(ctx context.Context, request mcp.CallToolRequest, args CreateSourceParams) (SourceChangeResult, error) {
 	c := mcplogchef.LogchefClientFromContext(ctx)
 	if c == nil {
-		return SourceResult{}, fmt.Errorf("logchef client not configured")
+		return SourceChangeResult{}, fmt.Errorf("logchef client not configured")
 	}
 	if err := checkAdminRole(ctx, c); err != nil {
-		return SourceResult{}, err
+		return SourceChangeResult{}, err
+	}
+	if args.DryRun {
+		return planCreateSource(ctx, c, args)
 	}
 
 	var schema []client.LogColumn
@@ -536,14 +600,15 @@ func handleCreateSource(ctx context.Context, request mcp.CallToolRequest, args C
 		TTLDays: args.TTLDays, Schema: schema,
 	})
 	if err != nil {
-		return SourceResult{}, fmt.Errorf("create source: %w", err)
+		return SourceChangeResult{}, toolError("create source", err)
 	}
 	s := source.Data
-	return SourceResult{
+	c.InvalidateCache()
+	return SourceChangeResult{SourceResult: SourceResult{
 		ID: s.ID, Name: s.Name, Description: s.Description,
 		Connection: ConnectionResult{Host: s.Connection.Host, Database: s.Connection.Database, TableName: s.Connection.TableName},
 		TsField: s.MetaTsField, IsConnected: s.IsConnected, TTLDays: s.TTLDays, CreatedAt: s.CreatedAt,
-	}, nil
+	}}, nil
 }
 
 func handleValidateSourceConnection(ctx context.Context, request mcp.CallToolRequest, args ValidateSourceConnectionParams) (ValidationResult, error) {
@@ -559,7 +624,7 @@ func handleValidateSourceConnection(ctx context.Context, request mcp.CallToolReq
 		TimestampField: args.TimestampField, SeverityField: args.SeverityField,
 	})
 	if err != nil {
-		return ValidationResult{}, fmt.Errorf("validate source connection: %w", err)
+		return ValidationResult{}, toolError("validate source connection", err)
 	}
 	return ValidationResult{
 		IsValid: v.Data.IsValid, Message: v.Data.Message,
@@ -568,18 +633,26 @@ func handleValidateSourceConnection(ctx context.Context, request mcp.CallToolReq
 	}, nil
 }
 
-func handleDeleteSource(ctx context.Context, request mcp.CallToolRequest, args DeleteSourceParams) (SuccessResult, error) {
+func handleDeleteSource(ctx context.Context, request mcp.CallToolRequest, args DeleteSourceParams) (DeleteResult, error) {
 	c := mcplogchef.LogchefClientFromContext(ctx)
 	if c == nil {
-		return SuccessResult{}, fmt.Errorf("logchef client not configured")
+		return DeleteResult{}, fmt.Errorf("logchef client not configured")
 	}
 	if err := checkAdminRole(ctx, c); err != nil {
-		return SuccessResult{}, err
+		return DeleteResult{}, err
+	}
+	impact, err := describeSourceDeletion(ctx, c, args.SourceID)
+	if err != nil {
+		return DeleteResult{}, err
+	}
+	if ok, pending, err := confirmDeletion(ctx, c, "delete_source", args.SourceID, impact, "", args.ConfirmToken); !ok {
+		return pending, err
 	}
 	if err := c.DeleteSource(ctx, args.SourceID); err != nil {
-		return SuccessResult{}, fmt.Errorf("delete source: %w", err)
+		return DeleteResult{}, toolError("delete source", err)
 	}
-	return SuccessResult{Success: true, Message: "Source deleted successfully"}, nil
+	c.InvalidateCache()
+	return DeleteResult{Success: true, Message: "Source deleted successfully"}, nil
 }
 
 func handleGetAdminSourceStats(ctx context.Context, request mcp.CallToolRequest, args GetAdminSourceStatsParams) (*mcp.CallToolResult, error) {
@@ -592,7 +665,7 @@ func handleGetAdminSourceStats(ctx context.Context, request mcp.CallToolRequest,
 	}
 	stats, err := c.GetAdminSourceStats(ctx, args.SourceID)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("get admin source stats: %v", err)), nil
+		return mcp.NewToolResultError(toolError("get admin source stats", err).Error()), nil
 	}
 	// Stats have nested/dynamic structure — use typed handler with JSON output
 	out, _ := json.MarshalIndent(stats.Data, "", "  ")
@@ -620,7 +693,7 @@ func AddAdminTools(s *server.MCPServer) {
 	s.AddTool(mcp.NewTool("create_team",
 		mcp.WithDescription("Create a new team (admin only). Provide a team name and optional description."),
 		mcp.WithInputSchema[CreateTeamParams](),
-		mcp.WithOutputSchema[AdminTeamResult](),
+		mcp.WithOutputSchema[TeamChangeResult](),
 		mcp.WithTitleAnnotation("Create Team"),
 		mcp.WithDestructiveHintAnnotation(false),
 	), mcp.NewStructuredToolHandler(handleCreateTeam))
@@ -628,15 +701,15 @@ func AddAdminTools(s *server.MCPServer) {
 	s.AddTool(mcp.NewTool("update_team",
 		mcp.WithDescription("Update an existing team's name and/or description. Requires team admin or global admin role."),
 		mcp.WithInputSchema[UpdateTeamParams](),
-		mcp.WithOutputSchema[AdminTeamResult](),
+		mcp.WithOutputSchema[TeamChangeResult](),
 		mcp.WithTitleAnnotation("Update Team"),
 		mcp.WithDestructiveHintAnnotation(false),
 	), mcp.NewStructuredToolHandler(handleUpdateTeam))
 
 	s.AddTool(mcp.NewTool("delete_team",
-		mcp.WithDescription("Delete a team permanently (admin only). Cannot be undone — removes all team associations."),
+		mcp.WithDescription("Delete a team permanently (admin only). Cannot be undone — removes all team associations. The user must confirm the impact first: if the result has a confirm_token, show the impact to the user and call again with the token only once they agree."),
 		mcp.WithInputSchema[DeleteTeamParams](),
-		mcp.WithOutputSchema[SuccessResult](),
+		mcp.WithOutputSchema[DeleteResult](),
 		mcp.WithTitleAnnotation("Delete Team"),
 		mcp.WithDestructiveHintAnnotation(true),
 	), mcp.NewStructuredToolHandler(handleDeleteTeam))
@@ -652,7 +725,7 @@ func AddAdminTools(s *server.MCPServer) {
 	s.AddTool(mcp.NewTool("add_team_member",
 		mcp.WithDescription("Add a user to a team with a specific role. Requires team admin or global admin. Valid roles: owner, admin, editor, member."),
 		mcp.WithInputSchema[AddTeamMemberParams](),
-		mcp.WithOutputSchema[SuccessResult](),
+		mcp.WithOutputSchema[ChangeResult](),
 		mcp.WithTitleAnnotation("Add Team Member"),
 		mcp.WithDestructiveHintAnnotation(false),
 	), mcp.NewStructuredToolHandler(handleAddTeamMember))
@@ -668,7 +741,7 @@ func AddAdminTools(s *server.MCPServer) {
 	s.AddTool(mcp.NewTool("link_source_to_team",
 		mcp.WithDescription("Link a log source to a team, granting team members access. Requires team admin or global admin."),
 		mcp.WithInputSchema[LinkSourceToTeamParams](),
-		mcp.WithOutputSchema[SuccessResult](),
+		mcp.WithOutputSchema[ChangeResult](),
 		mcp.WithTitleAnnotation("Link Source to Team"),
 		mcp.WithDestructiveHintAnnotation(false),
 	), mcp.NewStructuredToolHandler(handleLinkSourceToTeam))
@@ -701,7 +774,7 @@ func AddAdminTools(s *server.MCPServer) {
 	s.AddTool(mcp.NewTool("create_user",
 		mcp.WithDescription("Create a new user (admin only). Provide email, full name, role (admin/member), and status (active/inactive)."),
 		mcp.WithInputSchema[CreateUserParams](),
-		mcp.WithOutputSchema[AdminUserResult](),
+		mcp.WithOutputSchema[UserChangeResult](),
 		mcp.WithTitleAnnotation("Create User"),
 		mcp.WithDestructiveHintAnnotation(false),
 	), mcp.NewStructuredToolHandler(handleCreateUser))
@@ -709,15 +782,15 @@ func AddAdminTools(s *server.MCPServer) {
 	s.AddTool(mcp.NewTool("update_user",
 		mcp.WithDescription("Update a user's information (admin only). All fields are optional — provide only fields to change."),
 		mcp.WithInputSchema[UpdateUserParams](),
-		mcp.WithOutputSchema[AdminUserResult](),
+		mcp.WithOutputSchema[UserChangeResult](),
 		mcp.WithTitleAnnotation("Update User"),
 		mcp.WithDestructiveHintAnnotation(false),
 	), mcp.NewStructuredToolHandler(handleUpdateUser))
 
 	s.AddTool(mcp.NewTool("delete_user",
-		mcp.WithDescription("Delete a user permanently (admin only). Cannot be undone. Cannot delete the last admin user."),
+		mcp.WithDescription("Delete a user permanently (admin only). Cannot be undone. Cannot delete the last admin user. The user must confirm the impact first: if the result has a confirm_token, show the impact to the user and call again with the token only once they agree."),
 		mcp.WithInputSchema[DeleteUserParams](),
-		mcp.WithOutputSchema[SuccessResult](),
+		mcp.WithOutputSchema[DeleteResult](),
 		mcp.WithTitleAnnotation("Delete User"),
 		mcp.WithDestructiveHintAnnotation(true),
 	), mcp.NewStructuredToolHandler(handleDeleteUser))
@@ -734,7 +807,7 @@ func AddAdminTools(s *server.MCPServer) {
 	s.AddTool(mcp.NewTool("create_source",
 		mcp.WithDescription("Create a new log source (admin only). Provide ClickHouse connection details, metadata config, and optional schema for auto-creation."),
 		mcp.WithInputSchema[CreateSourceParams](),
-		mcp.WithOutputSchema[SourceResult](),
+		mcp.WithOutputSchema[SourceChangeResult](),
 		mcp.WithTitleAnnotation("Create Source"),
 		mcp.WithDestructiveHintAnnotation(false),
 	), mcp.NewStructuredToolHandler(handleCreateSource))
@@ -748,9 +821,9 @@ func AddAdminTools(s *server.MCPServer) {
 	), mcp.NewStructuredToolHandler(handleValidateSourceConnection))
 
 	s.AddTool(mcp.NewTool("delete_source",
-		mcp.WithDescription("Delete a log source permanently (admin only). Cannot be undone — removes all team associations."),
+		mcp.WithDescription("Delete a log source permanently (admin only). Cannot be undone — removes all team associations. The user must confirm the impact first: if the result has a confirm_token, show the impact to the user and call again with the token only once they agree."),
 		mcp.WithInputSchema[DeleteSourceParams](),
-		mcp.WithOutputSchema[SuccessResult](),
+		mcp.WithOutputSchema[DeleteResult](),
 		mcp.WithTitleAnnotation("Delete Source"),
 		mcp.WithDestructiveHintAnnotation(true),
 	), mcp.NewStructuredToolHandler(handleDeleteSource))
@@ -781,9 +854,9 @@ func AddAdminTools(s *server.MCPServer) {
 	), mcp.NewStructuredToolHandler(handleCreateAPIToken))
 
 	s.AddTool(mcp.NewTool("delete_api_token",
-		mcp.WithDescription("Delete an API token. Immediately revokes access for any applications using it. Cannot be undone."),
+		mcp.WithDescription("Delete an API token. Immediately revokes access for any applications using it. Cannot be undone. The user must confirm the impact first: if the result has a confirm_token, show the impact to the user and call again with the token only once they agree."),
 		mcp.WithInputSchema[DeleteAPITokenParams](),
-		mcp.WithOutputSchema[SuccessResult](),
+		mcp.WithOutputSchema[DeleteResult](),
 		mcp.WithTitleAnnotation("Delete API Token"),
 		mcp.WithDestructiveHintAnnotation(true),
 	), mcp.NewStructuredToolHandler(handleDeleteAPIToken))
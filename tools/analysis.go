@@ -11,6 +11,7 @@ import (
 
 	mcplogchef "github.com/mr-karan/logchef-mcp"
 	"github.com/mr-karan/logchef-mcp/client"
+	"github.com/mr-karan/logchef-mcp/logformat"
 )
 
 // Composite analysis tools that orchestrate multiple API calls.
@@ -27,6 +28,7 @@ type CompareWindowsParams struct {
 	Window2End   string `json:"window2_end" jsonschema:"End time for window 2 (YYYY-MM-DD HH:MM:SS)"`
 	Limit        int    `json:"limit,omitempty" jsonschema:"Max rows per window (default 100)"`
 	Timezone     string `json:"timezone,omitempty" jsonschema:"Timezone (default UTC)"`
+	FormatParams
 }
 
 type TopValuesParams struct {
@@ -72,21 +74,49 @@ type FieldValue struct {
 	Count int64  `json:"count" jsonschema:"Number of occurrences"`
 }
 
+// compareWindowsColumns orders the fields of formatted compare_windows output.
+var compareWindowsColumns = []string{"window", "start", "end", "row_count", "row_count_percent", "query_id"}
+
+// rows returns the windows and their delta as rows for formatted output.
+func (r CompareWindowsResult) rows() []map[string]any {
+	return []map[string]any{
+		{"window": "window1", "start": r.Window1.Start, "end": r.Window1.End, "row_count": r.Window1.RowCount, "query_id": r.Window1.QueryID},
+		{"window": "window2", "start": r.Window2.Start, "end": r.Window2.End, "row_count": r.Window2.RowCount, "query_id": r.Window2.QueryID},
+		{"window": "delta", "row_count": r.Delta.RowCountDiff, "row_count_percent": r.Delta.RowCountPercent},
+	}
+}
+
 // --- Handlers ---
 
-func handleCompareWindows(ctx context.Context, request mcp.CallToolRequest, params CompareWindowsParams) (CompareWindowsResult, error) {
+// compare_windows has a fixed output shape, returned as structured content.
+// Its text is the JSON encoding or, with format, the windows as rows.
+func handleCompareWindows(ctx context.Context, request mcp.CallToolRequest, params CompareWindowsParams) (*mcp.CallToolResult, error) {
+	format, err := logformat.Parse(params.Format)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	result, err := compareWindows(ctx, params)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if format == logformat.JSON {
+		return mcp.NewToolResultStructuredOnly(result), nil
+	}
+	text, err := logformat.Render(format, result.rows(), compareWindowsColumns)
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultStructured(result, text), nil
+}
+
+func compareWindows(ctx context.Context, params CompareWindowsParams) (CompareWindowsResult, error) {
 	lc := mcplogchef.LogchefClientFromContext(ctx)
 	if lc == nil {
 		return CompareWindowsResult{}, fmt.Errorf("logchef client not configured")
 	}
 
-	limit := params.Limit
-	if limit <= 0 {
-		limit = 100
-	}
-	if limit > 500 {
-		limit = 500
-	}
+	limit := rowLimit(ctx, params.Limit, 100, 500)
+	timezone := timezoneOr(ctx, params.Timezone, "")
 
 	// Query window 1
 	resp1, err := lc.QueryLogchefQL(ctx, params.TeamID, params.SourceID, client.LogchefQLQueryRequest{
@@ -94,11 +124,12 @@ func handleCompareWindows(ctx context.Context, request mcp.CallToolRequest, para
 		Limit:     limit,
 		StartTime: params.Window1Start,
 		EndTime:   params.Window1End,
-		Timezone:  params.Timezone,
+		Timezone:  timezone,
 	})
 	if err != nil {
-		return CompareWindowsResult{}, fmt.Errorf("window 1 query failed: %w", err)
+		return CompareWindowsResult{}, toolError("window 1 query failed", err)
 	}
+	mcplogchef.RecordQuery(ctx, resp1.Data.GeneratedSQL, len(resp1.Data.Logs))
 
 	// Query window 2
 	resp2, err := lc.QueryLogchefQL(ctx, params.TeamID, params.SourceID, client.LogchefQLQueryRequest{
@@ -106,11 +137,12 @@ func handleCompareWindows(ctx context.Context, request mcp.CallToolRequest, para
 		Limit:     limit,
 		StartTime: params.Window2Start,
 		EndTime:   params.Window2End,
-		Timezone:  params.Timezone,
+		Timezone:  timezone,
 	})
 	if err != nil {
-		return CompareWindowsResult{}, fmt.Errorf("window 2 query failed: %w", err)
+		return CompareWindowsResult{}, toolError("window 2 query failed", err)
 	}
+	mcplogchef.RecordQuery(ctx, resp2.Data.GeneratedSQL, len(resp2.Data.Logs))
 
 	count1 := len(resp1.Data.Logs)
 	count2 := len(resp2.Data.Logs)
@@ -146,18 +178,12 @@ func handleTopValues(ctx context.Context, request mcp.CallToolRequest, params To
 		return TopValuesResult{}, fmt.Errorf("at least one field is required")
 	}
 
-	limit := params.Limit
-	if limit <= 0 {
-		limit = 10
-	}
-	if limit > 50 {
-		limit = 50
-	}
+	limit := rowLimit(ctx, params.Limit, 10, 50)
 
 	// First get the schema to determine field types
 	schema, err := lc.GetSourceSchema(ctx, params.TeamID, params.SourceID)
 	if err != nil {
-		return TopValuesResult{}, fmt.Errorf("get schema: %w", err)
+		return TopValuesResult{}, toolError("get schema", err)
 	}
 
 	fieldTypes := make(map[string]string, len(schema.Data))
@@ -230,7 +256,7 @@ func AddAnalysisTools(s *server.MCPServer) {
 		mcp.WithTitleAnnotation("Compare Time Windows"),
 		mcp.WithReadOnlyHintAnnotation(true),
 	)
-	s.AddTool(compareWindowsTool, mcp.NewStructuredToolHandler(handleCompareWindows))
+	s.AddTool(compareWindowsTool, mcp.NewTypedToolHandler(handleCompareWindows))
 
 	topValuesTool := mcp.NewTool("top_values",
 		mcp.WithDescription("Get the top distinct values for multiple fields in one call. Fetches the schema to determine field types, then queries each field's top values. Useful for quickly exploring the dimensions of a log source."),
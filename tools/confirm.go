@@ -0,0 +1,304 @@
+package tools
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/mr-karan/logchef-mcp/client"
+)
+
+// confirmTokenTTL is how long a confirm_token stays valid after it is issued.
+const confirmTokenTTL = 5 * time.Minute
+
+// confirmKey signs confirm_tokens. It is generated at startup, so tokens are
+// only accepted by the process that issued them.
+var confirmKey = func() []byte {
+	k := make([]byte, 32)
+	if _, err := rand.Read(k); err != nil {
+		panic(fmt.Sprintf("generate confirmation key: %v", err))
+	}
+	return k
+}()
+
+// DeleteResult is the result of a destructive admin tool. Until the deletion
+// is confirmed, Success is false and Impact and ConfirmToken are set.
+type DeleteResult struct {
+	Success      bool   `json:"success" jsonschema:"Whether the object was deleted"`
+	Message      string `json:"message" jsonschema:"Human-readable result message"`
+	Impact       string `json:"impact,omitempty" jsonschema:"What the deletion will remove, to show the user before they confirm"`
+	ConfirmToken string `json:"confirm_token,omitempty" jsonschema:"Pass back as confirm_token to carry out the deletion once the user has agreed"`
+}
+
+// confirmDeletion asks the user to approve a deletion described by impact
+// and status. Only impact is bound to the confirm token; status holds lines
+// that change on their own, such as when an API token was last used, and
+// is only shown. With a valid confirm token from an earlier call it returns true right
+// away. Otherwise it asks through MCP elicitation when the client supports
+// it, and falls back to returning a pending result with a new token that
+// the model must pass back after showing the impact to the user.
+func confirmDeletion(ctx context.Context, c *client.Client, tool string, target int, impact, status, token string) (bool, DeleteResult, error) {
+	profile, err := c.GetProfile(ctx)
+	if err != nil {
+		return false, DeleteResult{}, toolError("failed to get user profile", err)
+	}
+	// Bind tokens to the caller and to the impact they were shown, so a
+	// token cannot be replayed by someone else or after the impact changed.
+	subject := strings.Join([]string{tool, strconv.Itoa(target), strconv.Itoa(profile.Data.User.ID), impact}, "\x00")
+	impact += status
+
+	message := fmt.Sprintf("Confirmation required: show the impact to the user and only if they explicitly agree, call %s again with the same arguments and confirm_token.", tool)
+	if token != "" {
+		if verifyConfirmToken(subject, token, time.Now()) {
+			return true, DeleteResult{}, nil
+		}
+		message = "The confirm_token is invalid, expired or was issued for a different impact. " + message
+	} else if elicitationSupported(ctx) {
+		ok, err := elicitConfirmation(ctx, impact)
+		if err == nil {
+			if !ok {
+				return false, DeleteResult{}, fmt.Errorf("%s cancelled: the user did not confirm the deletion", tool)
+			}
+			return true, DeleteResult{}, nil
+		}
+		// The client could not show the prompt; fall back to a token.
+	}
+	return false, DeleteResult{
+		Message:      message,
+		Impact:       impact,
+		ConfirmToken: newConfirmToken(subject, time.Now()),
+	}, nil
+}
+
+// elicitationSupported reports whether the client declared the elicitation
+// capability when it initialized the session.
+func elicitationSupported(ctx context.Context) bool {
+	session, ok := server.ClientSessionFromContext(ctx).(server.SessionWithClientInfo)
+	return ok && session.GetClientCapabilities().Elicitation != nil && server.ServerFromContext(ctx) != nil
+}
+
+// elicitConfirmation shows impact to the user and reports whether they
+// approved it.
+func elicitConfirmation(ctx context.Context, impact string) (bool, error) {
+	res, err := server.ServerFromContext(ctx).RequestElicitation(ctx, mcp.ElicitationRequest{
+		Params: mcp.ElicitationParams{
+			Message: impact + "\n\nThis cannot be undone. Do you want to proceed?",
+			RequestedSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"confirm": map[string]any{
+						"type":        "boolean",
+						"title":       "Delete",
+						"description": "Check to carry out the deletion",
+					},
+				},
+				"required": []string{"confirm"},
+			},
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+	if res.Action != mcp.ElicitationResponseActionAccept {
+		return false, nil
+	}
+	content, _ := res.Content.(map[string]any)
+	confirmed, _ := content["confirm"].(bool)
+	return confirmed, nil
+}
+
+// newConfirmToken returns a token for subject that expires confirmTokenTTL
+// after now.
+func newConfirmToken(subject string, now time.Time) string {
+	expires := strconv.FormatInt(now.Add(confirmTokenTTL).Unix(), 10)
+	return expires + "." + confirmMAC(subject, expires)
+}
+
+func verifyConfirmToken(subject, token string, now time.Time) bool {
+	expires, mac, ok := strings.Cut(token, ".")
+	if !ok {
+		return false
+	}
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || now.After(time.Unix(unix, 0)) {
+		return false
+	}
+	return hmac.Equal([]byte(mac), []byte(confirmMAC(subject, expires)))
+}
+
+func confirmMAC(subject, expires string) string {
+	h := hmac.New(sha256.New, confirmKey)
+	h.Write([]byte(subject + "\x00" + expires))
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
+
+// --- Impact descriptions ---
+
+func describeTeamDeletion(ctx context.Context, c *client.Client, teamID int) (string, error) {
+	team, err := c.GetTeamByID(ctx, teamID)
+	if err != nil {
+		return "", toolError("get team", err)
+	}
+	members, err := c.ListTeamMembers(ctx, teamID)
+	if err != nil {
+		return "", toolError("list team members", err)
+	}
+	sources, err := c.GetTeamSources(ctx, teamID)
+	if err != nil {
+		return "", toolError("get team sources", err)
+	}
+	var sourceNames, collections []string
+	for _, src := range sources.Data {
+		sourceNames = append(sourceNames, src.Name)
+		names, err := collectionNames(ctx, c, teamID, src.ID)
+		if err != nil {
+			return "", err
+		}
+		collections = append(collections, names...)
+	}
+	return fmt.Sprintf("Delete team %q (ID %d):\n- %s access through this team\n- %s unlinked from the team\n- %s orphaned",
+		team.Data.Name, teamID,
+		countNoun(len(members.Data), "member loses", "members lose"),
+		namedCount(sourceNames, "source", "sources")+isAre(len(sourceNames)),
+		namedCount(collections, "collection", "collections")+isAre(len(collections)),
+	), nil
+}
+
+func describeUserDeletion(ctx context.Context, c *client.Client, userID int) (string, error) {
+	user, err := c.GetUserByID(ctx, userID)
+	if err != nil {
+		return "", toolError("get user", err)
+	}
+	teams, err := c.ListAllTeams(ctx)
+	if err != nil {
+		return "", toolError("list teams", err)
+	}
+	var memberOf []string
+	for _, t := range teams.Data {
+		members, err := c.ListTeamMembers(ctx, t.ID)
+		if err != nil {
+			return "", toolError("list team members", err)
+		}
+		for _, m := range members.Data {
+			if m.UserID == userID {
+				memberOf = append(memberOf, t.Name)
+				break
+			}
+		}
+	}
+	u := user.Data
+	return fmt.Sprintf("Delete user %s (%s, %s, ID %d):\n- removed from %s\n- their API tokens stop working",
+		u.Email, u.FullName, u.Role, userID,
+		namedCount(memberOf, "team", "teams"),
+	), nil
+}
+
+func describeSourceDeletion(ctx context.Context, c *client.Client, sourceID int) (string, error) {
+	sources, err := c.ListAllSources(ctx)
+	if err != nil {
+		return "", toolError("list sources", err)
+	}
+	var source *client.Source
+	for i := range sources.Data {
+		if sources.Data[i].ID == sourceID {
+			source = &sources.Data[i]
+			break
+		}
+	}
+	if source == nil {
+		return "", fmt.Errorf("source %d not found; use list_all_sources to find source IDs", sourceID)
+	}
+	teams, err := c.ListAllTeams(ctx)
+	if err != nil {
+		return "", toolError("list teams", err)
+	}
+	var linked, collections []string
+	for _, t := range teams.Data {
+		teamSources, err := c.GetTeamSources(ctx, t.ID)
+		if err != nil {
+			return "", toolError("get team sources", err)
+		}
+		for _, src := range teamSources.Data {
+			if src.ID != sourceID {
+				continue
+			}
+			linked = append(linked, t.Name)
+			names, err := collectionNames(ctx, c, t.ID, sourceID)
+			if err != nil {
+				return "", err
+			}
+			collections = append(collections, names...)
+		}
+	}
+	conn := source.Connection
+	return fmt.Sprintf("Delete source %q (ID %d, table %s.%s):\n- unlinked from %s\n- %s orphaned",
+		source.Name, sourceID, conn.Database, conn.TableName,
+		namedCount(linked, "team", "teams"),
+		namedCount(collections, "collection", "collections")+isAre(len(collections)),
+	), nil
+}
+
+// describeAPITokenDeletion returns the impact of deleting a token and, as
+// status, when it was last used.
+func describeAPITokenDeletion(ctx context.Context, c *client.Client, tokenID int) (impact, status string, err error) {
+	tokens, err := c.ListAPITokens(ctx)
+	if err != nil {
+		return "", "", toolError("list API tokens", err)
+	}
+	for _, t := range tokens.Data {
+		if t.ID != tokenID {
+			continue
+		}
+		status = "\n- never used"
+		if t.LastUsedAt != nil {
+			status = "\n- last used " + *t.LastUsedAt
+		}
+		return fmt.Sprintf("Delete API token %q (ID %d, prefix %s):\n- applications using it lose access immediately",
+			t.Name, tokenID, t.Prefix), status, nil
+	}
+	return "", "", fmt.Errorf("API token %d not found; use list_api_tokens to find token IDs", tokenID)
+}
+
+func collectionNames(ctx context.Context, c *client.Client, teamID, sourceID int) ([]string, error) {
+	collections, err := c.GetCollections(ctx, teamID, sourceID)
+	if err != nil {
+		return nil, toolError("get collections", err)
+	}
+	names := make([]string, len(collections.Data))
+	for i, col := range collections.Data {
+		names[i] = col.Name
+	}
+	return names, nil
+}
+
+func countNoun(n int, singular, plural string) string {
+	if n == 1 {
+		return "1 " + singular
+	}
+	return fmt.Sprintf("%d %s", n, plural)
+}
+
+// namedCount formats names as "2 teams (a, b)".
+func namedCount(names []string, singular, plural string) string {
+	s := countNoun(len(names), singular, plural)
+	if len(names) > 0 {
+		s += " (" + strings.Join(names, ", ") + ")"
+	}
+	return s
+}
+
+func isAre(n int) string {
+	if n == 1 {
+		return " is"
+	}
+	return " are"
+}
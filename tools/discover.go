@@ -49,7 +49,7 @@ func handleGenerateQuery(ctx context.Context, request mcp.CallToolRequest, param
 		NaturalLanguageQuery: params.Query,
 	})
 	if err != nil {
-		return GenerateQueryResult{}, fmt.Errorf("generate query failed: %w", err)
+		return GenerateQueryResult{}, toolError("generate query failed", err)
 	}
 
 	return GenerateQueryResult{
@@ -64,23 +64,13 @@ func handleGetAllFieldDimensions(ctx context.Context, request mcp.CallToolReques
 		return mcp.NewToolResultError("logchef client not configured"), nil
 	}
 
-	limit := params.Limit
-	if limit <= 0 {
-		limit = 10
-	}
-	if limit > 100 {
-		limit = 100
-	}
-
-	timezone := params.Timezone
-	if timezone == "" {
-		timezone = "UTC"
-	}
+	limit := rowLimit(ctx, params.Limit, 10, 100)
+	timezone := timezoneOr(ctx, params.Timezone, "UTC")
 
 	resp, err := lc.GetAllFieldValues(ctx, params.TeamID, params.SourceID,
 		params.StartTime, params.EndTime, timezone, limit)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("get all field dimensions failed: %v", err)), nil
+		return mcp.NewToolResultError(toolError("get all field dimensions failed", err).Error()), nil
 	}
 
 	out, _ := json.MarshalIndent(resp.Data, "", "  ")
@@ -0,0 +1,444 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/mr-karan/logchef-mcp/client"
+)
+
+// ChangePlan describes what a mutating tool called with dry_run would do.
+// Nothing is changed in Logchef.
+type ChangePlan struct {
+	Action   string        `json:"action" jsonschema:"The change the call would make"`
+	Changes  []FieldChange `json:"changes" jsonschema:"Fields that would be set or changed, with their current values"`
+	Warnings []string      `json:"warnings,omitempty" jsonschema:"Problems the change may run into or side effects to review"`
+}
+
+type FieldChange struct {
+	Field  string `json:"field" jsonschema:"Field name"`
+	Before any    `json:"before" jsonschema:"Current value, null for new objects"`
+	After  any    `json:"after" jsonschema:"Value after the change"`
+}
+
+// The results of mutating tools. Plan is only set for dry runs, in which
+// case the embedded result is the object as it would be after the change.
+
+type TeamChangeResult struct {
+	AdminTeamResult
+	Plan *ChangePlan `json:"plan,omitempty" jsonschema:"Set for dry runs: the changes the call would make"`
+}
+
+type UserChangeResult struct {
+	AdminUserResult
+	Plan *ChangePlan `json:"plan,omitempty" jsonschema:"Set for dry runs: the changes the call would make"`
+}
+
+type SourceChangeResult struct {
+	SourceResult
+	Plan *ChangePlan `json:"plan,omitempty" jsonschema:"Set for dry runs: the changes the call would make"`
+}
+
+type CollectionChangeResult struct {
+	CollectionResult
+	Plan *ChangePlan `json:"plan,omitempty" jsonschema:"Set for dry runs: the changes the call would make"`
+}
+
+type ChangeResult struct {
+	SuccessResult
+	Plan *ChangePlan `json:"plan,omitempty" jsonschema:"Set for dry runs: the changes the call would make"`
+}
+
+var (
+	teamMemberRoles = []string{"owner", "admin", "editor", "member"}
+	userRoles       = []string{"admin", "member"}
+	userStatuses    = []string{"active", "inactive"}
+)
+
+// diff appends a change for field if before and after differ.
+func diff(changes []FieldChange, field string, before, after any) []FieldChange {
+	if before == after {
+		return changes
+	}
+	return append(changes, FieldChange{Field: field, Before: before, After: after})
+}
+
+// set appends a change for a field of a new object, skipping empty strings.
+func set(changes []FieldChange, field string, value any) []FieldChange {
+	if value == "" {
+		return changes
+	}
+	return append(changes, FieldChange{Field: field, After: value})
+}
+
+func noChangesWarning(changes []FieldChange) []string {
+	if len(changes) == 0 {
+		return []string{"no fields would change"}
+	}
+	return nil
+}
+
+func checkOneOf(field, value string, allowed []string) error {
+	if !slices.Contains(allowed, value) {
+		return fmt.Errorf("invalid %s %q: must be one of %s", field, value, strings.Join(allowed, ", "))
+	}
+	return nil
+}
+
+func checkRequired(fields ...string) error {
+	for i := 0; i < len(fields); i += 2 {
+		if strings.TrimSpace(fields[i+1]) == "" {
+			return fmt.Errorf("%s is required", fields[i])
+		}
+	}
+	return nil
+}
+
+// checkTeamAdmin mirrors the check Logchef makes on changes to a team: they
+// are open to global admins and to the team's own admins.
+func checkTeamAdmin(ctx context.Context, c *client.Client, teamID int) error {
+	profile, err := c.GetProfile(ctx)
+	if err != nil {
+		return toolError("failed to get user profile", err)
+	}
+	if profile.Data.User.Role == "admin" {
+		return nil
+	}
+	members, err := c.ListTeamMembers(ctx, teamID)
+	if err != nil {
+		return toolError("list team members", err)
+	}
+	if slices.ContainsFunc(members.Data, func(m client.TeamMember) bool {
+		return m.UserID == profile.Data.User.ID && (m.Role == "admin" || m.Role == "owner")
+	}) {
+		return nil
+	}
+	return fmt.Errorf("access denied: team admin role required for team %d", teamID)
+}
+
+// --- Teams ---
+
+func planCreateTeam(ctx context.Context, c *client.Client, args CreateTeamParams) (TeamChangeResult, error) {
+	if err := checkRequired("name", args.Name); err != nil {
+		return TeamChangeResult{}, err
+	}
+	teams, err := c.ListAllTeams(ctx)
+	if err != nil {
+		return TeamChangeResult{}, toolError("list teams", err)
+	}
+	plan := &ChangePlan{Action: fmt.Sprintf("create team %q", args.Name)}
+	plan.Changes = set(plan.Changes, "name", args.Name)
+	plan.Changes = set(plan.Changes, "description", args.Description)
+	for _, t := range teams.Data {
+		if strings.EqualFold(t.Name, args.Name) {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("team %d is already named %q", t.ID, t.Name))
+		}
+	}
+	return TeamChangeResult{
+		AdminTeamResult: AdminTeamResult{Name: args.Name, Description: args.Description},
+		Plan:            plan,
+	}, nil
+}
+
+func planUpdateTeam(ctx context.Context, c *client.Client, args UpdateTeamParams) (TeamChangeResult, error) {
+	if err := checkTeamAdmin(ctx, c, args.TeamID); err != nil {
+		return TeamChangeResult{}, err
+	}
+	if args.Name != nil {
+		if err := checkRequired("name", *args.Name); err != nil {
+			return TeamChangeResult{}, err
+		}
+	}
+	team, err := c.GetTeamByID(ctx, args.TeamID)
+	if err != nil {
+		return TeamChangeResult{}, toolError("get team", err)
+	}
+	after := teamToAdminResult(team.Data)
+	plan := &ChangePlan{Action: fmt.Sprintf("update team %q (ID %d)", team.Data.Name, args.TeamID)}
+	if args.Name != nil {
+		plan.Changes = diff(plan.Changes, "name", after.Name, *args.Name)
+		after.Name = *args.Name
+	}
+	if args.Description != nil {
+		plan.Changes = diff(plan.Changes, "description", after.Description, *args.Description)
+		after.Description = *args.Description
+	}
+	plan.Warnings = noChangesWarning(plan.Changes)
+	return TeamChangeResult{AdminTeamResult: after, Plan: plan}, nil
+}
+
+func planAddTeamMember(ctx context.Context, c *client.Client, args AddTeamMemberParams) (ChangeResult, error) {
+	if err := checkTeamAdmin(ctx, c, args.TeamID); err != nil {
+		return ChangeResult{}, err
+	}
+	if err := checkOneOf("role", args.Role, teamMemberRoles); err != nil {
+		return ChangeResult{}, err
+	}
+	team, err := c.GetTeamByID(ctx, args.TeamID)
+	if err != nil {
+		return ChangeResult{}, toolError("get team", err)
+	}
+	members, err := c.ListTeamMembers(ctx, args.TeamID)
+	if err != nil {
+		return ChangeResult{}, toolError("list team members", err)
+	}
+	// Team admins may add members without being global admins, but only
+	// global admins can look users up.
+	name := fmt.Sprintf("user %d", args.UserID)
+	var warnings []string
+	if user, err := c.GetUserByID(ctx, args.UserID); err == nil {
+		name = user.Data.Email
+	} else if client.IsForbidden(err) {
+		warnings = append(warnings, fmt.Sprintf("could not check that user %d exists without the global admin role; Logchef checks it when the member is added", args.UserID))
+	} else {
+		return ChangeResult{}, toolError("get user", err)
+	}
+	var before any
+	plan := &ChangePlan{Action: fmt.Sprintf("add %s to team %q as %s", name, team.Data.Name, args.Role), Warnings: warnings}
+	if i := slices.IndexFunc(members.Data, func(m client.TeamMember) bool { return m.UserID == args.UserID }); i >= 0 {
+		before = members.Data[i].Role
+		plan.Warnings = append(plan.Warnings, fmt.Sprintf("%s is already a member with role %s", name, members.Data[i].Role))
+	}
+	plan.Changes = diff(plan.Changes, "role", before, args.Role)
+	return ChangeResult{
+		SuccessResult: SuccessResult{Message: "Dry run: " + plan.Action},
+		Plan:          plan,
+	}, nil
+}
+
+func planLinkSourceToTeam(ctx context.Context, c *client.Client, args LinkSourceToTeamParams) (ChangeResult, error) {
+	if err := checkTeamAdmin(ctx, c, args.TeamID); err != nil {
+		return ChangeResult{}, err
+	}
+	team, err := c.GetTeamByID(ctx, args.TeamID)
+	if err != nil {
+		return ChangeResult{}, toolError("get team", err)
+	}
+	// As with members, only global admins can list every source.
+	name := fmt.Sprintf("source %d", args.SourceID)
+	var warnings []string
+	if sources, err := c.ListAllSources(ctx); err == nil {
+		i := slices.IndexFunc(sources.Data, func(s client.Source) bool { return s.ID == args.SourceID })
+		if i < 0 {
+			return ChangeResult{}, fmt.Errorf("source %d not found; use list_all_sources to find source IDs", args.SourceID)
+		}
+		name = sources.Data[i].Name
+	} else if client.IsForbidden(err) {
+		warnings = append(warnings, fmt.Sprintf("could not check that source %d exists without the global admin role; Logchef checks it when the source is linked", args.SourceID))
+	} else {
+		return ChangeResult{}, toolError("list sources", err)
+	}
+	linked, err := c.GetTeamSources(ctx, args.TeamID)
+	if err != nil {
+		return ChangeResult{}, toolError("get team sources", err)
+	}
+	before := make([]string, 0, len(linked.Data))
+	for _, s := range linked.Data {
+		before = append(before, s.Name)
+	}
+	plan := &ChangePlan{Action: fmt.Sprintf("link source %q to team %q", name, team.Data.Name), Warnings: warnings}
+	if slices.ContainsFunc(linked.Data, func(s *client.SourceResponse) bool { return s.ID == args.SourceID }) {
+		plan.Warnings = append(plan.Warnings, fmt.Sprintf("source %q is already linked to the team", name))
+	} else {
+		plan.Changes = append(plan.Changes, FieldChange{Field: "sources", Before: before, After: append(slices.Clone(before), name)})
+	}
+	return ChangeResult{
+		SuccessResult: SuccessResult{Message: "Dry run: " + plan.Action},
+		Plan:          plan,
+	}, nil
+}
+
+// --- Users ---
+
+func planCreateUser(ctx context.Context, c *client.Client, args CreateUserParams) (UserChangeResult, error) {
+	if err := checkRequired("email", args.Email, "full_name", args.FullName); err != nil {
+		return UserChangeResult{}, err
+	}
+	if !strings.Contains(args.Email, "@") {
+		return UserChangeResult{}, fmt.Errorf("invalid email %q", args.Email)
+	}
+	if err := checkOneOf("role", args.Role, userRoles); err != nil {
+		return UserChangeResult{}, err
+	}
+	if err := checkOneOf("status", args.Status, userStatuses); err != nil {
+		return UserChangeResult{}, err
+	}
+	users, err := c.ListAllUsers(ctx)
+	if err != nil {
+		return UserChangeResult{}, toolError("list all users", err)
+	}
+	for _, u := range users.Data {
+		if strings.EqualFold(u.Email, args.Email) {
+			return UserChangeResult{}, fmt.Errorf("user %d already has the email %s", u.ID, u.Email)
+		}
+	}
+	plan := &ChangePlan{Action: fmt.Sprintf("create %s user %s", args.Role, args.Email)}
+	plan.Changes = set(plan.Changes, "email", args.Email)
+	plan.Changes = set(plan.Changes, "full_name", args.FullName)
+	plan.Changes = set(plan.Changes, "role", args.Role)
+	plan.Changes = set(plan.Changes, "status", args.Status)
+	return UserChangeResult{
+		AdminUserResult: AdminUserResult{Email: args.Email, FullName: args.FullName, Role: args.Role, Status: args.Status},
+		Plan:            plan,
+	}, nil
+}
+
+func planUpdateUser(ctx context.Context, c *client.Client, args UpdateUserParams) (UserChangeResult, error) {
+	if args.Email != nil && !strings.Contains(*args.Email, "@") {
+		return UserChangeResult{}, fmt.Errorf("invalid email %q", *args.Email)
+	}
+	if args.Role != nil {
+		if err := checkOneOf("role", *args.Role, userRoles); err != nil {
+			return UserChangeResult{}, err
+		}
+	}
+	if args.Status != nil {
+		if err := checkOneOf("status", *args.Status, userStatuses); err != nil {
+			return UserChangeResult{}, err
+		}
+	}
+	user, err := c.GetUserByID(ctx, args.UserID)
+	if err != nil {
+		return UserChangeResult{}, toolError("get user", err)
+	}
+	after := userToResult(user.Data)
+	plan := &ChangePlan{Action: fmt.Sprintf("update user %s (ID %d)", user.Data.Email, args.UserID)}
+	for _, f := range []struct {
+		name  string
+		value *string
+		field *string
+	}{
+		{"email", args.Email, &after.Email},
+		{"full_name", args.FullName, &after.FullName},
+		{"role", args.Role, &after.Role},
+		{"status", args.Status, &after.Status},
+	} {
+		if f.value != nil {
+			plan.Changes = diff(plan.Changes, f.name, *f.field, *f.value)
+			*f.field = *f.value
+		}
+	}
+	plan.Warnings = noChangesWarning(plan.Changes)
+
+	profile, err := c.GetProfile(ctx)
+	if err != nil {
+		return UserChangeResult{}, toolError("failed to get user profile", err)
+	}
+	if profile.Data.User.ID == args.UserID && (after.Role != "admin" || after.Status != "active") {
+		plan.Warnings = append(plan.Warnings, "this is your own user: you would lose admin access")
+	}
+	return UserChangeResult{AdminUserResult: after, Plan: plan}, nil
+}
+
+// --- Sources ---
+
+func planCreateSource(ctx context.Context, c *client.Client, args CreateSourceParams) (SourceChangeResult, error) {
+	if err := checkRequired("name", args.Name, "host", args.Host, "database", args.Database, "table_name", args.TableName); err != nil {
+		return SourceChangeResult{}, err
+	}
+	if args.TTLDays < 0 {
+		return SourceChangeResult{}, fmt.Errorf("ttl_days must not be negative")
+	}
+	tsField := args.MetaTsField
+	if tsField == "" {
+		tsField = "timestamp"
+	}
+	sources, err := c.ListAllSources(ctx)
+	if err != nil {
+		return SourceChangeResult{}, toolError("list sources", err)
+	}
+
+	plan := &ChangePlan{Action: fmt.Sprintf("create source %q for table %s.%s", args.Name, args.Database, args.TableName)}
+	for _, s := range sources.Data {
+		conn := s.Connection
+		switch {
+		case strings.EqualFold(s.Name, args.Name):
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("source %d is already named %q", s.ID, s.Name))
+		case conn.Host == args.Host && conn.Database == args.Database && conn.TableName == args.TableName:
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("source %q (ID %d) already reads this table", s.Name, s.ID))
+		}
+	}
+	if !args.MetaIsAutoCreated {
+		v, err := c.ValidateSourceConnection(ctx, client.SourceValidationRequest{
+			Host: args.Host, Database: args.Database, TableName: args.TableName,
+			TimestampField: tsField, SeverityField: args.MetaSeverityField,
+		})
+		if err != nil {
+			return SourceChangeResult{}, toolError("validate source connection", err)
+		}
+		if !v.Data.IsValid {
+			plan.Warnings = append(plan.Warnings, "connection check failed: "+v.Data.Message)
+			plan.Warnings = append(plan.Warnings, v.Data.ErrorDetails...)
+		}
+	}
+
+	plan.Changes = set(plan.Changes, "name", args.Name)
+	plan.Changes = set(plan.Changes, "description", args.Description)
+	plan.Changes = set(plan.Changes, "connection", fmt.Sprintf("%s/%s.%s", args.Host, args.Database, args.TableName))
+	plan.Changes = set(plan.Changes, "ts_field", tsField)
+	plan.Changes = set(plan.Changes, "ttl_days", args.TTLDays)
+	return SourceChangeResult{
+		SourceResult: SourceResult{
+			Name: args.Name, Description: args.Description,
+			Connection: ConnectionResult{Host: args.Host, Database: args.Database, TableName: args.TableName},
+			TsField:    tsField, TTLDays: args.TTLDays,
+		},
+		Plan: plan,
+	}, nil
+}
+
+// --- Collections ---
+//
+// Collections belong to a team rather than the admin API, so their dry runs
+// rely on Logchef to check access while reading the current state.
+
+func planCreateCollection(ctx context.Context, c *client.Client, args CreateCollectionParams) (CollectionChangeResult, error) {
+	if err := checkRequired("name", args.Name, "query", args.Query); err != nil {
+		return CollectionChangeResult{}, err
+	}
+	collections, err := c.GetCollections(ctx, args.TeamID, args.SourceID)
+	if err != nil {
+		return CollectionChangeResult{}, toolError("get collections", err)
+	}
+	plan := &ChangePlan{Action: fmt.Sprintf("create collection %q", args.Name)}
+	for _, col := range collections.Data {
+		if strings.EqualFold(col.Name, args.Name) {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("collection %d is already named %q", col.ID, col.Name))
+		}
+	}
+	plan.Changes = set(plan.Changes, "name", args.Name)
+	plan.Changes = set(plan.Changes, "description", args.Description)
+	plan.Changes = set(plan.Changes, "query", args.Query)
+	return CollectionChangeResult{
+		CollectionResult: CollectionResult{
+			Name: args.Name, Description: args.Description,
+			TeamID: args.TeamID, SourceID: args.SourceID, Query: args.Query,
+		},
+		Plan: plan,
+	}, nil
+}
+
+func planUpdateCollection(ctx context.Context, c *client.Client, args UpdateCollectionParams) (CollectionChangeResult, error) {
+	if err := checkRequired("name", args.Name, "query", args.Query); err != nil {
+		return CollectionChangeResult{}, err
+	}
+	collection, err := c.GetCollection(ctx, args.TeamID, args.SourceID, args.CollectionID)
+	if err != nil {
+		return CollectionChangeResult{}, toolError("get collection", err)
+	}
+	after := collectionToResult(collection.Data)
+	plan := &ChangePlan{Action: fmt.Sprintf("update collection %q (ID %d)", collection.Data.Name, args.CollectionID)}
+	plan.Changes = diff(plan.Changes, "name", after.Name, args.Name)
+	after.Name = args.Name
+	// An empty description is left out of the update request.
+	if args.Description != "" {
+		plan.Changes = diff(plan.Changes, "description", after.Description, args.Description)
+		after.Description = args.Description
+	}
+	plan.Changes = diff(plan.Changes, "query", after.Query, args.Query)
+	after.Query = args.Query
+	plan.Warnings = noChangesWarning(plan.Changes)
+	return CollectionChangeResult{CollectionResult: after, Plan: plan}, nil
+}
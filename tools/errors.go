@@ -0,0 +1,37 @@
+package tools
+
+import (
+	"fmt"
+
+	"github.com/mr-karan/logchef-mcp/client"
+)
+
+// toolError wraps err with the failed operation and, when Logchef rejected the
+// request, appends a hint so the model knows how to recover instead of
+// retrying blindly.
+func toolError(op string, err error) error {
+	if hint := apiErrorHint(err); hint != "" {
+		return fmt.Errorf("%s: %w (%s)", op, err, hint)
+	}
+	return fmt.Errorf("%s: %w", op, err)
+}
+
+// apiErrorHint returns remediation advice for a Logchef API error, or an empty
+// string if err is not one or there is nothing actionable to say.
+func apiErrorHint(err error) string {
+	switch {
+	case client.IsUnauthorized(err):
+		return "the Logchef API key is missing, invalid or expired; ask the user to check their credentials"
+	case client.IsForbidden(err):
+		return "the API key's user lacks permission for this team or source; use get_teams and get_sources to find accessible IDs"
+	case client.IsNotFound(err):
+		return "the referenced team, source or object does not exist; verify the IDs with get_teams or get_sources"
+	case client.IsRateLimited(err):
+		return "Logchef is rate limiting requests; wait before retrying and issue fewer parallel calls"
+	case client.IsBadRequest(err):
+		return "Logchef rejected the input; fix the arguments or query syntax before retrying"
+	case client.IsServerError(err):
+		return "Logchef or ClickHouse failed to process the request; simplify the query or narrow the time range and retry"
+	}
+	return ""
+}
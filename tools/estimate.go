@@ -0,0 +1,213 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	mcplogchef "github.com/mr-karan/logchef-mcp"
+	"github.com/mr-karan/logchef-mcp/client"
+)
+
+// --- Input schemas ---
+
+type EstimateQueryParams struct {
+	TeamID    int    `json:"team_id" jsonschema:"The ID of the team that has access to the source"`
+	SourceID  int    `json:"source_id" jsonschema:"The ID of the source to estimate the query against"`
+	RawSQL    string `json:"raw_sql,omitempty" jsonschema:"ClickHouse SQL to estimate, as passed to query_logs. Set either raw_sql or query."`
+	Query     string `json:"query,omitempty" jsonschema:"LogchefQL filter to estimate, as passed to query_logchefql, with start_time and end_time"`
+	StartTime string `json:"start_time,omitempty" jsonschema:"Start time for query in YYYY-MM-DD HH:MM:SS format"`
+	EndTime   string `json:"end_time,omitempty" jsonschema:"End time for query in YYYY-MM-DD HH:MM:SS format"`
+	Timezone  string `json:"timezone,omitempty" jsonschema:"Timezone for query (default UTC)"`
+	Indexes   bool   `json:"indexes,omitempty" jsonschema:"Also return the EXPLAIN indexes = 1 plan, showing how the primary key and skip indexes narrow the read"`
+}
+
+// --- Output schemas ---
+
+type EstimateResult struct {
+	SQL        string   `json:"sql" jsonschema:"The SQL that was estimated, after the SQL guard or LogchefQL translation"`
+	Rows       int64    `json:"rows" jsonschema:"Estimated rows ClickHouse will read"`
+	Parts      int64    `json:"parts" jsonschema:"Estimated data parts ClickHouse will read"`
+	Marks      int64    `json:"marks" jsonschema:"Estimated index marks (granules) ClickHouse will read"`
+	WarnRows   int      `json:"warn_rows,omitempty" jsonschema:"Configured rows read above which query tools add a warning"`
+	MaxRows    int      `json:"max_rows,omitempty" jsonschema:"Configured rows read above which query tools refuse to run"`
+	OverBudget bool     `json:"over_budget" jsonschema:"Whether query_logs and query_logchefql would refuse this query"`
+	Warnings   []string `json:"warnings,omitempty" jsonschema:"Notes about the query and its cost"`
+	IndexPlan  []string `json:"index_plan,omitempty" jsonschema:"EXPLAIN indexes = 1 output, when requested"`
+}
+
+// queryEstimate is ClickHouse's EXPLAIN ESTIMATE for a query, summed over
+// the tables it reads.
+type queryEstimate struct {
+	Rows, Parts, Marks int64
+}
+
+// --- Handlers ---
+
+func handleEstimateQuery(ctx context.Context, request mcp.CallToolRequest, args EstimateQueryParams) (EstimateResult, error) {
+	c := mcplogchef.LogchefClientFromContext(ctx)
+	if c == nil {
+		return EstimateResult{}, fmt.Errorf("logchef client not configured")
+	}
+	if args.RawSQL != "" && args.Query != "" {
+		return EstimateResult{}, fmt.Errorf("set either raw_sql or query, not both")
+	}
+
+	var result EstimateResult
+	if args.RawSQL != "" {
+		if mcplogchef.ReadOnlyFromContext(ctx) && !isSelectStatement(args.RawSQL) {
+			return EstimateResult{}, errReadOnlySQL
+		}
+		src, err := teamSource(ctx, c, args.TeamID, args.SourceID)
+		if err != nil {
+			return EstimateResult{}, err
+		}
+		sql, note, err := guardSQL(args.RawSQL, src.Connection.Database+"."+src.Connection.TableName, src.MetaTsField)
+		if err != nil {
+			return EstimateResult{}, err
+		}
+		result.SQL = sql
+		if note != "" {
+			result.Warnings = append(result.Warnings, note)
+		}
+	} else {
+		sql, err := translateForEstimate(ctx, c, args.TeamID, args.SourceID, args.Query, args.StartTime, args.EndTime, args.Timezone)
+		if err != nil {
+			return EstimateResult{}, err
+		}
+		result.SQL = sql
+	}
+
+	est, err := estimateSQL(ctx, c, args.TeamID, args.SourceID, result.SQL)
+	if err != nil {
+		return EstimateResult{}, err
+	}
+	budget := mcplogchef.ReadBudgetFromContext(ctx)
+	over, message := checkReadBudget(budget, est)
+	result.Rows, result.Parts, result.Marks = est.Rows, est.Parts, est.Marks
+	result.WarnRows, result.MaxRows, result.OverBudget = budget.WarnRows, budget.MaxRows, over
+	if message != "" {
+		result.Warnings = append(result.Warnings, message)
+	}
+
+	if args.Indexes {
+		if result.IndexPlan, err = indexPlan(ctx, c, args.TeamID, args.SourceID, result.SQL); err != nil {
+			return EstimateResult{}, err
+		}
+	}
+	return result, nil
+}
+
+// translateForEstimate returns the SQL Logchef runs for a LogchefQL query.
+func translateForEstimate(ctx context.Context, c *client.Client, teamID, sourceID int, query, start, end, timezone string) (string, error) {
+	resp, err := c.TranslateLogchefQL(ctx, teamID, sourceID, client.LogchefQLTranslateRequest{
+		Query:     query,
+		StartTime: start,
+		EndTime:   end,
+		Timezone:  timezoneOr(ctx, timezone, ""),
+	})
+	if err != nil {
+		return "", toolError("logchefql translate failed", err)
+	}
+	if !resp.Data.Valid {
+		return "", fmt.Errorf("invalid LogchefQL query %q; use validate_logchefql to find the syntax error", query)
+	}
+	return resp.Data.SQL, nil
+}
+
+// estimateSQL runs EXPLAIN ESTIMATE for sql through Logchef.
+func estimateSQL(ctx context.Context, c *client.Client, teamID, sourceID int, sql string) (queryEstimate, error) {
+	explain := "EXPLAIN ESTIMATE " + trimStatement(sql)
+	resp, err := c.QueryLogs(ctx, teamID, sourceID, client.LogQueryRequest{
+		RawSQL:       explain,
+		QueryTimeout: queryTimeout(ctx, nil),
+	})
+	if err != nil {
+		return queryEstimate{}, toolError("estimate query", err)
+	}
+	mcplogchef.RecordQuery(ctx, explain, len(resp.Data.Data))
+
+	var est queryEstimate
+	for _, row := range resp.Data.Data {
+		est.Rows += explainInt(row["rows"])
+		est.Parts += explainInt(row["parts"])
+		est.Marks += explainInt(row["marks"])
+	}
+	return est, nil
+}
+
+// explainInt reads a UInt64 column, which ClickHouse quotes in JSON output
+// by default.
+func explainInt(v any) int64 {
+	switch v := v.(type) {
+	case float64:
+		return int64(v)
+	case string:
+		n, _ := strconv.ParseInt(v, 10, 64)
+		return n
+	}
+	return 0
+}
+
+// indexPlan runs EXPLAIN indexes = 1 for sql and returns its lines.
+func indexPlan(ctx context.Context, c *client.Client, teamID, sourceID int, sql string) ([]string, error) {
+	explain := "EXPLAIN indexes = 1 " + trimStatement(sql)
+	resp, err := c.QueryLogs(ctx, teamID, sourceID, client.LogQueryRequest{
+		RawSQL:       explain,
+		Limit:        1000,
+		QueryTimeout: queryTimeout(ctx, nil),
+	})
+	if err != nil {
+		return nil, toolError("explain indexes", err)
+	}
+	mcplogchef.RecordQuery(ctx, explain, len(resp.Data.Data))
+
+	lines := make([]string, 0, len(resp.Data.Data))
+	for _, row := range resp.Data.Data {
+		if line, ok := row["explain"].(string); ok {
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
+
+// checkReadBudget compares an estimate with the budget. over reports whether
+// the query must be refused; message explains any breach.
+func checkReadBudget(budget mcplogchef.ReadBudget, est queryEstimate) (over bool, message string) {
+	switch {
+	case budget.MaxRows > 0 && est.Rows > int64(budget.MaxRows):
+		return true, fmt.Sprintf("query is estimated to read %d rows (%d parts, %d marks), over the read budget of %d rows; narrow the time range or filter on indexed columns, and check the cost with estimate_query", est.Rows, est.Parts, est.Marks, budget.MaxRows)
+	case budget.WarnRows > 0 && est.Rows > int64(budget.WarnRows):
+		return false, fmt.Sprintf("query is estimated to read %d rows (%d parts, %d marks), over the warning threshold of %d rows; consider narrowing the time range", est.Rows, est.Parts, est.Marks, budget.WarnRows)
+	}
+	return false, ""
+}
+
+// preflight checks sql against the operator's read budget before a query
+// tool runs it. It returns an error when the query must be refused, and a
+// note to pass on to the model when it is over the warning threshold. If
+// the estimate itself fails, a query under a maximum is refused, since its
+// cost is unknown; with only a warning threshold it runs with a note.
+func preflight(ctx context.Context, c *client.Client, teamID, sourceID int, sql string) (string, error) {
+	budget := mcplogchef.ReadBudgetFromContext(ctx)
+	if !budget.Enabled() {
+		return "", nil
+	}
+	est, err := estimateSQL(ctx, c, teamID, sourceID, sql)
+	if err != nil {
+		slog.Warn("Query cost estimate failed", "team_id", teamID, "source_id", sourceID, "error", err)
+		if budget.MaxRows > 0 {
+			return "", fmt.Errorf("query refused because its cost could not be checked against the read budget of %d rows: %w", budget.MaxRows, err)
+		}
+		return "the read budget was not checked because the cost estimate failed", nil
+	}
+	over, message := checkReadBudget(budget, est)
+	if over {
+		return "", errors.New(message)
+	}
+	return message, nil
+}
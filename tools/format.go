@@ -0,0 +1,39 @@
+package tools
+
+import (
+	"encoding/json"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/mr-karan/logchef-mcp/client"
+	"github.com/mr-karan/logchef-mcp/logformat"
+)
+
+// FormatParams is the output format argument shared by the tools that
+// return log rows, embedded in their params.
+type FormatParams struct {
+	Format string `json:"format,omitempty" jsonschema:"Output format: json (default), ndjson, csv, markdown, or logfmt for one line per log starting with timestamp, severity, service and message. Other formats than json return the rows as the first content block and the rest of the result as JSON in the second."`
+}
+
+// formattedLogs returns rows rendered in format f, followed by meta, the
+// rest of the result, as indented JSON.
+func formattedLogs(f logformat.Format, rows []client.LogEntry, columns []string, meta any) (*mcp.CallToolResult, error) {
+	text, err := logformat.Render(f, rows, columns)
+	if err != nil {
+		return nil, err
+	}
+	out, _ := json.MarshalIndent(meta, "", "  ")
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{mcp.NewTextContent(text), mcp.NewTextContent(string(out))},
+	}, nil
+}
+
+// columnNames returns the names of cols, which order the fields of
+// formatted rows.
+func columnNames(cols []client.LogColumn) []string {
+	names := make([]string, len(cols))
+	for i, col := range cols {
+		names[i] = col.Name
+	}
+	return names
+}
@@ -0,0 +1,61 @@
+package tools
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	mcplogchef "github.com/mr-karan/logchef-mcp"
+)
+
+// ListInstancesParams represents the parameters for listing Logchef instances.
+type ListInstancesParams struct{}
+
+// --- Output schemas ---
+
+type InstanceResult struct {
+	Name        string `json:"name" jsonschema:"Instance name to pass as the instance argument of other tools"`
+	URL         string `json:"url" jsonschema:"Logchef base URL"`
+	Description string `json:"description,omitempty" jsonschema:"Operator-provided description"`
+	Default     bool   `json:"default" jsonschema:"Whether tools use this instance when the instance argument is omitted"`
+}
+
+// --- Handlers ---
+
+func handleListInstances(ctx context.Context, request mcp.CallToolRequest, args ListInstancesParams) ([]InstanceResult, error) {
+	result := []InstanceResult{{
+		Name:    mcplogchef.DefaultInstance,
+		URL:     redactURL(mcplogchef.LogchefURLFromContext(ctx)),
+		Default: true,
+	}}
+	for _, inst := range mcplogchef.InstancesFromContext(ctx) {
+		result = append(result, InstanceResult{
+			Name:        inst.Name,
+			URL:         redactURL(inst.URL),
+			Description: inst.Description,
+		})
+	}
+	return result, nil
+}
+
+// redactURL hides any password in u.
+func redactURL(u string) string {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return ""
+	}
+	return parsed.Redacted()
+}
+
+func AddInstanceTools(s *server.MCPServer) {
+	listInstancesTool := mcp.NewTool("list_instances",
+		mcp.WithDescription("List the Logchef instances this server can query. Pass an instance name as the instance argument of other tools to query it, e.g. to compare staging and production."),
+		mcp.WithInputSchema[ListInstancesParams](),
+		mcp.WithOutputSchema[[]InstanceResult](),
+		mcp.WithTitleAnnotation("List Logchef Instances"),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+	s.AddTool(listInstancesTool, mcp.NewStructuredToolHandler(handleListInstances))
+}
@@ -3,13 +3,14 @@ package tools
 import (
 	"context"
 	"encoding/json"
-	"fmt"
+	"maps"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 
 	mcplogchef "github.com/mr-karan/logchef-mcp"
 	"github.com/mr-karan/logchef-mcp/client"
+	"github.com/mr-karan/logchef-mcp/logformat"
 )
 
 // Investigation tools — field values, log context, and alerts for incident analysis.
@@ -30,6 +31,8 @@ type GetLogContextParams struct {
 	Timestamp   int64 `json:"timestamp" jsonschema:"Target timestamp in milliseconds (from a log entry)"`
 	BeforeLimit int   `json:"before_limit,omitempty" jsonschema:"Number of logs before the target (default 10)"`
 	AfterLimit  int   `json:"after_limit,omitempty" jsonschema:"Number of logs after the target (default 10)"`
+	ShapeParams
+	FormatParams
 }
 
 type ListAlertsParams struct {
@@ -52,18 +55,12 @@ func handleGetFieldValues(ctx context.Context, request mcp.CallToolRequest, para
 		return mcp.NewToolResultError("logchef client not configured"), nil
 	}
 
-	limit := params.Limit
-	if limit <= 0 {
-		limit = 20
-	}
-	if limit > 100 {
-		limit = 100
-	}
+	limit := rowLimit(ctx, params.Limit, 20, 100)
 
 	resp, err := lc.GetFieldValues(ctx, params.TeamID, params.SourceID,
 		params.FieldName, params.FieldType, params.StartTime, params.EndTime, limit)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("get field values failed: %v", err)), nil
+		return mcp.NewToolResultError(toolError("get field values failed", err).Error()), nil
 	}
 
 	out, _ := json.MarshalIndent(resp.Data, "", "  ")
@@ -77,20 +74,12 @@ func handleGetLogContext(ctx context.Context, request mcp.CallToolRequest, param
 		return mcp.NewToolResultError("logchef client not configured"), nil
 	}
 
-	beforeLimit := params.BeforeLimit
-	if beforeLimit <= 0 {
-		beforeLimit = 10
-	}
-	if beforeLimit > 100 {
-		beforeLimit = 100
-	}
-	afterLimit := params.AfterLimit
-	if afterLimit <= 0 {
-		afterLimit = 10
-	}
-	if afterLimit > 100 {
-		afterLimit = 100
+	format, err := logformat.Parse(params.Format)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
 	}
+	beforeLimit := rowLimit(ctx, params.BeforeLimit, 10, 100)
+	afterLimit := rowLimit(ctx, params.AfterLimit, 10, 100)
 
 	resp, err := lc.GetLogContext(ctx, params.TeamID, params.SourceID, client.LogContextRequest{
 		Timestamp:   params.Timestamp,
@@ -98,16 +87,45 @@ func handleGetLogContext(ctx context.Context, request mcp.CallToolRequest, param
 		AfterLimit:  afterLimit,
 	})
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("get log context failed: %v", err)), nil
+		return mcp.NewToolResultError(toolError("get log context failed", err).Error()), nil
 	}
 
+	// The target rows get the byte budget first, then the rows around them.
+	shape := params.ShapeParams.shaper()
+	targetLogs := shape.rows(resp.Data.TargetLogs)
+	beforeLogs := shape.rows(resp.Data.BeforeLogs)
+	afterLogs := shape.rows(resp.Data.AfterLogs)
+
 	result := map[string]any{
 		"target_timestamp": resp.Data.TargetTimestamp,
-		"before_logs":      resp.Data.BeforeLogs,
-		"target_logs":      resp.Data.TargetLogs,
-		"after_logs":       resp.Data.AfterLogs,
 		"stats":            resp.Data.Stats,
 	}
+	if shape.dropped > 0 {
+		result["rows_dropped"] = shape.dropped
+	}
+	if notes := shape.notes(); len(notes) > 0 {
+		result["notes"] = notes
+	}
+	if format != logformat.JSON {
+		// Other formats list the rows together, each marked with a
+		// _context field of before, target or after. The underscore keeps
+		// it apart from a context column of the source.
+		var rows []client.LogEntry
+		for _, part := range []struct {
+			name string
+			rows []client.LogEntry
+		}{{"before", beforeLogs}, {"target", targetLogs}, {"after", afterLogs}} {
+			for _, row := range part.rows {
+				row = maps.Clone(row)
+				row["_context"] = part.name
+				rows = append(rows, row)
+			}
+		}
+		return formattedLogs(format, rows, []string{"_context"}, result)
+	}
+	result["before_logs"] = beforeLogs
+	result["target_logs"] = targetLogs
+	result["after_logs"] = afterLogs
 
 	out, _ := json.MarshalIndent(result, "", "  ")
 	return mcp.NewToolResultText(string(out)), nil
@@ -122,7 +140,7 @@ func handleListAlerts(ctx context.Context, request mcp.CallToolRequest, params L
 
 	resp, err := lc.ListAlerts(ctx, params.TeamID, params.SourceID)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("list alerts failed: %v", err)), nil
+		return mcp.NewToolResultError(toolError("list alerts failed", err).Error()), nil
 	}
 
 	out, _ := json.MarshalIndent(resp.Data, "", "  ")
@@ -137,7 +155,7 @@ func handleGetAlertHistory(ctx context.Context, request mcp.CallToolRequest, par
 
 	resp, err := lc.GetAlertHistory(ctx, params.TeamID, params.SourceID, params.AlertID)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("get alert history failed: %v", err)), nil
+		return mcp.NewToolResultError(toolError("get alert history failed", err).Error()), nil
 	}
 
 	out, _ := json.MarshalIndent(resp.Data, "", "  ")
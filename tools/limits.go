@@ -0,0 +1,46 @@
+package tools
+
+import (
+	"context"
+	"time"
+
+	mcplogchef "github.com/mr-karan/logchef-mcp"
+)
+
+// rowLimit resolves the row limit for a tool call: requested, or def when
+// unset, capped at max. A MaxRows configured for the tool replaces max.
+func rowLimit(ctx context.Context, requested, def, max int) int {
+	if m := mcplogchef.ToolLimitFromContext(ctx).MaxRows; m > 0 {
+		max = m
+	}
+	if requested <= 0 {
+		requested = def
+	}
+	return min(requested, max)
+}
+
+// queryTimeout resolves the ClickHouse query timeout in seconds. When a
+// QueryTimeout is configured for the tool it is both the default and the
+// ceiling; otherwise requested passes through unchanged.
+func queryTimeout(ctx context.Context, requested *int) *int {
+	limit := mcplogchef.ToolLimitFromContext(ctx).QueryTimeout
+	if limit <= 0 {
+		return requested
+	}
+	secs := max(int(limit/time.Second), 1)
+	if requested == nil || *requested <= 0 || *requested > secs {
+		return &secs
+	}
+	return requested
+}
+
+// timezoneOr returns requested, else the server's default timezone, else def.
+func timezoneOr(ctx context.Context, requested, def string) string {
+	if requested != "" {
+		return requested
+	}
+	if tz := mcplogchef.DefaultTimezoneFromContext(ctx); tz != "" {
+		return tz
+	}
+	return def
+}
@@ -10,6 +10,7 @@ import (
 
 	mcplogchef "github.com/mr-karan/logchef-mcp"
 	"github.com/mr-karan/logchef-mcp/client"
+	"github.com/mr-karan/logchef-mcp/logformat"
 )
 
 // LogchefQL tools — query, translate, validate using Logchef's native search syntax.
@@ -20,9 +21,12 @@ type QueryLogchefQLParams struct {
 	Query        string `json:"query" jsonschema:"LogchefQL filter expression (e.g. severity_text=ERROR and service=api). Empty string returns all logs."`
 	StartTime    string `json:"start_time" jsonschema:"Start time in YYYY-MM-DD HH:MM:SS format"`
 	EndTime      string `json:"end_time" jsonschema:"End time in YYYY-MM-DD HH:MM:SS format"`
-	Limit        int    `json:"limit,omitempty" jsonschema:"Max rows to return (1-500 default 100)"`
+	Limit        int    `json:"limit,omitempty" jsonschema:"Max rows to return per page (1-500 default 100)"`
 	Timezone     string `json:"timezone,omitempty" jsonschema:"Timezone (default UTC)"`
 	QueryTimeout *int   `json:"query_timeout,omitempty" jsonschema:"Query timeout in seconds (default 60)"`
+	Cursor       string `json:"cursor,omitempty" jsonschema:"next_cursor from a previous call with the same query and time range, to fetch the next page"`
+	ShapeParams
+	FormatParams
 }
 
 type TranslateLogchefQLParams struct {
@@ -50,34 +54,80 @@ func handleQueryLogchefQL(ctx context.Context, request mcp.CallToolRequest, para
 		return mcp.NewToolResultError("logchef client not configured"), nil
 	}
 
-	limit := params.Limit
-	if limit <= 0 {
-		limit = 100
+	format, err := logformat.Parse(params.Format)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	key := queryKey("query_logchefql", params.TeamID, params.SourceID, params.Query, params.StartTime, params.EndTime, params.Timezone)
+	cur, err := decodeCursor(params.Cursor, key)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
 	}
-	if limit > 500 {
-		limit = 500
+	query, tsField := params.Query, ""
+	var notes []string
+	if cur == nil && mcplogchef.ReadBudgetFromContext(ctx).Enabled() {
+		sql, err := translateForEstimate(ctx, lc, params.TeamID, params.SourceID, params.Query, params.StartTime, params.EndTime, params.Timezone)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		note, err := preflight(ctx, lc, params.TeamID, params.SourceID, sql)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if note != "" {
+			notes = append(notes, note)
+		}
+	}
+	if cur != nil {
+		src, err := teamSource(ctx, lc, params.TeamID, params.SourceID)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		tsField = src.MetaTsField
+		query = resumeLogchefQL(query, tsField, cur)
 	}
 
+	limit := rowLimit(ctx, params.Limit, 100, 500)
 	resp, err := lc.QueryLogchefQL(ctx, params.TeamID, params.SourceID, client.LogchefQLQueryRequest{
-		Query:        params.Query,
-		Limit:        limit,
+		Query:        query,
+		Limit:        fetchLimit(limit, cur),
 		StartTime:    params.StartTime,
 		EndTime:      params.EndTime,
-		Timezone:     params.Timezone,
-		QueryTimeout: params.QueryTimeout,
+		Timezone:     timezoneOr(ctx, params.Timezone, ""),
+		QueryTimeout: queryTimeout(ctx, params.QueryTimeout),
 	})
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("logchefql query failed: %v", err)), nil
+		return mcp.NewToolResultError(toolError("logchefql query failed", err).Error()), nil
+	}
+	shape := params.ShapeParams.shaper()
+	rows, next, hasMore, err := nextPage(ctx, lc, params.TeamID, params.SourceID, resp.Data.Logs, limit, cur, key, tsField, shape)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
 	}
+	mcplogchef.RecordQuery(ctx, resp.Data.GeneratedSQL, len(rows))
 
+	columns := params.ShapeParams.columns(resp.Data.Columns)
 	result := map[string]any{
-		"logs":          resp.Data.Logs,
-		"columns":       resp.Data.Columns,
+		"columns":       columns,
 		"stats":         resp.Data.Stats,
 		"query_id":      resp.Data.QueryID,
 		"generated_sql": resp.Data.GeneratedSQL,
-		"row_count":     len(resp.Data.Logs),
+		"row_count":     len(rows),
+		"has_more":      hasMore,
+	}
+	if next != "" {
+		result["next_cursor"] = next
+	}
+	if shape.dropped > 0 {
+		result["rows_dropped"] = shape.dropped
+	}
+	if notes = append(notes, shape.notes()...); len(notes) > 0 {
+		result["notes"] = notes
+	}
+	if format != logformat.JSON {
+		return formattedLogs(format, rows, columnNames(columns), result)
 	}
+	result["logs"] = rows
 
 	out, _ := json.MarshalIndent(result, "", "  ")
 	return mcp.NewToolResultText(string(out)), nil
@@ -93,11 +143,11 @@ func handleTranslateLogchefQL(ctx context.Context, request mcp.CallToolRequest,
 		Query:     params.Query,
 		StartTime: params.StartTime,
 		EndTime:   params.EndTime,
-		Timezone:  params.Timezone,
+		Timezone:  timezoneOr(ctx, params.Timezone, ""),
 		Limit:     params.Limit,
 	})
 	if err != nil {
-		return TranslateResult{}, fmt.Errorf("logchefql translate failed: %w", err)
+		return TranslateResult{}, toolError("logchefql translate failed", err)
 	}
 
 	return TranslateResult{
@@ -127,7 +177,7 @@ func handleValidateLogchefQL(ctx context.Context, request mcp.CallToolRequest, p
 		Query: params.Query,
 	})
 	if err != nil {
-		return ValidateResult{}, fmt.Errorf("logchefql validate failed: %w", err)
+		return ValidateResult{}, toolError("logchefql validate failed", err)
 	}
 
 	return ValidateResult{
@@ -139,7 +189,7 @@ func handleValidateLogchefQL(ctx context.Context, request mcp.CallToolRequest, p
 func AddLogchefQLTools(s *server.MCPServer) {
 	// query_logchefql returns flexible log data — uses typed handler
 	queryTool := mcp.NewTool("query_logchefql",
-		mcp.WithDescription("Execute a LogchefQL query against a log source. LogchefQL is a simple filter syntax (e.g. 'severity_text=ERROR and service=api'). Time range is specified separately. Returns logs, columns, stats, and the generated SQL."),
+		mcp.WithDescription("Execute a LogchefQL query against a log source. LogchefQL is a simple filter syntax (e.g. 'severity_text=ERROR and service=api'). Time range is specified separately. Returns logs, columns, stats, and the generated SQL. When has_more is true, call again with the same arguments and cursor set to next_cursor to get the next page."),
 		mcp.WithInputSchema[QueryLogchefQLParams](),
 		mcp.WithTitleAnnotation("Query LogchefQL"),
 		mcp.WithReadOnlyHintAnnotation(true),
@@ -10,6 +10,7 @@ import (
 
 	mcplogchef "github.com/mr-karan/logchef-mcp"
 	"github.com/mr-karan/logchef-mcp/client"
+	"github.com/mr-karan/logchef-mcp/logformat"
 )
 
 // --- Input schemas ---
@@ -17,9 +18,12 @@ import (
 type QueryLogsParams struct {
 	TeamID       int    `json:"team_id" jsonschema:"The ID of the team that has access to the source"`
 	SourceID     int    `json:"source_id" jsonschema:"The ID of the source to query logs from"`
-	RawSQL       string `json:"raw_sql" jsonschema:"The ClickHouse SQL query to execute. Use get_source_schema first to understand available columns. Include WHERE clauses with timestamp filters and ORDER BY and LIMIT clauses."`
-	Limit        int    `json:"limit,omitempty" jsonschema:"Maximum number of log entries to return (1-100 default 100)"`
+	RawSQL       string `json:"raw_sql" jsonschema:"The ClickHouse SQL query to execute. Use get_source_schema first to understand available columns. Include WHERE clauses with timestamp filters and ORDER BY the timestamp DESC. Leave out LIMIT to page through all matching rows."`
+	Limit        int    `json:"limit,omitempty" jsonschema:"Maximum number of log entries to return per page (1-100 default 100)"`
 	QueryTimeout *int   `json:"query_timeout,omitempty" jsonschema:"Query timeout in seconds (default 30)"`
+	Cursor       string `json:"cursor,omitempty" jsonschema:"next_cursor from a previous call with the same query, to fetch the next page"`
+	ShapeParams
+	FormatParams
 }
 
 type GetSourceSchemaParams struct {
@@ -48,6 +52,7 @@ type CreateCollectionParams struct {
 	Name        string `json:"name" jsonschema:"Name of the collection"`
 	Description string `json:"description,omitempty" jsonschema:"Optional description of the collection"`
 	Query       string `json:"query" jsonschema:"The ClickHouse SQL query to save in the collection"`
+	DryRun      bool   `json:"dry_run,omitempty" jsonschema:"Validate the change and return the would-be result with a diff, without applying it"`
 }
 
 type GetCollectionParams struct {
@@ -63,6 +68,7 @@ type UpdateCollectionParams struct {
 	Name         string `json:"name" jsonschema:"Name of the collection"`
 	Description  string `json:"description,omitempty" jsonschema:"Optional description of the collection"`
 	Query        string `json:"query" jsonschema:"The ClickHouse SQL query to save in the collection"`
+	DryRun       bool   `json:"dry_run,omitempty" jsonschema:"Validate the change and return the would-be result with a diff, without applying it"`
 }
 
 type DeleteCollectionParams struct {
@@ -94,6 +100,19 @@ type SuccessResult struct {
 	Message string `json:"message" jsonschema:"Human-readable result message"`
 }
 
+// queryLogsResult is the JSON text returned by query_logs. Data is nil
+// when the rows are returned in another format.
+type queryLogsResult struct {
+	Data        any                  `json:"data,omitempty"`
+	Stats       client.LogQueryStats `json:"stats"`
+	Columns     []client.LogColumn   `json:"columns"`
+	QueryID     string               `json:"query_id"`
+	HasMore     bool                 `json:"has_more"`
+	NextCursor  string               `json:"next_cursor,omitempty"`
+	RowsDropped int                  `json:"rows_dropped,omitempty"`
+	Notes       []string             `json:"notes,omitempty"`
+}
+
 // --- Handlers ---
 
 // query_logs returns flexible log data — uses typed handler
@@ -103,23 +122,72 @@ func handleQueryLogs(ctx context.Context, request mcp.CallToolRequest, args Quer
 		return mcp.NewToolResultError("logchef client not configured"), nil
 	}
 
-	if args.Limit < 0 {
-		args.Limit = 0
+	if mcplogchef.ReadOnlyFromContext(ctx) && !isSelectStatement(args.RawSQL) {
+		return mcp.NewToolResultError(errReadOnlySQL.Error()), nil
+	}
+
+	format, err := logformat.Parse(args.Format)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	key := queryKey("query_logs", args.TeamID, args.SourceID, args.RawSQL)
+	cur, err := decodeCursor(args.Cursor, key)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	src, err := teamSource(ctx, c, args.TeamID, args.SourceID)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	sql, note, err := guardSQL(args.RawSQL, src.Connection.Database+"."+src.Connection.TableName, src.MetaTsField)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	var notes []string
+	if note != "" {
+		notes = append(notes, note)
 	}
-	if args.Limit > 100 {
-		args.Limit = 100
+	// Later pages wrap the query so it resumes at the cursor's timestamp.
+	// They read no more than the first page, so only that is estimated.
+	if cur != nil {
+		sql = resumeSQL(sql, src.MetaTsField, cur)
+	} else if note, err := preflight(ctx, c, args.TeamID, args.SourceID, sql); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	} else if note != "" {
+		notes = append(notes, note)
 	}
 
+	limit := rowLimit(ctx, args.Limit, 100, 100)
 	logs, err := c.QueryLogs(ctx, args.TeamID, args.SourceID, client.LogQueryRequest{
-		RawSQL:       args.RawSQL,
-		Limit:        args.Limit,
-		QueryTimeout: args.QueryTimeout,
+		RawSQL:       sql,
+		Limit:        fetchLimit(limit, cur),
+		QueryTimeout: queryTimeout(ctx, args.QueryTimeout),
 	})
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("query logs: %v", err)), nil
+		return mcp.NewToolResultError(toolError("query logs", err).Error()), nil
 	}
-
-	out, _ := json.MarshalIndent(logs.Data, "", "  ")
+	shape := args.ShapeParams.shaper()
+	rows, next, hasMore, err := nextPage(ctx, c, args.TeamID, args.SourceID, logs.Data.Data, limit, cur, key, src.MetaTsField, shape)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	mcplogchef.RecordQuery(ctx, sql, len(rows))
+
+	columns := args.ShapeParams.columns(logs.Data.Columns)
+	result := queryLogsResult{
+		Stats:       logs.Data.Stats,
+		Columns:     columns,
+		QueryID:     logs.Data.QueryID,
+		HasMore:     hasMore,
+		NextCursor:  next,
+		RowsDropped: shape.dropped,
+		Notes:       append(notes, shape.notes()...),
+	}
+	if format != logformat.JSON {
+		return formattedLogs(format, rows, columnNames(columns), result)
+	}
+	result.Data = rows
+	out, _ := json.MarshalIndent(result, "", "  ")
 	return mcp.NewToolResultText(string(out)), nil
 }
 
@@ -131,7 +199,7 @@ func handleGetSourceSchema(ctx context.Context, request mcp.CallToolRequest, arg
 
 	schema, err := c.GetSourceSchema(ctx, args.TeamID, args.SourceID)
 	if err != nil {
-		return nil, fmt.Errorf("get source schema: %w", err)
+		return nil, toolError("get source schema", err)
 	}
 
 	result := make([]SchemaColumnResult, len(schema.Data))
@@ -148,16 +216,21 @@ func handleGetLogHistogram(ctx context.Context, request mcp.CallToolRequest, arg
 		return mcp.NewToolResultError("logchef client not configured"), nil
 	}
 
+	if mcplogchef.ReadOnlyFromContext(ctx) && !isSelectStatement(args.RawSQL) {
+		return mcp.NewToolResultError(errReadOnlySQL.Error()), nil
+	}
+
 	histogram, err := c.GetLogHistogram(ctx, args.TeamID, args.SourceID, client.HistogramRequest{
 		RawSQL:       args.RawSQL,
 		Window:       args.Window,
 		GroupBy:      args.GroupBy,
-		Timezone:     args.Timezone,
-		QueryTimeout: args.QueryTimeout,
+		Timezone:     timezoneOr(ctx, args.Timezone, ""),
+		QueryTimeout: queryTimeout(ctx, args.QueryTimeout),
 	})
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("get log histogram: %v", err)), nil
+		return mcp.NewToolResultError(toolError("get log histogram", err).Error()), nil
 	}
+	mcplogchef.RecordQuery(ctx, args.RawSQL, len(histogram.Data.Data))
 
 	out, _ := json.MarshalIndent(histogram.Data, "", "  ")
 	return mcp.NewToolResultText(string(out)), nil
@@ -171,7 +244,7 @@ func handleGetCollections(ctx context.Context, request mcp.CallToolRequest, args
 
 	collections, err := c.GetCollections(ctx, args.TeamID, args.SourceID)
 	if err != nil {
-		return nil, fmt.Errorf("get collections: %w", err)
+		return nil, toolError("get collections", err)
 	}
 
 	result := make([]CollectionResult, len(collections.Data))
@@ -181,20 +254,23 @@ func handleGetCollections(ctx context.Context, request mcp.CallToolRequest, args
 	return result, nil
 }
 
-func handleCreateCollection(ctx context.Context, request mcp.CallToolRequest, args CreateCollectionParams) (CollectionResult, error) {
+func handleCreateCollection(ctx context.Context, request mcp.CallToolRequest, args CreateCollectionParams) (CollectionChangeResult, error) {
 	c := mcplogchef.LogchefClientFromContext(ctx)
 	if c == nil {
-		return CollectionResult{}, fmt.Errorf("logchef client not configured")
+		return CollectionChangeResult{}, fmt.Errorf("logchef client not configured")
+	}
+	if args.DryRun {
+		return planCreateCollection(ctx, c, args)
 	}
 
 	collection, err := c.CreateCollection(ctx, args.TeamID, args.SourceID, client.CollectionRequest{
 		Name: args.Name, Description: args.Description, Query: args.Query,
 	})
 	if err != nil {
-		return CollectionResult{}, fmt.Errorf("create collection: %w", err)
+		return CollectionChangeResult{}, toolError("create collection", err)
 	}
 
-	return collectionToResult(collection.Data), nil
+	return CollectionChangeResult{CollectionResult: collectionToResult(collection.Data)}, nil
 }
 
 func handleGetCollection(ctx context.Context, request mcp.CallToolRequest, args GetCollectionParams) (CollectionResult, error) {
@@ -205,26 +281,29 @@ func handleGetCollection(ctx context.Context, request mcp.CallToolRequest, args
 
 	collection, err := c.GetCollection(ctx, args.TeamID, args.SourceID, args.CollectionID)
 	if err != nil {
-		return CollectionResult{}, fmt.Errorf("get collection: %w", err)
+		return CollectionResult{}, toolError("get collection", err)
 	}
 
 	return collectionToResult(collection.Data), nil
 }
 
-func handleUpdateCollection(ctx context.Context, request mcp.CallToolRequest, args UpdateCollectionParams) (CollectionResult, error) {
+func handleUpdateCollection(ctx context.Context, request mcp.CallToolRequest, args UpdateCollectionParams) (CollectionChangeResult, error) {
 	c := mcplogchef.LogchefClientFromContext(ctx)
 	if c == nil {
-		return CollectionResult{}, fmt.Errorf("logchef client not configured")
+		return CollectionChangeResult{}, fmt.Errorf("logchef client not configured")
+	}
+	if args.DryRun {
+		return planUpdateCollection(ctx, c, args)
 	}
 
 	collection, err := c.UpdateCollection(ctx, args.TeamID, args.SourceID, args.CollectionID, client.CollectionRequest{
 		Name: args.Name, Description: args.Description, Query: args.Query,
 	})
 	if err != nil {
-		return CollectionResult{}, fmt.Errorf("update collection: %w", err)
+		return CollectionChangeResult{}, toolError("update collection", err)
 	}
 
-	return collectionToResult(collection.Data), nil
+	return CollectionChangeResult{CollectionResult: collectionToResult(collection.Data)}, nil
 }
 
 func handleDeleteCollection(ctx context.Context, request mcp.CallToolRequest, args DeleteCollectionParams) (SuccessResult, error) {
@@ -234,7 +313,7 @@ func handleDeleteCollection(ctx context.Context, request mcp.CallToolRequest, ar
 	}
 
 	if err := c.DeleteCollection(ctx, args.TeamID, args.SourceID, args.CollectionID); err != nil {
-		return SuccessResult{}, fmt.Errorf("delete collection: %w", err)
+		return SuccessResult{}, toolError("delete collection", err)
 	}
 
 	return SuccessResult{Success: true, Message: "Collection deleted successfully"}, nil
@@ -251,13 +330,22 @@ func collectionToResult(c client.Collection) CollectionResult {
 func AddLogsTools(s *server.MCPServer) {
 	// query_logs returns flexible log data — typed handler
 	queryLogsTool := mcp.NewTool("query_logs",
-		mcp.WithDescription("Execute a ClickHouse SQL query against a specific log source within a team. Use get_source_schema first to understand available columns. The query should include proper WHERE clauses with timestamp filters, ORDER BY, and LIMIT. Maximum 100 results per query."),
+		mcp.WithDescription("Execute a ClickHouse SQL query against a specific log source within a team. Use get_source_schema first to understand available columns. The query must be a single SELECT that reads only from the source's table (database.table from get_sources), and should filter on the source's timestamp field and ORDER BY it DESC; without a timestamp filter only the last hour is queried. Returns up to 100 rows per page; when has_more is true, call again with the same query and cursor set to next_cursor to get the next page."),
 		mcp.WithInputSchema[QueryLogsParams](),
 		mcp.WithTitleAnnotation("Query Logs (SQL)"),
 		mcp.WithReadOnlyHintAnnotation(true),
 	)
 	s.AddTool(queryLogsTool, mcp.NewTypedToolHandler(handleQueryLogs))
 
+	estimateTool := mcp.NewTool("estimate_query",
+		mcp.WithDescription("Estimate how many rows, parts and marks ClickHouse would read for a query_logs SQL query or a query_logchefql filter, using EXPLAIN ESTIMATE, without running it. Reports whether the query is over the configured read budget. Use this before querying large time ranges."),
+		mcp.WithInputSchema[EstimateQueryParams](),
+		mcp.WithOutputSchema[EstimateResult](),
+		mcp.WithTitleAnnotation("Estimate Query Cost"),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+	s.AddTool(estimateTool, mcp.NewStructuredToolHandler(handleEstimateQuery))
+
 	schemaTool := mcp.NewTool("get_source_schema",
 		mcp.WithDescription("Get the ClickHouse table schema (column names and types) for a specific log source within a team. Use this before querying logs to understand what fields are available."),
 		mcp.WithInputSchema[GetSourceSchemaParams](),
@@ -288,7 +376,7 @@ func AddLogsTools(s *server.MCPServer) {
 	createCollectionTool := mcp.NewTool("create_collection",
 		mcp.WithDescription("Create a new saved query collection for a specific team and source. Provide a name, optional description, and the ClickHouse SQL query to save."),
 		mcp.WithInputSchema[CreateCollectionParams](),
-		mcp.WithOutputSchema[CollectionResult](),
+		mcp.WithOutputSchema[CollectionChangeResult](),
 		mcp.WithTitleAnnotation("Create Collection"),
 		mcp.WithDestructiveHintAnnotation(false),
 	)
@@ -306,7 +394,7 @@ func AddLogsTools(s *server.MCPServer) {
 	updateCollectionTool := mcp.NewTool("update_collection",
 		mcp.WithDescription("Update an existing saved query collection. All fields are required - provide the current values for fields you don't want to change."),
 		mcp.WithInputSchema[UpdateCollectionParams](),
-		mcp.WithOutputSchema[CollectionResult](),
+		mcp.WithOutputSchema[CollectionChangeResult](),
 		mcp.WithTitleAnnotation("Update Collection"),
 		mcp.WithDestructiveHintAnnotation(false),
 	)
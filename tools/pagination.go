@@ -0,0 +1,202 @@
+package tools
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/mr-karan/logchef-mcp/client"
+)
+
+// logCursor marks where the next page of a log query starts. Pages follow
+// the source's timestamp field, newest first. Rows sharing the timestamp of
+// the last row returned are told apart by a hash of their contents, so
+// pages neither skip nor repeat them.
+type logCursor struct {
+	// Query identifies the query the cursor belongs to.
+	Query string `json:"q"`
+	// TS is the timestamp of the last row returned.
+	TS string `json:"ts"`
+	// Seen holds the hashes of the rows at TS already returned.
+	Seen []string `json:"seen,omitempty"`
+}
+
+// maxCursorSeen bounds how many rows sharing one timestamp a cursor can
+// page through. Each is kept in the cursor and fetched again for every
+// later page, so beyond this the time range has to be narrowed instead.
+const maxCursorSeen = 1000
+
+// cursorTS matches the timestamps a cursor may hold: ClickHouse DateTime
+// and DateTime64 values as Logchef returns them, optionally in RFC 3339.
+var cursorTS = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}[ T]\d{2}:\d{2}:\d{2}(\.\d{1,9})?(Z|[+-]\d{2}:?\d{2})?$`)
+
+func (c logCursor) encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// decodeCursor parses a cursor from a previous page of query. An empty
+// string is the first page and returns nil.
+func decodeCursor(s, query string) (*logCursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	var c logCursor
+	if err == nil {
+		err = json.Unmarshal(b, &c)
+	}
+	// TS ends up in SQL and LogchefQL, so only timestamps are accepted.
+	if err != nil || !cursorTS.MatchString(c.TS) || len(c.Seen) > maxCursorSeen {
+		return nil, fmt.Errorf("invalid cursor; pass next_cursor from the previous page unchanged")
+	}
+	if c.Query != query {
+		return nil, fmt.Errorf("cursor belongs to a different query; rerun the query without a cursor")
+	}
+	return &c, nil
+}
+
+// queryKey identifies a query by its arguments, excluding the page size.
+func queryKey(parts ...any) string {
+	h := fnv.New64a()
+	for _, p := range parts {
+		fmt.Fprintf(h, "%v\x00", p)
+	}
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+func rowHash(row client.LogEntry) string {
+	b, _ := json.Marshal(row)
+	h := fnv.New64a()
+	h.Write(b)
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+// fetchLimit is how many rows to request for a page of limit rows: enough
+// to skip the rows already returned and to tell whether more follow.
+func fetchLimit(limit int, cur *logCursor) int {
+	if cur == nil {
+		return limit + 1
+	}
+	return limit + len(cur.Seen) + 1
+}
+
+// skipSeen drops the rows cur says were already returned.
+func skipSeen(rows []client.LogEntry, tsField string, cur *logCursor) []client.LogEntry {
+	seen := slices.Clone(cur.Seen)
+	return slices.DeleteFunc(rows, func(row client.LogEntry) bool {
+		if ts, _ := row[tsField].(string); ts != cur.TS {
+			return false
+		}
+		i := slices.Index(seen, rowHash(row))
+		if i < 0 {
+			return false
+		}
+		seen = slices.Delete(seen, i, i+1)
+		return true
+	})
+}
+
+// cursorAfter returns the cursor for the rows following page. It is empty
+// when page lacks tsField, holds values of it that are not timestamps, or
+// is not sorted by it newest first, since a cursor could not resume it
+// reliably. It fails when more than maxCursorSeen rows share the timestamp
+// of the last row.
+func cursorAfter(page []client.LogEntry, tsField string, cur *logCursor, query string) (string, error) {
+	var prev string
+	for _, row := range page {
+		ts, ok := row[tsField].(string)
+		if !ok || !cursorTS.MatchString(ts) || (prev != "" && ts > prev) {
+			return "", nil
+		}
+		prev = ts
+	}
+	c := logCursor{Query: query, TS: prev}
+	if cur != nil && cur.TS == prev {
+		c.Seen = slices.Clone(cur.Seen)
+	}
+	for _, row := range page {
+		if row[tsField] == prev {
+			c.Seen = append(c.Seen, rowHash(row))
+		}
+	}
+	if len(c.Seen) > maxCursorSeen {
+		return "", fmt.Errorf("more than %d rows share the timestamp %s, too many to page through; narrow the time range or add filters so fewer rows match", maxCursorSeen, prev)
+	}
+	return c.encode(), nil
+}
+
+// teamSource returns a source the team can access.
+func teamSource(ctx context.Context, c *client.Client, teamID, sourceID int) (*client.SourceResponse, error) {
+	sources, err := c.GetTeamSources(ctx, teamID)
+	if err != nil {
+		return nil, toolError("get team sources", err)
+	}
+	for _, s := range sources.Data {
+		if s.ID == sourceID {
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf("source %d not found for team %d; use get_sources to find accessible IDs", sourceID, teamID)
+}
+
+// sqlQuoter escapes a value for a single-quoted ClickHouse string literal.
+var sqlQuoter = strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+
+// resumeSQL wraps sql so it only returns rows at or before the cursor.
+func resumeSQL(sql, tsField string, cur *logCursor) string {
+	return fmt.Sprintf("SELECT * FROM (\n%s\n) WHERE `%s` <= '%s' ORDER BY `%s` DESC",
+		trimStatement(sql), tsField, sqlQuoter.Replace(cur.TS), tsField)
+}
+
+// resumeLogchefQL narrows query to rows at or before the cursor.
+func resumeLogchefQL(query, tsField string, cur *logCursor) string {
+	cond := fmt.Sprintf("%s <= %q", tsField, cur.TS)
+	if strings.TrimSpace(query) == "" {
+		return cond
+	}
+	return "(" + query + ") and " + cond
+}
+
+// nextPage cuts rows, fetched with fetchLimit, to a page of at most limit
+// rows and shapes it. Rows the shaper drops to stay within its byte budget
+// start the next page. The source's timestamp field is looked up when
+// tsField is empty and a cursor is needed.
+func nextPage(ctx context.Context, c *client.Client, teamID, sourceID int, rows []client.LogEntry, limit int, cur *logCursor, query, tsField string, shape *rowShaper) (page []client.LogEntry, next string, hasMore bool, err error) {
+	lookup := func() error {
+		if tsField == "" {
+			tsField, err = sourceTsField(ctx, c, teamID, sourceID)
+		}
+		return err
+	}
+	if cur != nil {
+		if err := lookup(); err != nil {
+			return nil, "", false, err
+		}
+		rows = skipSeen(rows, tsField, cur)
+	}
+	page = shape.rows(rows[:min(limit, len(rows))])
+	if len(page) == len(rows) {
+		return page, "", false, nil
+	}
+	if err := lookup(); err != nil {
+		return nil, "", false, err
+	}
+	if next, err = cursorAfter(rows[:len(page)], tsField, cur, query); err != nil {
+		return nil, "", false, err
+	}
+	return page, next, true, nil
+}
+
+func sourceTsField(ctx context.Context, c *client.Client, teamID, sourceID int) (string, error) {
+	src, err := teamSource(ctx, c, teamID, sourceID)
+	if err != nil {
+		return "", err
+	}
+	return src.MetaTsField, nil
+}
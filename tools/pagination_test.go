@@ -0,0 +1,98 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/mr-karan/logchef-mcp/client"
+)
+
+func TestDecodeCursor(t *testing.T) {
+	const query = "q"
+	for _, ts := range []string{"2026-03-01 10:03:00", "2026-03-01 10:03:00.123", "2026-03-01T10:03:00.123456Z", "2026-03-01T10:03:00+05:30"} {
+		if _, err := decodeCursor(logCursor{Query: query, TS: ts}.encode(), query); err != nil {
+			t.Errorf("cursor at %q: %v", ts, err)
+		}
+	}
+	for _, ts := range []string{"", "x\\' OR 1 UNION ALL SELECT name FROM system.users --", "2026-03-01 10:03:00' OR 1 --", `2026-03-01 10:03:00" or level!="x`} {
+		if _, err := decodeCursor(logCursor{Query: query, TS: ts}.encode(), query); err == nil {
+			t.Errorf("cursor at %q accepted", ts)
+		}
+	}
+	if _, err := decodeCursor(logCursor{Query: "other", TS: "2026-03-01 10:03:00"}.encode(), query); err == nil {
+		t.Error("cursor for another query accepted")
+	}
+}
+
+func TestResumeSQLEscapes(t *testing.T) {
+	got := resumeSQL("SELECT * FROM logs.app", "timestamp", &logCursor{TS: `a\' b`})
+	if !strings.Contains(got, "<= 'a\\\\\\' b'") {
+		t.Errorf("resumeSQL = %q", got)
+	}
+}
+
+// pageThrough pages through rows, sorted newest first, as Logchef would
+// serve them for resumed queries, and returns the messages of every page.
+func pageThrough(rows []client.LogEntry, limit int) ([]string, error) {
+	const query = "q"
+	var got []string
+	var next string
+	for {
+		cur, err := decodeCursor(next, query)
+		if err != nil {
+			return got, err
+		}
+		fetched := rows
+		if cur != nil {
+			fetched = nil
+			for _, row := range rows {
+				if row["timestamp"].(string) <= cur.TS {
+					fetched = append(fetched, row)
+				}
+			}
+			fetched = skipSeen(fetched[:min(fetchLimit(limit, cur), len(fetched))], "timestamp", cur)
+		}
+		page := fetched[:min(limit, len(fetched))]
+		for _, row := range page {
+			got = append(got, row["message"].(string))
+		}
+		if len(page) == len(fetched) {
+			return got, nil
+		}
+		if next, err = cursorAfter(page, "timestamp", cur, query); err != nil {
+			return got, err
+		}
+	}
+}
+
+func TestCursorSharedTimestamp(t *testing.T) {
+	sameSecond := func(n int) []client.LogEntry {
+		rows := make([]client.LogEntry, n, n+1)
+		for i := range rows {
+			rows[i] = client.LogEntry{"timestamp": "2026-03-01 10:03:00", "message": fmt.Sprint(i)}
+		}
+		return append(rows, client.LogEntry{"timestamp": "2026-03-01 10:02:00", "message": "older"})
+	}
+
+	// Seven rows at one timestamp span three pages of two.
+	got, err := pageThrough(sameSecond(7), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "0 1 2 3 4 5 6 older"; strings.Join(got, " ") != want {
+		t.Errorf("pages = %v, want %s", got, want)
+	}
+
+	got, err = pageThrough(sameSecond(maxCursorSeen+200), 100)
+	if err == nil || !strings.Contains(err.Error(), "narrow the time range") {
+		t.Errorf("paging past %d rows at one timestamp: %v", maxCursorSeen, err)
+	}
+	if len(got) > maxCursorSeen+100 {
+		t.Errorf("returned %d rows before failing", len(got))
+	}
+	seen := logCursor{Query: "q", TS: "2026-03-01 10:03:00", Seen: make([]string, maxCursorSeen+1)}
+	if _, err := decodeCursor(seen.encode(), "q"); err == nil {
+		t.Error("cursor over the limit accepted")
+	}
+}
@@ -69,7 +69,7 @@ func handleGetProfile(ctx context.Context, request mcp.CallToolRequest, args Get
 
 	profile, err := c.GetProfile(ctx)
 	if err != nil {
-		return ProfileResult{}, fmt.Errorf("get profile: %w", err)
+		return ProfileResult{}, toolError("get profile", err)
 	}
 
 	d := profile.Data
@@ -102,7 +102,7 @@ func handleGetTeams(ctx context.Context, request mcp.CallToolRequest, args GetTe
 
 	teams, err := c.GetTeams(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("get teams: %w", err)
+		return nil, toolError("get teams", err)
 	}
 
 	result := make([]TeamResult, len(teams.Data))
@@ -127,7 +127,7 @@ func handleGetMeta(ctx context.Context, request mcp.CallToolRequest, args GetMet
 
 	meta, err := c.GetMeta(ctx)
 	if err != nil {
-		return MetaResult{}, fmt.Errorf("get meta: %w", err)
+		return MetaResult{}, toolError("get meta", err)
 	}
 
 	return MetaResult{
@@ -62,7 +62,7 @@ func handleSourceSchemaResource(ctx context.Context, request mcp.ReadResourceReq
 
 	schema, err := c.GetSourceSchema(ctx, teamID, sourceID)
 	if err != nil {
-		return nil, fmt.Errorf("get source schema: %w", err)
+		return nil, toolError("get source schema", err)
 	}
 
 	out, _ := json.MarshalIndent(schema.Data, "", "  ")
@@ -88,7 +88,7 @@ func handleCollectionsListResource(ctx context.Context, request mcp.ReadResource
 
 	collections, err := c.GetCollections(ctx, teamID, sourceID)
 	if err != nil {
-		return nil, fmt.Errorf("get collections: %w", err)
+		return nil, toolError("get collections", err)
 	}
 
 	out, _ := json.MarshalIndent(collections.Data, "", "  ")
@@ -114,7 +114,7 @@ func handleCollectionResource(ctx context.Context, request mcp.ReadResourceReque
 
 	collection, err := c.GetCollection(ctx, teamID, sourceID, collectionID)
 	if err != nil {
-		return nil, fmt.Errorf("get collection: %w", err)
+		return nil, toolError("get collection", err)
 	}
 
 	out, _ := json.MarshalIndent(collection.Data, "", "  ")
@@ -0,0 +1,155 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mr-karan/logchef-mcp/client"
+	"github.com/mr-karan/logchef-mcp/logformat"
+)
+
+const (
+	defaultMaxFieldLength = 2000
+	defaultMaxBytes       = 200_000
+)
+
+// ShapeParams are the output options shared by the tools that return log
+// rows, embedded in their params. Rows are flattened, projected and
+// truncated in that order, then cut to the byte budget.
+type ShapeParams struct {
+	Columns        []string `json:"columns,omitempty" jsonschema:"Only return these fields of each row (default all). With flatten, a dotted name selects a nested field and a map name selects all the fields under it."`
+	ExcludeColumns []string `json:"exclude_columns,omitempty" jsonschema:"Leave these fields out of each row, matched like columns"`
+	MaxFieldLength int      `json:"max_field_length,omitempty" jsonschema:"Cut string values longer than this many characters and mark the cut with [truncated N chars] (default 2000)"`
+	Flatten        bool     `json:"flatten,omitempty" jsonschema:"Flatten nested maps such as attributes into dotted keys (attributes.http.status)"`
+	MaxBytes       int      `json:"max_bytes,omitempty" jsonschema:"Budget for the rows in the response in bytes of JSON (default 200000). Rows past it are left out and counted in rows_dropped."`
+}
+
+// rowShaper applies ShapeParams to the rows of one response, keeping count
+// of what it cut.
+type rowShaper struct {
+	ShapeParams
+	left      int // budget left, in bytes
+	kept      int // rows returned so far
+	truncated int // string values cut to MaxFieldLength
+	dropped   int // rows left out for the budget
+}
+
+func (p ShapeParams) shaper() *rowShaper {
+	s := &rowShaper{ShapeParams: p}
+	if s.MaxFieldLength <= 0 {
+		s.MaxFieldLength = defaultMaxFieldLength
+	}
+	if s.MaxBytes <= 0 {
+		s.MaxBytes = defaultMaxBytes
+	}
+	s.left = s.MaxBytes
+	return s
+}
+
+// rows shapes rows and keeps those that fit the budget left. Once a row
+// does not fit, the rest are dropped too, so the rows kept are a prefix and
+// a page can resume after them. The first row of a response is always kept.
+func (s *rowShaper) rows(rows []client.LogEntry) []client.LogEntry {
+	out := make([]client.LogEntry, 0, len(rows))
+	for i, row := range rows {
+		row, cut := s.row(row)
+		b, _ := json.Marshal(row)
+		if s.kept > 0 && len(b) > s.left {
+			s.dropped += len(rows) - i
+			break
+		}
+		s.left -= len(b)
+		s.kept++
+		s.truncated += cut
+		out = append(out, row)
+	}
+	return out
+}
+
+// row returns a shaped copy of row and the number of values it cut.
+func (s *rowShaper) row(row client.LogEntry) (client.LogEntry, int) {
+	if s.Flatten {
+		row = logformat.Flatten(row)
+	}
+	out := make(client.LogEntry, len(row))
+	cut := 0
+	for k, v := range row {
+		if s.keep(k) {
+			out[k] = truncateValue(v, s.MaxFieldLength, &cut)
+		}
+	}
+	return out, cut
+}
+
+// keep reports whether a row field is returned.
+func (p ShapeParams) keep(key string) bool {
+	if slices.ContainsFunc(p.ExcludeColumns, func(c string) bool { return underColumn(key, c) }) {
+		return false
+	}
+	return len(p.Columns) == 0 || slices.ContainsFunc(p.Columns, func(c string) bool { return underColumn(key, c) })
+}
+
+// columns filters the column metadata of a result to the columns that still
+// have fields in its rows.
+func (p ShapeParams) columns(cols []client.LogColumn) []client.LogColumn {
+	return slices.DeleteFunc(slices.Clone(cols), func(col client.LogColumn) bool {
+		if slices.ContainsFunc(p.ExcludeColumns, func(c string) bool { return underColumn(col.Name, c) }) {
+			return true
+		}
+		return len(p.Columns) > 0 && !slices.ContainsFunc(p.Columns, func(c string) bool {
+			return underColumn(col.Name, c) || (p.Flatten && underColumn(c, col.Name))
+		})
+	})
+}
+
+// notes explains what was cut from the response.
+func (s *rowShaper) notes() []string {
+	var notes []string
+	if s.truncated > 0 {
+		notes = append(notes, fmt.Sprintf("%d values were cut to max_field_length (%d characters)", s.truncated, s.MaxFieldLength))
+	}
+	if s.dropped > 0 {
+		notes = append(notes, fmt.Sprintf("%d rows were left out to stay within max_bytes (%d bytes); select fewer columns or raise max_bytes", s.dropped, s.MaxBytes))
+	}
+	return notes
+}
+
+// underColumn reports whether key is column or a field nested in it.
+func underColumn(key, column string) bool {
+	return key == column || strings.HasPrefix(key, column+".")
+}
+
+// truncateValue cuts the strings in v to limit characters, counting each cut
+// in cut. Maps and slices are copied rather than changed in place.
+func truncateValue(v any, limit int, cut *int) any {
+	switch v := v.(type) {
+	case string:
+		n := utf8.RuneCountInString(v)
+		if n <= limit {
+			return v
+		}
+		*cut++
+		i := 0
+		for range limit {
+			_, size := utf8.DecodeRuneInString(v[i:])
+			i += size
+		}
+		return fmt.Sprintf("%s…[truncated %d chars]", v[:i], n-limit)
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, e := range v {
+			out[k] = truncateValue(e, limit, cut)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, e := range v {
+			out[i] = truncateValue(e, limit, cut)
+		}
+		return out
+	}
+	return v
+}
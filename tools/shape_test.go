@@ -0,0 +1,102 @@
+package tools
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/mr-karan/logchef-mcp/client"
+)
+
+func TestShapeRow(t *testing.T) {
+	row := client.LogEntry{
+		"timestamp": "2026-03-01 10:00:00",
+		"message":   "héllo wörld",
+		"attributes": map[string]any{
+			"http":   map[string]any{"status": 500.0, "path": "/orders"},
+			"tags":   []any{"a", "long tag"},
+			"secret": "x",
+		},
+	}
+	tests := []struct {
+		name  string
+		shape ShapeParams
+		want  client.LogEntry
+		cut   int
+	}{
+		{
+			name:  "truncate",
+			shape: ShapeParams{MaxFieldLength: 5, Columns: []string{"message", "attributes"}},
+			want: client.LogEntry{
+				"message": "héllo…[truncated 6 chars]",
+				"attributes": map[string]any{
+					"http":   map[string]any{"status": 500.0, "path": "/orde…[truncated 2 chars]"},
+					"tags":   []any{"a", "long …[truncated 3 chars]"},
+					"secret": "x",
+				},
+			},
+			cut: 3,
+		},
+		{
+			name:  "flatten and select nested",
+			shape: ShapeParams{Flatten: true, Columns: []string{"attributes.http", "message"}},
+			want: client.LogEntry{
+				"message":                "héllo wörld",
+				"attributes.http.status": 500.0,
+				"attributes.http.path":   "/orders",
+			},
+		},
+		{
+			name:  "exclude",
+			shape: ShapeParams{Flatten: true, ExcludeColumns: []string{"attributes.http", "attributes.tags", "timestamp"}},
+			want: client.LogEntry{
+				"message":           "héllo wörld",
+				"attributes.secret": "x",
+			},
+		},
+		{
+			name:  "exclude without flatten",
+			shape: ShapeParams{ExcludeColumns: []string{"attributes.http", "attributes"}},
+			want:  client.LogEntry{"timestamp": "2026-03-01 10:00:00", "message": "héllo wörld"},
+		},
+	}
+	for _, tt := range tests {
+		got, cut := tt.shape.shaper().row(row)
+		if !reflect.DeepEqual(got, tt.want) || cut != tt.cut {
+			t.Errorf("%s: row = %v (%d cut), want %v (%d cut)", tt.name, got, cut, tt.want, tt.cut)
+		}
+	}
+	if row["attributes"].(map[string]any)["http"].(map[string]any)["path"] != "/orders" {
+		t.Error("shaping changed the original row")
+	}
+}
+
+func TestShapeRowsBudget(t *testing.T) {
+	rows := make([]client.LogEntry, 5)
+	for i := range rows {
+		rows[i] = client.LogEntry{"n": float64(i)}
+	}
+	size := len(mustJSON(rows[0]))
+
+	s := ShapeParams{MaxBytes: 2*size + 1}.shaper()
+	if got := s.rows(rows); len(got) != 2 || s.dropped != 3 {
+		t.Errorf("rows kept = %d, dropped = %d; want 2, 3", len(got), s.dropped)
+	}
+	// The budget is shared by later calls, and once spent drops whole lists.
+	if got := s.rows(rows[:1]); len(got) != 0 || s.dropped != 4 {
+		t.Errorf("after budget spent: kept %d, dropped %d", len(got), s.dropped)
+	}
+
+	s = ShapeParams{MaxBytes: 1}.shaper()
+	if got := s.rows(rows); len(got) != 1 {
+		t.Errorf("rows kept with a tiny budget = %d, want the first row", len(got))
+	}
+}
+
+func mustJSON(v any) []byte {
+	b, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
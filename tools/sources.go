@@ -1,8 +1,10 @@
 package tools
 
 import (
+	"cmp"
 	"context"
 	"fmt"
+	"slices"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
@@ -64,7 +66,7 @@ func handleGetTeamSources(ctx context.Context, request mcp.CallToolRequest, args
 
 	sources, err := c.GetTeamSources(ctx, args.TeamID)
 	if err != nil {
-		return nil, fmt.Errorf("get team sources: %w", err)
+		return nil, toolError("get team sources", err)
 	}
 
 	result := make([]SourceResult, len(sources.Data))
@@ -95,7 +97,7 @@ func handleGetSources(ctx context.Context, request mcp.CallToolRequest, args Get
 
 	teamsResp, err := c.GetTeams(ctx)
 	if err != nil {
-		return SourcesAggregateResult{}, fmt.Errorf("get user teams: %w", err)
+		return SourcesAggregateResult{}, toolError("get user teams", err)
 	}
 
 	// Fetch sources for all teams in parallel to avoid N+1.
@@ -150,6 +152,7 @@ func handleGetSources(ctx context.Context, request mcp.CallToolRequest, args Get
 	for _, entry := range sourceMap {
 		sources = append(sources, *entry)
 	}
+	slices.SortFunc(sources, func(a, b SourceWithTeamsResult) int { return cmp.Compare(a.ID, b.ID) })
 
 	return SourcesAggregateResult{Sources: sources}, nil
 }
@@ -0,0 +1,429 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// errReadOnlySQL is returned when a read-only server is asked to run a
+// statement other than SELECT.
+var errReadOnlySQL = errors.New("server is in read-only mode: raw_sql must be a single SELECT statement (no INSERT, ALTER, DROP or multiple statements)")
+
+// stripSQL removes comments from sql and blanks out the contents of string
+// literals and quoted identifiers, so keywords and statement separators can
+// be found with plain string operations.
+func stripSQL(sql string) string {
+	var b strings.Builder
+	for i := 0; i < len(sql); i++ {
+		ch := sql[i]
+		switch {
+		case ch == '-' && i+1 < len(sql) && sql[i+1] == '-', ch == '#':
+			for i < len(sql) && sql[i] != '\n' {
+				i++
+			}
+			b.WriteByte(' ')
+		case ch == '/' && i+1 < len(sql) && sql[i+1] == '*':
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				return b.String()
+			}
+			i += end + 3
+			b.WriteByte(' ')
+		case ch == '\'' || ch == '"' || ch == '`':
+			b.WriteByte(ch)
+			for i++; i < len(sql) && sql[i] != ch; i++ {
+				if sql[i] == '\\' {
+					i++
+				}
+			}
+			b.WriteByte(ch)
+		default:
+			b.WriteByte(ch)
+		}
+	}
+	return b.String()
+}
+
+// isSelectStatement reports whether sql is a single SELECT statement,
+// optionally introduced by WITH or wrapped in parentheses. A trailing
+// semicolon is allowed; anything after it is not.
+func isSelectStatement(sql string) bool {
+	s := strings.TrimSpace(stripSQL(sql))
+	s = strings.TrimSpace(strings.TrimSuffix(s, ";"))
+	if strings.Contains(s, ";") {
+		return false
+	}
+	s = strings.TrimLeft(s, "( \t\r\n")
+	keyword, _, _ := strings.Cut(s, " ")
+	keyword, _, _ = strings.Cut(keyword, "\n")
+	switch strings.ToUpper(strings.TrimSpace(keyword)) {
+	case "SELECT", "WITH":
+		return true
+	}
+	return false
+}
+
+// trimStatement strips surrounding space and a trailing semicolon from sql,
+// so it can be embedded in another statement.
+func trimStatement(sql string) string {
+	return strings.TrimRight(strings.TrimSpace(sql), "; \t\r\n")
+}
+
+// defaultSQLWindow is the time window added to raw_sql that has no condition
+// on the source's timestamp field.
+const defaultSQLWindow = "1 HOUR"
+
+// sqlToken is a lexical token of a ClickHouse query. Comments are dropped,
+// and string literals and quoted identifiers are single tokens.
+type sqlToken struct {
+	text  string // identifier or keyword text without quotes; punctuation as is
+	kind  sqlTokenKind
+	pos   int // byte offsets in the query
+	end   int
+	depth int // parenthesis nesting; a "(" and its ")" share the outer depth
+}
+
+type sqlTokenKind int
+
+const (
+	sqlWord   sqlTokenKind = iota // keyword or bare identifier
+	sqlQuoted                     // `identifier` or "identifier"
+	sqlString                     // 'literal'
+	sqlNumber
+	sqlPunct
+)
+
+// keyword reports whether t is the bare word kw, case-insensitively.
+func (t sqlToken) keyword(kw string) bool {
+	return t.kind == sqlWord && strings.EqualFold(t.text, kw)
+}
+
+func (t sqlToken) ident() bool { return t.kind == sqlWord || t.kind == sqlQuoted }
+
+// lexSQL splits sql into tokens. It does not validate the query.
+func lexSQL(sql string) []sqlToken {
+	var toks []sqlToken
+	depth := 0
+	for i := 0; i < len(sql); {
+		ch := sql[i]
+		start := i
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			i++
+			continue
+		case ch == '-' && i+1 < len(sql) && sql[i+1] == '-', ch == '#':
+			for i < len(sql) && sql[i] != '\n' {
+				i++
+			}
+			continue
+		case ch == '/' && i+1 < len(sql) && sql[i+1] == '*':
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				return toks
+			}
+			i += end + 4
+			continue
+		case ch == '\'' || ch == '"' || ch == '`':
+			for i++; i < len(sql) && sql[i] != ch; i++ {
+				if sql[i] == '\\' {
+					i++
+				}
+			}
+			i = min(i+1, len(sql))
+			kind := sqlQuoted
+			if ch == '\'' {
+				kind = sqlString
+			}
+			text := strings.TrimSuffix(sql[start+1:i], string(ch))
+			toks = append(toks, sqlToken{text: text, kind: kind, pos: start, end: i, depth: depth})
+			continue
+		case isWordByte(ch):
+			for i < len(sql) && isWordByte(sql[i]) {
+				i++
+			}
+			kind := sqlWord
+			if ch >= '0' && ch <= '9' {
+				kind = sqlNumber
+			}
+			toks = append(toks, sqlToken{text: sql[start:i], kind: kind, pos: start, end: i, depth: depth})
+			continue
+		}
+		i++
+		if i < len(sql) && strings.Contains("<>!=", string(ch)) && strings.Contains("=>", string(sql[i])) {
+			i++
+		}
+		t := sqlToken{text: sql[start:i], kind: sqlPunct, pos: start, end: i, depth: depth}
+		switch t.text {
+		case "(":
+			depth++
+		case ")":
+			depth = max(depth-1, 0)
+			t.depth = depth
+		}
+		toks = append(toks, t)
+	}
+	return toks
+}
+
+func isWordByte(ch byte) bool {
+	return ch == '_' || ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= '0' && ch <= '9'
+}
+
+// clauseKeywords end a WHERE clause or the FROM clause of a query.
+var clauseKeywords = []string{"GROUP", "ORDER", "LIMIT", "HAVING", "SETTINGS", "FORMAT", "WINDOW", "QUALIFY", "UNION", "INTERSECT", "EXCEPT"}
+
+// tableKeywords can follow a table name in FROM, so they are not aliases.
+var tableKeywords = []string{"WHERE", "PREWHERE", "FINAL", "SAMPLE", "JOIN", "INNER", "LEFT", "RIGHT", "FULL", "CROSS", "ANY", "ALL", "ARRAY", "GLOBAL", "ASOF", "SEMI", "ANTI", "ON", "USING"}
+
+func isClauseKeyword(t sqlToken) bool {
+	return slices.ContainsFunc(clauseKeywords, t.keyword)
+}
+
+// guardSQL checks raw_sql before it is sent to a source's table. It must be
+// a single SELECT that reads only from table (database.table) or its own
+// subqueries and CTEs. If no condition on tsField is found, guardSQL adds
+// one for the last defaultSQLWindow and returns a note saying so. Errors
+// explain to the model how to fix the query.
+func guardSQL(sql, table, tsField string) (guarded, note string, err error) {
+	if !isSelectStatement(sql) {
+		return "", "", errors.New("raw_sql must be a single SELECT statement: INSERT, ALTER, DROP and other statements, and multiple statements separated by ';', are not allowed")
+	}
+	toks := lexSQL(sql)
+	if n := len(toks); n > 0 && toks[n-1].text == ";" {
+		toks = toks[:n-1]
+	}
+	if err := checkTables(toks, table); err != nil {
+		return "", "", err
+	}
+	if tsField == "" || hasTimePredicate(toks, tsField) {
+		return sql, "", nil
+	}
+
+	cond := fmt.Sprintf("`%s` >= now() - INTERVAL %s", tsField, defaultSQLWindow)
+	guarded, ok := injectCondition(sql, toks, table, cond)
+	if !ok {
+		return "", "", fmt.Errorf("raw_sql has no condition on the timestamp column `%s`; add one to the WHERE clause, e.g. WHERE `%s` >= now() - INTERVAL 1 HOUR", tsField, tsField)
+	}
+	return guarded, fmt.Sprintf("raw_sql had no condition on `%s`, so only the last %s was queried; add a WHERE condition on `%s` to query another time range", tsField, strings.ToLower(defaultSQLWindow), tsField), nil
+}
+
+// checkTables rejects table references other than table, the CTEs defined
+// in toks and subqueries, including table functions such as url() or
+// remote().
+func checkTables(toks []sqlToken, table string) error {
+	allowed := []string{table}
+	if _, name, ok := strings.Cut(table, "."); ok {
+		allowed = append(allowed, name)
+	}
+	for i := 0; i+2 < len(toks); i++ {
+		if toks[i].ident() && toks[i+1].keyword("AS") && toks[i+2].text == "(" {
+			allowed = append(allowed, toks[i].text)
+		}
+	}
+
+	for i, t := range toks {
+		from := t.keyword("FROM") && !inFromFunction(toks, i)
+		join := t.keyword("JOIN") && (i == 0 || !toks[i-1].keyword("ARRAY"))
+		// "x IN db.table" reads the set from a table.
+		in := t.keyword("IN") && i+1 < len(toks) && toks[i+1].ident()
+		if !from && !join && !in {
+			continue
+		}
+		for j := i + 1; j < len(toks); {
+			if toks[j].text == "(" {
+				break // a subquery, checked on its own
+			}
+			name, next := qualifiedName(toks, j)
+			if name == "" {
+				return fmt.Errorf("raw_sql has an unexpected token after %s; select FROM %s", strings.ToUpper(t.text), table)
+			}
+			if in && next < len(toks) && toks[next].text == "(" {
+				break // x IN tuple(...)
+			}
+			if next < len(toks) && toks[next].text == "(" {
+				return fmt.Errorf("raw_sql uses the table function %s(); only the source table %s can be queried", name, table)
+			}
+			if !slices.Contains(allowed, name) {
+				return fmt.Errorf("raw_sql reads from %s; only the source table %s can be queried", name, table)
+			}
+			if in {
+				break
+			}
+			// Skip an alias and continue with comma-separated tables.
+			j = next
+			if j < len(toks) && toks[j].keyword("AS") {
+				j++
+			}
+			if j < len(toks) && toks[j].ident() && !isClauseKeyword(toks[j]) && !slices.ContainsFunc(tableKeywords, toks[j].keyword) {
+				j++
+			}
+			j = skipTableModifiers(toks, j)
+			if j >= len(toks) || toks[j].text != "," {
+				break
+			}
+			j++
+		}
+	}
+	return nil
+}
+
+// skipTableModifiers steps over the FINAL, SAMPLE k [OFFSET m] and
+// [LEFT] ARRAY JOIN modifiers that can follow a table and its alias, and
+// returns the index of the token after them.
+func skipTableModifiers(toks []sqlToken, j int) int {
+	// ratio skips a sample size or offset such as 0.1, 1/10 or 10000.
+	ratio := func(j int) int {
+		for j < len(toks) && (toks[j].kind == sqlNumber || toks[j].text == "." || toks[j].text == "/") {
+			j++
+		}
+		return j
+	}
+	for j < len(toks) {
+		switch {
+		case toks[j].keyword("FINAL"):
+			j++
+		case toks[j].keyword("SAMPLE"):
+			j = ratio(j + 1)
+			if j < len(toks) && toks[j].keyword("OFFSET") {
+				j = ratio(j + 1)
+			}
+		case toks[j].keyword("ARRAY") || toks[j].keyword("LEFT") && j+1 < len(toks) && toks[j+1].keyword("ARRAY"):
+			// The commas of an ARRAY JOIN separate arrays, not tables, so
+			// its list runs to the next clause or join.
+			d := toks[j].depth
+			for j++; j < len(toks) && (toks[j].keyword("ARRAY") || toks[j].keyword("JOIN")); j++ {
+			}
+			for j < len(toks) && (toks[j].depth > d || !isClauseKeyword(toks[j]) && !slices.ContainsFunc(tableKeywords, toks[j].keyword)) {
+				j++
+			}
+		default:
+			return j
+		}
+	}
+	return j
+}
+
+// fromFunctions take FROM inside their arguments, as in EXTRACT(HOUR FROM ts).
+var fromFunctions = []string{"EXTRACT", "SUBSTRING", "TRIM"}
+
+// inFromFunction reports whether toks[i] is in the arguments of one of the
+// fromFunctions. Any other FROM, including that of a FROM-first subquery
+// such as (FROM t SELECT x), is a table reference.
+func inFromFunction(toks []sqlToken, i int) bool {
+	d := toks[i].depth
+	for j := i - 1; j > 0; j-- {
+		if toks[j].depth < d {
+			return toks[j].text == "(" && slices.ContainsFunc(fromFunctions, toks[j-1].keyword)
+		}
+	}
+	return false
+}
+
+// qualifiedName reads a possibly dotted name starting at toks[i] and returns
+// it with the index of the token after it.
+func qualifiedName(toks []sqlToken, i int) (string, int) {
+	var parts []string
+	for i < len(toks) && toks[i].ident() {
+		parts = append(parts, toks[i].text)
+		if i+2 < len(toks) && toks[i+1].text == "." {
+			i += 2
+			continue
+		}
+		i++
+		break
+	}
+	return strings.Join(parts, "."), i
+}
+
+// hasTimePredicate reports whether tsField is compared to something, either
+// directly or through functions like toDate(ts).
+func hasTimePredicate(toks []sqlToken, tsField string) bool {
+	isCompare := func(t sqlToken) bool {
+		switch t.text {
+		case "=", "==", "<", "<=", ">", ">=":
+			return t.kind == sqlPunct
+		}
+		return t.keyword("BETWEEN") || t.keyword("IN")
+	}
+	for i, t := range toks {
+		if !t.ident() || t.text != tsField {
+			continue
+		}
+		j := i + 1
+		for j < len(toks) && (toks[j].text == ")" || toks[j].text == ",") && toks[j].kind == sqlPunct {
+			j++
+		}
+		if j < len(toks) && isCompare(toks[j]) {
+			return true
+		}
+		// Walk back over opening parentheses, function names and table
+		// qualifiers.
+		k := i - 1
+		for k >= 0 && (toks[k].text == "(" || toks[k].text == "." || toks[k].kind == sqlWord && (toks[k+1].text == "(" || toks[k+1].text == ".")) {
+			k--
+		}
+		if k >= 0 && isCompare(toks[k]) {
+			return true
+		}
+	}
+	return false
+}
+
+// injectCondition adds cond to the WHERE clause of the outer query, which
+// must select directly from table. It reports false for queries it cannot
+// safely rewrite, such as a UNION, a join or a SELECT from a subquery.
+func injectCondition(sql string, toks []sqlToken, table, cond string) (string, bool) {
+	sel := slices.IndexFunc(toks, func(t sqlToken) bool { return t.depth == 0 && t.keyword("SELECT") })
+	if sel < 0 || slices.ContainsFunc(toks, func(t sqlToken) bool {
+		return t.depth == 0 && (t.keyword("UNION") || t.keyword("INTERSECT") || t.keyword("EXCEPT"))
+	}) {
+		return "", false
+	}
+	from := -1
+	for i := sel + 1; i < len(toks); i++ {
+		if toks[i].depth == 0 && toks[i].keyword("FROM") {
+			from = i
+			break
+		}
+	}
+	if from < 0 {
+		return "", false
+	}
+	if name, _ := qualifiedName(toks, from+1); name != table && !strings.HasSuffix(table, "."+name) {
+		return "", false
+	}
+
+	// end is the index of the first token after the FROM or WHERE clause.
+	where, end := -1, len(toks)
+	for i := from + 1; i < len(toks); i++ {
+		if toks[i].depth != 0 {
+			continue
+		}
+		// With a join, even of the table with itself, an unqualified
+		// timestamp column may be ambiguous.
+		if where < 0 && (toks[i].text == "," && toks[i].kind == sqlPunct || toks[i].keyword("JOIN") && !toks[i-1].keyword("ARRAY")) {
+			return "", false
+		}
+		if toks[i].keyword("WHERE") {
+			where = i
+		} else if isClauseKeyword(toks[i]) {
+			end = i
+			break
+		}
+	}
+	if where >= 0 {
+		if where+1 == end {
+			return "", false
+		}
+		last := toks[end-1].end
+		return sql[:toks[where].end] + " " + cond + " AND (" + strings.TrimLeft(sql[toks[where].end:last], " \t\r\n") + ")" + sql[last:], true
+	}
+	if end < len(toks) {
+		pos := toks[end].pos
+		return sql[:pos] + "WHERE " + cond + " " + sql[pos:], true
+	}
+	last := toks[end-1].end
+	return sql[:last] + " WHERE " + cond + sql[last:], true
+}
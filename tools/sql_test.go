@@ -0,0 +1,96 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIsSelectStatement(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want bool
+	}{
+		{"SELECT * FROM logs.app LIMIT 10", true},
+		{"  select count() from logs.app;", true},
+		{"WITH 1 AS x SELECT x", true},
+		{"(SELECT 1) UNION ALL (SELECT 2)", true},
+		{"-- recent errors\nSELECT * FROM logs.app", true},
+		{"SELECT 'a;b' FROM logs.app", true},
+		{"SELECT 1; DROP TABLE logs.app", false},
+		{"/* SELECT */ DROP TABLE logs.app", false},
+		{"INSERT INTO logs.app SELECT * FROM logs.app", false},
+		{"ALTER TABLE logs.app DELETE WHERE 1", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := isSelectStatement(tt.sql); got != tt.want {
+			t.Errorf("isSelectStatement(%q) = %v, want %v", tt.sql, got, tt.want)
+		}
+	}
+}
+
+func TestGuardSQL(t *testing.T) {
+	const window = "`timestamp` >= now() - INTERVAL 1 HOUR"
+	tests := []struct {
+		sql  string
+		want string // guarded SQL; empty when the query passes unchanged
+		err  string // substring of the rejection
+	}{
+		{sql: "SELECT * FROM logs.app WHERE timestamp > now() - INTERVAL 1 DAY"},
+		{sql: "SELECT count() FROM app WHERE toDate(`timestamp`) = today()"},
+		{sql: "SELECT * FROM logs.app AS a WHERE now() - INTERVAL 5 MINUTE <= a.timestamp"},
+		{sql: "SELECT toHour(timestamp) AS h, count() FROM logs.app WHERE timestamp BETWEEN '2026-03-01' AND '2026-03-02' GROUP BY h"},
+		{sql: "WITH errors AS (SELECT * FROM logs.app WHERE level = 'error' AND timestamp >= today()) SELECT * FROM errors"},
+		{sql: "SELECT EXTRACT(HOUR FROM timestamp) FROM logs.app WHERE timestamp >= today()"},
+		{sql: "SELECT * FROM logs.app ARRAY JOIN tags AS tag WHERE timestamp >= today()"},
+		{sql: "SELECT * FROM logs.app FINAL SAMPLE 0.1 LEFT ARRAY JOIN tags AS tag, values AS v WHERE timestamp >= today()"},
+		{sql: "SELECT substring(message FROM 2) FROM logs.app WHERE timestamp >= today()"},
+		{
+			sql:  "SELECT * FROM logs.app",
+			want: "SELECT * FROM logs.app WHERE " + window,
+		},
+		{
+			sql:  "SELECT * FROM logs.app WHERE level = 'error' OR status >= 500 ORDER BY timestamp DESC LIMIT 10;",
+			want: "SELECT * FROM logs.app WHERE " + window + " AND (level = 'error' OR status >= 500) ORDER BY timestamp DESC LIMIT 10;",
+		},
+		{
+			sql:  "SELECT level, count() FROM logs.app GROUP BY level -- by level",
+			want: "SELECT level, count() FROM logs.app WHERE " + window + " GROUP BY level -- by level",
+		},
+		{sql: "SELECT * FROM (SELECT * FROM logs.app) ORDER BY timestamp", err: "no condition on the timestamp column"},
+		{sql: "SELECT * FROM logs.app UNION ALL SELECT * FROM logs.app", err: "no condition on the timestamp column"},
+		{sql: "SELECT * FROM logs.app ARRAY JOIN tags AS tag", want: "SELECT * FROM logs.app ARRAY JOIN tags AS tag WHERE " + window},
+		{sql: "SELECT * FROM logs.app a JOIN logs.app b ON a.trace_id = b.trace_id", err: "no condition on the timestamp column"},
+		{sql: "SELECT * FROM logs.app a, logs.app b WHERE a.trace_id = b.trace_id", err: "no condition on the timestamp column"},
+		{sql: "DROP TABLE logs.app", err: "single SELECT"},
+		{sql: "SELECT 1; SELECT 2", err: "single SELECT"},
+		{sql: "SELECT * FROM system.users", err: "reads from system.users"},
+		{sql: "SELECT * FROM logs.app JOIN logs.payments USING (trace_id) WHERE timestamp > today()", err: "reads from logs.payments"},
+		{sql: "SELECT * FROM logs.app, logs.secrets WHERE timestamp > today()", err: "reads from logs.secrets"},
+		{sql: "SELECT * FROM logs.app WHERE timestamp > today() AND user IN (SELECT name FROM system.users)", err: "reads from system.users"},
+		{sql: "SELECT * FROM logs.app WHERE timestamp > today() AND user IN system.users", err: "reads from system.users"},
+		{sql: "SELECT * FROM logs.app FINAL, system.users WHERE timestamp > today()", err: "reads from system.users"},
+		{sql: "SELECT * FROM logs.app AS a SAMPLE 0.1, system.users WHERE timestamp > today()", err: "reads from system.users"},
+		{sql: "SELECT * FROM logs.app SAMPLE 1/10 OFFSET 1/2, system.users WHERE timestamp > today()", err: "reads from system.users"},
+		{sql: "SELECT * FROM logs.app WHERE timestamp > today() AND user IN (FROM system.users SELECT name)", err: "reads from system.users"},
+		{sql: "SELECT * FROM url('http://example.com/x.csv', CSV)", err: "table function url()"},
+		{sql: "SELECT * FROM `logs`.`app` WHERE level = 'x FROM system.users'", want: "SELECT * FROM `logs`.`app` WHERE " + window + " AND (level = 'x FROM system.users')"},
+	}
+	for _, tt := range tests {
+		got, note, err := guardSQL(tt.sql, "logs.app", "timestamp")
+		switch {
+		case tt.err != "":
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("guardSQL(%q) error = %v, want %q", tt.sql, err, tt.err)
+			}
+		case err != nil:
+			t.Errorf("guardSQL(%q) error = %v", tt.sql, err)
+		case tt.want == "":
+			if got != tt.sql || note != "" {
+				t.Errorf("guardSQL(%q) = %q, %q; want it unchanged", tt.sql, got, note)
+			}
+		case got != tt.want || note == "":
+			t.Errorf("guardSQL(%q) = %q, %q; want %q with a note", tt.sql, got, note, tt.want)
+		}
+	}
+}
@@ -28,13 +28,7 @@ func handleGetQueryTelemetry(ctx context.Context, request mcp.CallToolRequest, p
 		return mcp.NewToolResultError("logchef client not configured"), nil
 	}
 
-	limit := params.Limit
-	if limit <= 0 {
-		limit = 20
-	}
-	if limit > 50 {
-		limit = 50
-	}
+	limit := rowLimit(ctx, params.Limit, 20, 50)
 
 	// Build a query against system.query_log.
 	// Note: the query column is intentionally excluded to avoid leaking
@@ -64,12 +58,14 @@ ORDER BY event_time DESC
 LIMIT %d`, durationFilter, limit)
 
 	resp, err := lc.QueryLogs(ctx, params.TeamID, params.SourceID, client.LogQueryRequest{
-		RawSQL: sql,
-		Limit:  limit,
+		RawSQL:       sql,
+		Limit:        limit,
+		QueryTimeout: queryTimeout(ctx, nil),
 	})
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("query telemetry failed: %v", err)), nil
+		return mcp.NewToolResultError(toolError("query telemetry failed", err).Error()), nil
 	}
+	mcplogchef.RecordQuery(ctx, sql, len(resp.Data.Data))
 
 	out, _ := json.MarshalIndent(resp.Data, "", "  ")
 	return mcp.NewToolResultText(string(out)), nil
@@ -0,0 +1,143 @@
+package mcplogchef
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// URLPolicy restricts the Logchef URLs that HTTP callers may supply in the
+// X-Logchef-URL header. URLs from the environment, the config file and
+// named instances are trusted and never checked.
+type URLPolicy struct {
+	// Hosts are allowed host names, optionally with a port
+	// ("logchef.example.com" or "logchef.example.com:8443"), matched
+	// case-insensitively. A host without a port allows any port.
+	Hosts []string
+	// Prefixes allow URLs whose host is an IP literal inside one of the
+	// ranges. Host names are not resolved, so DNS cannot be used to slip
+	// past the list.
+	Prefixes []netip.Prefix
+	// Schemes are the allowed URL schemes. Empty allows http and https.
+	Schemes []string
+}
+
+// ParseURLPolicy builds a URLPolicy from host names and CIDR ranges (any
+// entry containing a "/") and URL schemes.
+func ParseURLPolicy(hosts, schemes []string) (URLPolicy, error) {
+	var p URLPolicy
+	for _, h := range hosts {
+		if strings.Contains(h, "/") {
+			prefix, err := netip.ParsePrefix(h)
+			if err != nil {
+				return URLPolicy{}, fmt.Errorf("invalid CIDR %q: %w", h, err)
+			}
+			p.Prefixes = append(p.Prefixes, prefix.Masked())
+			continue
+		}
+		if strings.ContainsAny(h, " @?#") {
+			return URLPolicy{}, fmt.Errorf("invalid host %q", h)
+		}
+		p.Hosts = append(p.Hosts, strings.ToLower(h))
+	}
+	for _, s := range schemes {
+		s = strings.ToLower(s)
+		if s != "http" && s != "https" {
+			return URLPolicy{}, fmt.Errorf("invalid scheme %q: must be http or https", s)
+		}
+		p.Schemes = append(p.Schemes, s)
+	}
+	return p, nil
+}
+
+// Enabled reports whether the policy restricts anything.
+func (p URLPolicy) Enabled() bool {
+	return len(p.Hosts) > 0 || len(p.Prefixes) > 0 || len(p.Schemes) > 0
+}
+
+// Check returns an error describing why rawURL is not allowed, or nil.
+func (p URLPolicy) Check(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("Logchef URL %q is not a valid absolute URL", rawURL)
+	}
+	shown := u.Redacted()
+	scheme := strings.ToLower(u.Scheme)
+	schemes := p.Schemes
+	if len(schemes) == 0 {
+		schemes = []string{"http", "https"}
+	}
+	if !slices.Contains(schemes, scheme) {
+		return fmt.Errorf("Logchef URL %s is not allowed: scheme %q is not one of %s", shown, u.Scheme, strings.Join(schemes, ", "))
+	}
+	if len(p.Hosts) == 0 && len(p.Prefixes) == 0 {
+		return nil
+	}
+
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+	if port == "" {
+		port = map[string]string{"http": "80", "https": "443"}[scheme]
+	}
+	for _, h := range p.Hosts {
+		if h == host || h == net.JoinHostPort(host, port) {
+			return nil
+		}
+	}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		for _, prefix := range p.Prefixes {
+			if prefix.Contains(addr.Unmap()) {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("Logchef URL %s is not allowed: host %s is not in this server's allowlist", shown, u.Host)
+}
+
+type logchefURLPolicyKey struct{}
+type logchefClientErrorKey struct{}
+
+// WithURLPolicy adds the policy for header-supplied Logchef URLs to the context.
+func WithURLPolicy(ctx context.Context, policy URLPolicy) context.Context {
+	return context.WithValue(ctx, logchefURLPolicyKey{}, policy)
+}
+
+// URLPolicyFromContext extracts the URL policy. If none is set, it returns
+// the zero URLPolicy, which allows any http or https URL.
+func URLPolicyFromContext(ctx context.Context) URLPolicy {
+	policy, _ := ctx.Value(logchefURLPolicyKey{}).(URLPolicy)
+	return policy
+}
+
+// withLogchefClientError records why no Logchef client could be created for
+// the session.
+func withLogchefClientError(ctx context.Context, err error) context.Context {
+	return context.WithValue(ctx, logchefClientErrorKey{}, err)
+}
+
+// LogchefClientErrorFromContext returns why the session has no Logchef
+// client, or nil.
+func LogchefClientErrorFromContext(ctx context.Context) error {
+	err, _ := ctx.Value(logchefClientErrorKey{}).(error)
+	return err
+}
+
+// ClientErrorMiddleware fails tool calls with the reason the session has no
+// Logchef client, such as a disallowed X-Logchef-URL, instead of letting
+// each tool report a generic error. Register it after any middleware that
+// swaps the client.
+func ClientErrorMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if err := LogchefClientErrorFromContext(ctx); err != nil && LogchefClientFromContext(ctx) == nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return next(ctx, req)
+	}
+}
@@ -0,0 +1,85 @@
+package mcplogchef_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	mcplogchef "github.com/mr-karan/logchef-mcp"
+	"github.com/mr-karan/logchef-mcp/client"
+)
+
+func TestURLPolicyCheck(t *testing.T) {
+	policy, err := mcplogchef.ParseURLPolicy(
+		[]string{"logchef.example.com", "LOGS.internal:8443", "10.0.0.0/8", "fd00::/8"},
+		[]string{"https"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		url     string
+		allowed bool
+	}{
+		{"https://logchef.example.com", true},
+		{"https://Logchef.Example.com:9000/", true},
+		{"https://logs.internal:8443", true},
+		{"https://logs.internal", false},
+		{"https://10.1.2.3:5173", true},
+		{"https://[fd00::1]", true},
+		{"https://11.0.0.1", false},
+		{"http://logchef.example.com", false},
+		{"https://evil.example.com", false},
+		{"https://logchef.example.com.evil.net", false},
+		{"https://logchef.example.com@evil.net", false},
+		{"logchef.example.com", false},
+	}
+	for _, tt := range tests {
+		err := policy.Check(tt.url)
+		if (err == nil) != tt.allowed {
+			t.Errorf("Check(%q) = %v, want allowed=%v", tt.url, err, tt.allowed)
+		}
+	}
+
+	if err := (mcplogchef.URLPolicy{}).Check("ftp://logchef.example.com"); err == nil {
+		t.Error("zero policy allowed ftp")
+	}
+	for _, bad := range [][]string{{"10.0.0.0/33"}, {"user@host"}} {
+		if _, err := mcplogchef.ParseURLPolicy(bad, nil); err == nil {
+			t.Errorf("ParseURLPolicy(%q) succeeded", bad)
+		}
+	}
+	if _, err := mcplogchef.ParseURLPolicy(nil, []string{"file"}); err == nil {
+		t.Error("ParseURLPolicy accepted scheme file")
+	}
+}
+
+func TestDisallowedHeaderURL(t *testing.T) {
+	policy, _ := mcplogchef.ParseURLPolicy([]string{"logchef.example.com"}, nil)
+	req := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+	req.Header.Set("X-Logchef-URL", "http://169.254.169.254/latest")
+	req.Header.Set("X-Logchef-API-Key", "caller-key")
+
+	ctx := mcplogchef.ComposedHTTPContextFunc(false, client.Config{}, policy)(context.Background(), req)
+	if mcplogchef.LogchefClientFromContext(ctx) != nil {
+		t.Fatal("client created for disallowed URL")
+	}
+
+	called := false
+	handler := mcplogchef.ClientErrorMiddleware(func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		called = true
+		return mcp.NewToolResultText("ok"), nil
+	})
+	res, err := handler(ctx, mcp.CallToolRequest{})
+	if err != nil || called {
+		t.Fatalf("handler ran for disallowed URL (err %v)", err)
+	}
+	text := res.Content[0].(mcp.TextContent).Text
+	if !res.IsError || !strings.Contains(text, "not in this server's allowlist") {
+		t.Errorf("unexpected result: %s", text)
+	}
+}